@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/game-server/controller/internal/core/repository"
+	"github.com/game-server/controller/internal/gametype"
+	"github.com/game-server/controller/pkg/config"
+	"go.uber.org/zap"
+)
+
+// runImportEgg implements the "import-egg" subcommand, which converts a
+// Pterodactyl egg into a game type definition and stores it in the database
+func runImportEgg(args []string) int {
+	fs := flag.NewFlagSet("import-egg", flag.ContinueOnError)
+	id := fs.String("id", "", "game type ID (derived from the egg name if empty)")
+	dryRun := fs.Bool("dry-run", false, "print the converted definition without saving it")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: controller import-egg [-id ID] [-dry-run] <egg.json>...")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+	if *id != "" && fs.NArg() > 1 {
+		fmt.Println("-id can only be used when importing a single egg")
+		return 2
+	}
+
+	// Convert all eggs before touching the database
+	var defs []*gametype.Definition
+	for _, path := range fs.Args() {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Printf("Failed to read %s: %v\n", path, err)
+			return 1
+		}
+
+		def, err := gametype.ImportEgg(data, *id)
+		if err != nil {
+			fmt.Printf("Failed to import %s: %v\n", path, err)
+			return 1
+		}
+
+		if gametype.NewRegistry().IsBuiltin(def.ID) {
+			fmt.Printf("Failed to import %s: cannot replace built-in game type %s\n", path, def.ID)
+			return 1
+		}
+
+		defs = append(defs, def)
+	}
+
+	if *dryRun {
+		out, _ := json.MarshalIndent(defs, "", "  ")
+		fmt.Println(string(out))
+		return 0
+	}
+
+	configPath := "config.yaml"
+	if envPath := os.Getenv("CONFIG_PATH"); envPath != "" {
+		configPath = envPath
+	}
+
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		fmt.Printf("Failed to load configuration: %v\n", err)
+		return 1
+	}
+
+	db, err := repository.NewDatabase(cfg)
+	if err != nil {
+		fmt.Printf("Failed to initialize database: %v\n", err)
+		return 1
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	gameTypeRepo := repository.NewGameTypeRepository(db, zap.NewNop())
+	for _, def := range defs {
+		if err := gameTypeRepo.Save(ctx, def); err != nil {
+			fmt.Printf("Failed to save game type %s: %v\n", def.ID, err)
+			return 1
+		}
+		fmt.Printf("Imported game type %s (%s)\n", def.ID, def.Name)
+	}
+
+	fmt.Println("Restart the controller to load newly imported game types")
+	return 0
+}
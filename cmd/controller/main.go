@@ -10,15 +10,26 @@ import (
 
 	"github.com/game-server/controller/internal/api/grpc/server"
 	"github.com/game-server/controller/internal/api/rest"
+	"github.com/game-server/controller/internal/core/models"
 	"github.com/game-server/controller/internal/core/repository"
 	"github.com/game-server/controller/internal/docker"
+	"github.com/game-server/controller/internal/gc"
+	"github.com/game-server/controller/internal/gametype"
 	"github.com/game-server/controller/internal/node"
+	"github.com/game-server/controller/internal/query"
 	"github.com/game-server/controller/internal/scheduler"
+	"github.com/game-server/controller/internal/sessions"
 	"github.com/game-server/controller/pkg/config"
+	"github.com/game-server/controller/pkg/secrets"
 	"go.uber.org/zap"
 )
 
 func main() {
+	// Subcommands
+	if len(os.Args) > 1 && os.Args[1] == "import-egg" {
+		os.Exit(runImportEgg(os.Args[2:]))
+	}
+
 	// Load configuration
 	configPath := "config.yaml"
 	if envPath := os.Getenv("CONFIG_PATH"); envPath != "" {
@@ -56,39 +67,85 @@ func main() {
 	}
 	defer db.Close()
 
-	// Initialize Docker volume manager
-	volumeMgr, err := docker.NewVolumeManager(log)
+	// Initialize secrets box used to encrypt stored secrets
+	secretsBox, err := secrets.LoadBox(cfg.SecretsKey, cfg.SecretsKeyFile)
 	if err != nil {
-		log.Warn("Failed to initialize Docker volume manager, volume cleanup will be disabled", zap.Error(err))
-		// Continue without volume manager - deletion will still work, just won't clean up volumes
+		log.Fatal("Failed to initialize secrets", zap.Error(err))
 	}
 
-	// Initialize Docker container manager
-	var containerMgr *docker.ContainerManager
-	if volumeMgr != nil {
-		containerMgr, err = docker.NewContainerManager(volumeMgr, log)
-		if err != nil {
-			log.Warn("Failed to initialize Docker container manager, dynamic node creation will be disabled", zap.Error(err))
-			// Continue without container manager - nodes can still register manually
-		}
+	if _, err := models.ParseHostPlacementStrategy(cfg.HostPlacementStrategy); err != nil {
+		log.Fatal("Invalid host placement strategy", zap.Error(err))
 	}
 
-	if volumeMgr != nil {
-		defer volumeMgr.Close()
-	}
-	if containerMgr != nil {
-		defer containerMgr.Close()
+	// Initialize the container hosts, starting with the local container
+	// runtime. Registered hosts are connected once the scheduler is up.
+	hosts := docker.NewHosts(log)
+	defer hosts.Close()
+	runtime, err := docker.NewRuntime(cfg.ContainerRuntime, cfg.PodmanSocket)
+	if err != nil {
+		log.Warn("Failed to initialize container runtime, nodes can only be placed on registered hosts",
+			zap.String("runtime", cfg.ContainerRuntime),
+			zap.Error(err))
+		// Continue without a runtime - nodes can still register manually
+	} else {
+		hosts.Add(&docker.Host{
+			ID:          models.LocalHostID,
+			Runtime:     runtime,
+			NetworkName: cfg.NodeNetworkName,
+		})
 	}
 
 	// Initialize repositories
 	nodeRepo := repository.NewNodeRepository(db, log)
 	serverRepo := repository.NewServerRepository(db, log)
+	gameTypeRepo := repository.NewGameTypeRepository(db, log)
+	sessionRepo := repository.NewSessionRepository(db, log)
+	fleetRepo := repository.NewFleetRepository(db, log)
+	templateRepo := repository.NewTemplateRepository(db, log)
+	hostRepo := repository.NewHostRepository(db, log)
+	settingsRepo := repository.NewSettingsRepository(db, log)
+
+	// A lost or replaced key would leave the stored secrets undecryptable
+	if err := verifySecretsKey(context.Background(), secretsBox, settingsRepo, serverRepo, hostRepo); err != nil {
+		log.Fatal("Secrets key cannot decrypt the stored secrets, restore the key in secrets_key or secrets_key_file",
+			zap.Error(err),
+			zap.String("secrets_key_file", cfg.SecretsKeyFile))
+	}
 
 	// Initialize node manager
-	nodeMgr := node.NewManager(nodeRepo, serverRepo, volumeMgr, containerMgr, cfg, log)
+	nodeMgr := node.NewManager(nodeRepo, serverRepo, hosts, cfg, log)
+
+	// Initialize game type registry with built-in and imported game types
+	gameTypes := gametype.NewRegistry()
+	importedGameTypes, err := gameTypeRepo.List(context.Background())
+	if err != nil {
+		log.Warn("Failed to load imported game types", zap.Error(err))
+	}
+	for _, def := range importedGameTypes {
+		gameTypes.Register(def)
+	}
 
 	// Initialize scheduler
-	sched := scheduler.NewScheduler(nodeRepo, serverRepo, nodeMgr, log)
+	sched := scheduler.NewScheduler(nodeRepo, serverRepo, fleetRepo, templateRepo, hostRepo, nodeMgr, gameTypes, secretsBox, cfg, log)
+
+	// Connect the registered hosts
+	if err := sched.LoadHosts(context.Background()); err != nil {
+		log.Warn("Failed to load hosts", zap.Error(err))
+	}
+
+	// Recover node state so nodes are known before their agents reconnect
+	if err := nodeMgr.Recover(context.Background()); err != nil {
+		log.Warn("Failed to recover nodes", zap.Error(err))
+	}
+
+	// Initialize game query prober
+	prober := query.NewProber(serverRepo, nodeMgr, cfg, log)
+
+	// Initialize player session tracker
+	tracker := sessions.NewTracker(sessionRepo, serverRepo, nodeMgr, log)
+
+	// Initialize orphan collector
+	collector := gc.NewCollector(nodeRepo, hosts, cfg, log)
 
 	// Initialize gRPC server
 	grpcServer, err := server.NewGRPCServer(cfg, nodeMgr, sched, log)
@@ -97,7 +154,20 @@ func main() {
 	}
 
 	// Initialize REST API server
-	restServer := rest.NewServer(cfg, nodeMgr, serverRepo, sched, containerMgr, log)
+	restServer := rest.NewServer(cfg, nodeMgr, serverRepo, gameTypeRepo, sched, gameTypes, prober, tracker, collector, log)
+
+	// Start background workers
+	runCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+	go sched.Run(runCtx)
+	go sched.RunHibernation(runCtx)
+	go sched.RunFleets(runCtx)
+	go prober.Run(runCtx)
+	go tracker.Run(runCtx)
+	go nodeMgr.RunReconnectGrace(runCtx)
+	go nodeMgr.StartHealthCheck(runCtx)
+	go nodeMgr.RunContainerStats(runCtx)
+	go collector.Run(runCtx)
 
 	// Start gRPC server
 	go func() {
@@ -123,6 +193,7 @@ func main() {
 	<-quit
 
 	log.Info("Shutting down servers...")
+	stopWorkers()
 
 	// Create context with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -140,6 +211,56 @@ func main() {
 	log.Info("Servers stopped")
 }
 
+// verifySecretsKey checks that the secrets key is the one the stored secrets
+// were encrypted with. The first start stores a key check; databases from
+// before the check are verified against a stored secret instead.
+func verifySecretsKey(
+	ctx context.Context,
+	box *secrets.Box,
+	settingsRepo *repository.SettingsRepository,
+	serverRepo *repository.ServerRepository,
+	hostRepo *repository.HostRepository,
+) error {
+	check, err := settingsRepo.Get(ctx, secretsKeyCheckSetting)
+	if err != nil {
+		return err
+	}
+	if check != "" {
+		return box.VerifyKeyCheck(check)
+	}
+
+	stored, err := serverRepo.AnyRCONPassword(ctx)
+	if err != nil {
+		return err
+	}
+	if stored == "" {
+		hosts, err := hostRepo.List(ctx)
+		if err != nil {
+			return err
+		}
+		for _, host := range hosts {
+			if host.TLSKey != "" {
+				stored = host.TLSKey
+				break
+			}
+		}
+	}
+	if stored != "" {
+		if _, err := box.Decrypt(stored); err != nil {
+			return secrets.ErrKeyMismatch
+		}
+	}
+
+	check, err = box.KeyCheck()
+	if err != nil {
+		return err
+	}
+	return settingsRepo.Create(ctx, secretsKeyCheckSetting, check)
+}
+
+// secretsKeyCheckSetting is the setting holding the secrets key check
+const secretsKeyCheckSetting = "secrets_key_check"
+
 // StartGameServerController is the main entry point
 func StartGameServerController() error {
 	main()
@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/game-server/controller/internal/core/models"
+	"github.com/game-server/controller/internal/scheduler"
+	"go.uber.org/zap"
+)
+
+// FleetHandler handles REST API requests for fleets and allocations
+type FleetHandler struct {
+	scheduler *scheduler.Scheduler
+	logger    *zap.Logger
+}
+
+// NewFleetHandler creates a new fleet handler
+func NewFleetHandler(scheduler *scheduler.Scheduler, logger *zap.Logger) *FleetHandler {
+	return &FleetHandler{
+		scheduler: scheduler,
+		logger:    logger,
+	}
+}
+
+// RegisterRoutes registers the fleet routes
+func (h *FleetHandler) RegisterRoutes(router *gin.RouterGroup) {
+	fleets := router.Group("/fleets")
+	{
+		fleets.GET("", h.ListFleets)
+		fleets.POST("", h.CreateFleet)
+		fleets.GET("/:id", h.GetFleet)
+		fleets.PUT("/:id", h.UpdateFleet)
+		fleets.DELETE("/:id", h.DeleteFleet)
+		fleets.POST("/:id/allocate", h.Allocate)
+		fleets.GET("/:id/autoscale", h.PreviewAutoscale)
+		fleets.GET("/:id/events", h.ListEvents)
+	}
+
+	router.POST("/servers/:id/release", h.Release)
+}
+
+// ListFleets returns all fleets with the state of their servers
+func (h *FleetHandler) ListFleets(c *gin.Context) {
+	fleets, err := h.scheduler.ListFleets(c.Request.Context())
+	if err != nil {
+		h.logger.Error("Failed to list fleets", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to list fleets",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"fleets": fleets,
+		"count":  len(fleets),
+	})
+}
+
+// CreateFleet creates a new fleet
+func (h *FleetHandler) CreateFleet(c *gin.Context) {
+	var req models.CreateFleetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	fleet, err := h.scheduler.CreateFleet(c.Request.Context(), &req)
+	if err != nil {
+		if respondValidationError(c, err) {
+			return
+		}
+		h.respondError(c, "Failed to create fleet", err, "")
+		return
+	}
+
+	c.JSON(http.StatusCreated, fleet)
+}
+
+// GetFleet returns a fleet with the state of its servers
+func (h *FleetHandler) GetFleet(c *gin.Context) {
+	id := c.Param("id")
+
+	status, err := h.scheduler.GetFleet(c.Request.Context(), id)
+	if err != nil {
+		h.respondError(c, "Failed to get fleet", err, id)
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// UpdateFleet changes the size or template of a fleet
+func (h *FleetHandler) UpdateFleet(c *gin.Context) {
+	id := c.Param("id")
+
+	var req models.UpdateFleetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	fleet, err := h.scheduler.UpdateFleet(c.Request.Context(), id, req)
+	if err != nil {
+		if respondValidationError(c, err) {
+			return
+		}
+		h.respondError(c, "Failed to update fleet", err, id)
+		return
+	}
+
+	c.JSON(http.StatusOK, fleet)
+}
+
+// DeleteFleet deletes a fleet and its servers
+func (h *FleetHandler) DeleteFleet(c *gin.Context) {
+	id := c.Param("id")
+
+	if err := h.scheduler.DeleteFleet(c.Request.Context(), id); err != nil {
+		h.respondError(c, "Failed to delete fleet", err, id)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// Allocate hands a ready server of a fleet to a matchmaker
+func (h *FleetHandler) Allocate(c *gin.Context) {
+	id := c.Param("id")
+
+	allocation, err := h.scheduler.Allocate(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, scheduler.ErrNoReadyServer) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":   "No ready server",
+				"message": err.Error(),
+			})
+			return
+		}
+		h.respondError(c, "Failed to allocate server", err, id)
+		return
+	}
+
+	c.JSON(http.StatusOK, allocation)
+}
+
+// PreviewAutoscale returns what the autoscaler would decide for a fleet now
+func (h *FleetHandler) PreviewAutoscale(c *gin.Context) {
+	id := c.Param("id")
+
+	decision, err := h.scheduler.PreviewAutoscale(c.Request.Context(), id)
+	if err != nil {
+		h.respondError(c, "Failed to preview autoscaling", err, id)
+		return
+	}
+
+	c.JSON(http.StatusOK, decision)
+}
+
+// ListEvents returns the most recent autoscaler decisions of a fleet
+func (h *FleetHandler) ListEvents(c *gin.Context) {
+	id := c.Param("id")
+
+	limit, ok := intQuery(c, "limit", 50, 500)
+	if !ok {
+		return
+	}
+
+	events, err := h.scheduler.ListFleetEvents(c.Request.Context(), id, limit)
+	if err != nil {
+		h.respondError(c, "Failed to list fleet events", err, id)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"fleet_id": id,
+		"events":   events,
+		"count":    len(events),
+	})
+}
+
+// Release returns an allocated server to its fleet's ready pool
+func (h *FleetHandler) Release(c *gin.Context) {
+	id := c.Param("id")
+
+	if err := h.scheduler.Release(c.Request.Context(), id); err != nil {
+		if errors.Is(err, scheduler.ErrNotAllocated) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":   "Server is not allocated",
+				"message": err.Error(),
+			})
+			return
+		}
+		h.logger.Error("Failed to release server",
+			zap.Error(err),
+			zap.String("server_id", id))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to release server",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Server released",
+	})
+}
+
+// respondError writes 404 for unknown fleets, 400 for invalid autoscale
+// policies and 500 for other errors
+func (h *FleetHandler) respondError(c *gin.Context, message string, err error, fleetID string) {
+	if errors.Is(err, scheduler.ErrFleetNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Fleet not found",
+			"message": err.Error(),
+		})
+		return
+	}
+	if errors.Is(err, scheduler.ErrInvalidAutoscalePolicy) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid autoscale policy",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	h.logger.Error(message,
+		zap.Error(err),
+		zap.String("fleet_id", fleetID))
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error":   message,
+		"message": err.Error(),
+	})
+}
@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/game-server/controller/internal/core/repository"
+	"github.com/game-server/controller/internal/gametype"
+	"go.uber.org/zap"
+)
+
+// GameTypeHandler handles REST API requests for game types
+type GameTypeHandler struct {
+	registry     *gametype.Registry
+	gameTypeRepo *repository.GameTypeRepository
+	logger       *zap.Logger
+}
+
+// NewGameTypeHandler creates a new game type handler
+func NewGameTypeHandler(registry *gametype.Registry, gameTypeRepo *repository.GameTypeRepository, logger *zap.Logger) *GameTypeHandler {
+	return &GameTypeHandler{
+		registry:     registry,
+		gameTypeRepo: gameTypeRepo,
+		logger:       logger,
+	}
+}
+
+// RegisterRoutes registers the game type routes
+func (h *GameTypeHandler) RegisterRoutes(router *gin.RouterGroup) {
+	gameTypes := router.Group("/game-types")
+	{
+		gameTypes.GET("", h.ListGameTypes)
+		gameTypes.GET("/:id", h.GetGameType)
+		gameTypes.GET("/:id/schema", h.GetGameTypeSchema)
+		gameTypes.DELETE("/:id", h.DeleteGameType)
+		gameTypes.POST("/import/pterodactyl", h.ImportPterodactylEgg)
+	}
+}
+
+// ListGameTypes returns the list of supported game types
+func (h *GameTypeHandler) ListGameTypes(c *gin.Context) {
+	definitions := h.registry.List()
+
+	gameTypes := make([]gin.H, 0, len(definitions))
+	for _, def := range definitions {
+		gameTypes = append(gameTypes, gin.H{
+			"id":           def.ID,
+			"name":         def.Name,
+			"description":  def.Description,
+			"source":       def.Source,
+			"default_port": def.DefaultPort,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"game_types": gameTypes,
+	})
+}
+
+// GetGameType returns a single game type definition
+func (h *GameTypeHandler) GetGameType(c *gin.Context) {
+	id := c.Param("id")
+
+	def, err := h.registry.Get(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Game type not found",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, def)
+}
+
+// GetGameTypeSchema returns the settings and env var schemas of a game type
+func (h *GameTypeHandler) GetGameTypeSchema(c *gin.Context) {
+	id := c.Param("id")
+
+	def, err := h.registry.Get(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Game type not found",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"game_type": def.ID,
+		"settings":  def.Settings,
+		"env_vars":  def.EnvVars,
+	})
+}
+
+// ImportPterodactylEgg converts a Pterodactyl egg into a game type definition
+func (h *GameTypeHandler) ImportPterodactylEgg(c *gin.Context) {
+	data, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	def, err := gametype.ImportEgg(data, c.Query("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid egg",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if h.registry.IsBuiltin(def.ID) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "Game type already exists",
+			"message": "Cannot replace built-in game type: " + def.ID,
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err := h.gameTypeRepo.Save(ctx, def); err != nil {
+		h.logger.Error("Failed to save game type", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to save game type",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	h.registry.Register(def)
+
+	h.logger.Info("Imported Pterodactyl egg",
+		zap.String("game_type", def.ID),
+		zap.String("name", def.Name))
+
+	c.JSON(http.StatusCreated, gin.H{
+		"game_type": def,
+		"message":   "Game type imported successfully",
+	})
+}
+
+// DeleteGameType deletes an imported game type
+func (h *GameTypeHandler) DeleteGameType(c *gin.Context) {
+	id := c.Param("id")
+
+	if _, err := h.registry.Get(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Game type not found",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if h.registry.IsBuiltin(id) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Cannot delete game type",
+			"message": "Built-in game types cannot be deleted",
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	servers, fleets, err := h.gameTypeRepo.CountUsers(ctx, id)
+	if err != nil {
+		h.logger.Error("Failed to delete game type", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to delete game type",
+			"message": err.Error(),
+		})
+		return
+	}
+	if servers > 0 || fleets > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "Game type in use",
+			"message": fmt.Sprintf("%d servers and %d fleets use game type %s", servers, fleets, id),
+		})
+		return
+	}
+
+	if err := h.gameTypeRepo.Delete(ctx, id); err != nil {
+		h.logger.Error("Failed to delete game type", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to delete game type",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	h.registry.Unregister(id)
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/game-server/controller/internal/gc"
+	"go.uber.org/zap"
+)
+
+// GCHandler handles REST API requests for orphaned node containers and
+// volumes
+type GCHandler struct {
+	collector *gc.Collector
+	logger    *zap.Logger
+}
+
+// NewGCHandler creates a new GC handler
+func NewGCHandler(collector *gc.Collector, logger *zap.Logger) *GCHandler {
+	return &GCHandler{
+		collector: collector,
+		logger:    logger,
+	}
+}
+
+// RegisterRoutes registers the GC routes
+func (h *GCHandler) RegisterRoutes(router *gin.RouterGroup) {
+	gcGroup := router.Group("/gc")
+	{
+		gcGroup.GET("/orphans", h.ListOrphans)
+		gcGroup.POST("/collect", h.Collect)
+	}
+}
+
+// ListOrphans reports orphaned node containers and volumes without removing
+// them
+func (h *GCHandler) ListOrphans(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+
+	report, err := h.collector.Scan(c.Request.Context())
+	if err != nil {
+		h.logger.Error("Failed to scan for orphans", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to scan for orphans",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// Collect removes orphans past the grace period now. The body may set
+// dry_run, which defaults to gc_dry_run.
+func (h *GCHandler) Collect(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+
+	var req struct {
+		DryRun *bool `json:"dry_run"`
+	}
+	c.ShouldBindJSON(&req)
+
+	report, err := h.collector.Collect(c.Request.Context(), req.DryRun)
+	if err != nil {
+		h.logger.Error("Failed to collect orphans", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to collect orphans",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// available writes an error if Docker is not configured
+func (h *GCHandler) available(c *gin.Context) bool {
+	if h.collector == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Container manager not available",
+			"message": "Docker is not configured or unavailable",
+		})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/game-server/controller/internal/core/models"
+	"github.com/game-server/controller/internal/scheduler"
+	"go.uber.org/zap"
+)
+
+// HostHandler handles REST API requests for the Docker hosts nodes are
+// placed on
+type HostHandler struct {
+	scheduler *scheduler.Scheduler
+	logger    *zap.Logger
+}
+
+// NewHostHandler creates a new host handler
+func NewHostHandler(scheduler *scheduler.Scheduler, logger *zap.Logger) *HostHandler {
+	return &HostHandler{
+		scheduler: scheduler,
+		logger:    logger,
+	}
+}
+
+// RegisterRoutes registers the host routes
+func (h *HostHandler) RegisterRoutes(router *gin.RouterGroup) {
+	hosts := router.Group("/hosts")
+	{
+		hosts.GET("", h.ListHosts)
+		hosts.POST("", h.RegisterHost)
+		hosts.GET("/:id", h.GetHost)
+		hosts.PUT("/:id", h.UpdateHost)
+		hosts.DELETE("/:id", h.RemoveHost)
+	}
+}
+
+// ListHosts returns the local host and the registered hosts with their state
+func (h *HostHandler) ListHosts(c *gin.Context) {
+	hosts, err := h.scheduler.ListHosts(c.Request.Context())
+	if err != nil {
+		h.logger.Error("Failed to list hosts", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to list hosts",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"hosts": hosts,
+		"count": len(hosts),
+	})
+}
+
+// RegisterHost registers a Docker endpoint that nodes can be placed on
+func (h *HostHandler) RegisterHost(c *gin.Context) {
+	var req models.CreateHostRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	host, err := h.scheduler.RegisterHost(c.Request.Context(), &req)
+	if err != nil {
+		if respondHostError(c, err) {
+			return
+		}
+		h.logger.Error("Failed to register host", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to register host",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, host)
+}
+
+// GetHost returns a host with its state and the nodes on it
+func (h *HostHandler) GetHost(c *gin.Context) {
+	id := c.Param("id")
+
+	host, err := h.scheduler.GetHost(c.Request.Context(), id)
+	if err != nil {
+		if respondHostError(c, err) {
+			return
+		}
+		h.logger.Error("Failed to get host",
+			zap.Error(err),
+			zap.String("host_id", id))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get host",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, host)
+}
+
+// UpdateHost changes the controller address or capacity of a host
+func (h *HostHandler) UpdateHost(c *gin.Context) {
+	id := c.Param("id")
+
+	var req models.UpdateHostRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	host, err := h.scheduler.UpdateHost(c.Request.Context(), id, &req)
+	if err != nil {
+		if respondHostError(c, err) {
+			return
+		}
+		h.logger.Error("Failed to update host",
+			zap.Error(err),
+			zap.String("host_id", id))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to update host",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, host)
+}
+
+// RemoveHost unregisters a host without nodes
+func (h *HostHandler) RemoveHost(c *gin.Context) {
+	id := c.Param("id")
+
+	if err := h.scheduler.RemoveHost(c.Request.Context(), id); err != nil {
+		if respondHostError(c, err) {
+			return
+		}
+		h.logger.Error("Failed to remove host",
+			zap.Error(err),
+			zap.String("host_id", id))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to remove host",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// respondHostError writes the response for host errors and reports whether
+// err was one
+func respondHostError(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, scheduler.ErrHostNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Host not found",
+			"message": err.Error(),
+		})
+	case errors.Is(err, scheduler.ErrInvalidHost):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+	case errors.Is(err, scheduler.ErrHostInUse), errors.Is(err, scheduler.ErrNoHostCapacity):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "Host conflict",
+			"message": err.Error(),
+		})
+	case errors.Is(err, scheduler.ErrHostUnreachable):
+		c.JSON(http.StatusBadGateway, gin.H{
+			"error":   "Host unreachable",
+			"message": err.Error(),
+		})
+	default:
+		return false
+	}
+	return true
+}
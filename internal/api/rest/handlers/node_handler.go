@@ -1,7 +1,15 @@
 package handlers
 
 import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/game-server/controller/internal/core/models"
@@ -17,7 +25,6 @@ import (
 type NodeHandler struct {
 	nodeRepo     *node.Manager
 	scheduler    *scheduler.Scheduler
-	containerMgr *docker.ContainerManager
 	cfg          *config.Config
 	logger       *zap.Logger
 }
@@ -26,14 +33,12 @@ type NodeHandler struct {
 func NewNodeHandler(
 	nodeRepo *node.Manager,
 	scheduler *scheduler.Scheduler,
-	containerMgr *docker.ContainerManager,
 	cfg *config.Config,
 	logger *zap.Logger,
 ) *NodeHandler {
 	return &NodeHandler{
 		nodeRepo:     nodeRepo,
 		scheduler:    scheduler,
-		containerMgr: containerMgr,
 		cfg:          cfg,
 		logger:       logger,
 	}
@@ -51,6 +56,8 @@ func (h *NodeHandler) RegisterRoutes(router *gin.RouterGroup) {
 		nodes.GET("/:id/status", h.GetNodeStatus)
 		nodes.GET("/:id/metrics", h.GetNodeMetrics)
 		nodes.POST("/:id/action", h.NodeAction)
+		nodes.GET("/:id/drain", h.GetDrainProgress)
+		nodes.GET("/:id/container/logs", h.GetContainerLogs)
 	}
 }
 
@@ -120,39 +127,61 @@ func (h *NodeHandler) CreateNode(c *gin.Context) {
 		return
 	}
 
-	// Check if container manager is available
-	if h.containerMgr == nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Container manager not available",
-			"message": "Docker is not configured or unavailable",
-		})
-		return
-	}
-
 	// Set default port if not provided
 	port := req.Port
 	if port == 0 {
 		port = 8080
 	}
 
-	// Generate node ID
-	nodeID := generateNodeID()
+	ctx := c.Request.Context()
+	resources := h.nodeResources(&req)
+	hostID, release, err := h.scheduler.SelectHost(ctx, req.HostID, resources)
+	if err != nil {
+		if respondHostError(c, err) {
+			return
+		}
+		h.logger.Error("Failed to select host", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to select host",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Record the node first, so the agent registers against a known ID and
+	// its limits survive restarts
+	node := &models.Node{
+		ID:                generateNodeID(),
+		Name:              req.Name,
+		Port:              port,
+		Status:            models.NodeStatusOffline,
+		GameType:          req.GameType,
+		HostID:            hostID,
+		HeartbeatInterval: h.cfg.DefaultHeartbeatInterval,
+		NodeResources:     resources,
+	}
+	nodeID := node.ID
 
-	// Create node container config
-	containerCfg := &docker.NodeContainerConfig{
-		NodeID:         nodeID,
-		NodeName:       req.Name,
-		Image:          h.cfg.NodeAgentImage,
-		ControllerAddr: h.cfg.GetGRPCAddress(),
-		GameTypes:      []string{req.GameType},
-		NetworkName:    h.cfg.NodeNetworkName,
+	err = h.nodeRepo.CreateNode(ctx, node)
+	release()
+	if err != nil {
+		h.logger.Error("Failed to create node", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to create node",
+			"message": err.Error(),
+		})
+		return
 	}
 
 	// Start the node agent container (this will create volumes automatically)
-	ctx := c.Request.Context()
-	containerID, err := h.containerMgr.CreateNodeContainer(ctx, containerCfg)
+	containerID, err := h.nodeRepo.CreateNodeContainer(ctx, h.nodeRepo.NodeContainerConfig(node))
 	if err != nil {
 		h.logger.Error("Failed to create node container", zap.Error(err))
+		if err := h.nodeRepo.DeleteNode(context.Background(), nodeID); err != nil {
+			h.logger.Warn("Failed to remove node after container creation failed",
+				zap.Error(err),
+				zap.String("node_id", nodeID))
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to create node container",
 			"message": err.Error(),
@@ -163,6 +192,7 @@ func (h *NodeHandler) CreateNode(c *gin.Context) {
 	h.logger.Info("Node container started",
 		zap.String("node_id", nodeID),
 		zap.String("container_id", containerID),
+		zap.String("host_id", hostID),
 		zap.String("name", req.Name))
 
 	// Return the node info - the node agent will register itself via gRPC
@@ -173,7 +203,12 @@ func (h *NodeHandler) CreateNode(c *gin.Context) {
 			"port":              port,
 			"status":            "pending",
 			"game_type":         req.GameType,
+			"host_id":           hostID,
 			"container_id":      containerID,
+			"max_servers":       node.MaxServers,
+			"cpu_cores":         node.CPUCores,
+			"memory_mb":         node.MemoryMB,
+			"storage_mb":        node.StorageMB,
 		},
 		"message": "Node container started, waiting for registration",
 	})
@@ -201,6 +236,28 @@ func (h *NodeHandler) UpdateNode(c *gin.Context) {
 		return
 	}
 
+	// Resize first, a resize that is refused leaves the node unchanged
+	resources := node.NodeResources
+	if req.MaxServers != nil {
+		resources.MaxServers = *req.MaxServers
+	}
+	if req.CPUCores != nil {
+		resources.CPUCores = *req.CPUCores
+	}
+	if req.MemoryMB != nil {
+		resources.MemoryMB = *req.MemoryMB
+	}
+	if req.StorageMB != nil {
+		resources.StorageMB = *req.StorageMB
+	}
+	if resources != node.NodeResources {
+		if err := h.scheduler.ResizeNode(c.Request.Context(), id, resources); err != nil {
+			h.respondContainerError(c, "Failed to resize node", err, id)
+			return
+		}
+		node.NodeResources = resources
+	}
+
 	// Update fields
 	if req.Name != nil {
 		node.Name = *req.Name
@@ -233,23 +290,13 @@ func (h *NodeHandler) UpdateNode(c *gin.Context) {
 	})
 }
 
-// DeleteNode deletes a node by removing its container and volumes first
+// DeleteNode deletes a node with its container and volumes
 func (h *NodeHandler) DeleteNode(c *gin.Context) {
 	id := c.Param("id")
 
 	ctx := c.Request.Context()
 
-	// First, remove the container if container manager is available
-	if h.containerMgr != nil {
-		if err := h.containerMgr.RemoveNodeContainer(ctx, id); err != nil {
-			h.logger.Warn("Failed to remove node container (may not exist)",
-				zap.Error(err),
-				zap.String("node_id", id))
-			// Continue with deletion even if container removal fails
-		}
-	}
-
-	// Delete the node (this will also delete volumes via node manager)
+	// The node manager removes the container and volumes on the node's host
 	if err := h.nodeRepo.DeleteNode(ctx, id); err != nil {
 		h.logger.Error("Failed to delete node", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -306,7 +353,12 @@ func (h *NodeHandler) NodeAction(c *gin.Context) {
 	id := c.Param("id")
 
 	var req struct {
-		Action string `json:"action" binding:"required"`
+		Action       string `json:"action" binding:"required"`
+		Policy       string `json:"policy"`
+		Force        bool   `json:"force"`         // drain only
+		Image        string `json:"image"`         // upgrade only
+		AgentVersion string `json:"agent_version"` // upgrade only, checked after registration
+		models.StopOptions
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -339,12 +391,106 @@ func (h *NodeHandler) NodeAction(c *gin.Context) {
 			"message": "Node set to maintenance mode",
 		})
 
-	case "refresh":
-		// Refresh node connection
+	case "drain":
+		// Stop placements and evacuate servers in the background
+		policy, err := models.ParseDrainPolicy(req.Policy)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid drain policy",
+				"message": err.Error(),
+			})
+			return
+		}
+		progress, err := h.scheduler.DrainNode(c.Request.Context(), id, models.DrainOptions{
+			Policy:      policy,
+			Force:       req.Force,
+			StopOptions: req.StopOptions,
+		})
+		if err != nil {
+			h.logger.Error("Failed to drain node",
+				zap.Error(err),
+				zap.String("node_id", id))
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Failed to drain node",
+				"message": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusAccepted, progress)
+
+	case "undrain":
+		// Cancel a drain and put the node back into service
+		if err := h.scheduler.UndrainNode(c.Request.Context(), id); err != nil {
+			if errors.Is(err, scheduler.ErrNotDraining) {
+				c.JSON(http.StatusConflict, gin.H{
+					"error":   "Node is not draining",
+					"message": err.Error(),
+				})
+				return
+			}
+			h.logger.Error("Failed to undrain node",
+				zap.Error(err),
+				zap.String("node_id", id))
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Failed to undrain node",
+				"message": err.Error(),
+			})
+			return
+		}
 		c.JSON(http.StatusOK, gin.H{
-			"message": "Node refresh requested",
+			"message": "Node back in service",
 		})
 
+	case "start":
+		if err := h.scheduler.StartNode(c.Request.Context(), id); err != nil {
+			h.respondContainerError(c, "Failed to start node", err, id)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Node container started, waiting for registration",
+		})
+
+	case "stop":
+		if err := h.scheduler.StopNode(c.Request.Context(), id); err != nil {
+			h.respondContainerError(c, "Failed to stop node", err, id)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Node container stopped",
+		})
+
+	case "restart":
+		if err := h.scheduler.RestartNode(c.Request.Context(), id); err != nil {
+			h.respondContainerError(c, "Failed to restart node", err, id)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Node container restarted, waiting for registration",
+		})
+
+	case "recreate":
+		containerID, err := h.scheduler.RecreateNode(c.Request.Context(), id)
+		if err != nil {
+			h.respondContainerError(c, "Failed to recreate node", err, id)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"message":      "Node container recreated, waiting for registration",
+			"container_id": containerID,
+		})
+
+	case "upgrade":
+		// Waiting for the agent can outlast the server's write timeout, and
+		// a half-done upgrade must not stop when the client disconnects
+		extendWriteDeadline(c, scheduler.NodeUpgradeDuration(), h.logger)
+		ctx := context.WithoutCancel(c.Request.Context())
+		upgrade, err := h.scheduler.UpgradeNode(ctx, id, req.Image, req.AgentVersion)
+		if err != nil {
+			h.respondContainerError(c, "Failed to upgrade node", err, id)
+			return
+		}
+		c.JSON(http.StatusOK, upgrade)
+
 	default:
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error":   "Invalid action",
@@ -353,6 +499,223 @@ func (h *NodeHandler) NodeAction(c *gin.Context) {
 	}
 }
 
+// respondContainerError writes the response for a failed node container action
+func (h *NodeHandler) respondContainerError(c *gin.Context, message string, err error, nodeID string) {
+	if errors.Is(err, scheduler.ErrNodeHasActiveServers) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "Node has active servers",
+			"message": err.Error(),
+		})
+		return
+	}
+	if respondHostError(c, err) {
+		return
+	}
+
+	h.logger.Error(message,
+		zap.Error(err),
+		zap.String("node_id", nodeID))
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error":   message,
+		"message": err.Error(),
+	})
+}
+
+// GetDrainProgress returns the progress of the current or last drain of a node
+func (h *NodeHandler) GetDrainProgress(c *gin.Context) {
+	id := c.Param("id")
+
+	progress, err := h.scheduler.GetDrainProgress(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "No drain found",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, progress)
+}
+
+// GetContainerLogs returns the logs of a node's container as plain text.
+// With follow, new lines are streamed as server-sent events until the client
+// disconnects or the container stops; with download, the logs are sent as a
+// gzipped attachment.
+func (h *NodeHandler) GetContainerLogs(c *gin.Context) {
+	id := c.Param("id")
+
+	opts, ok := logOptionsQuery(c)
+	if !ok {
+		return
+	}
+	download := c.Query("download") == "true"
+	if download && opts.Follow {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid query parameters",
+			"message": "follow and download cannot be combined",
+		})
+		return
+	}
+
+	if _, err := h.nodeRepo.GetNode(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Node not found",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	logs, err := h.nodeRepo.NodeContainerLogs(c.Request.Context(), id, opts)
+	if err != nil {
+		if errors.Is(err, docker.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "Node container not found",
+				"message": err.Error(),
+			})
+			return
+		}
+		h.logger.Error("Failed to get node container logs",
+			zap.Error(err),
+			zap.String("node_id", id))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get node container logs",
+			"message": err.Error(),
+		})
+		return
+	}
+	defer logs.Close()
+
+	switch {
+	case opts.Follow:
+		h.streamLogs(c, logs, id)
+
+	case download:
+		filename := fmt.Sprintf("node-%s-%s.log.gz", id, time.Now().UTC().Format("20060102-150405"))
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+		c.Header("Content-Type", "application/gzip")
+		c.Status(http.StatusOK)
+
+		gz := gzip.NewWriter(c.Writer)
+		if _, err := io.Copy(gz, logs); err != nil {
+			h.logger.Warn("Failed to send node container logs",
+				zap.Error(err),
+				zap.String("node_id", id))
+		}
+		gz.Close()
+
+	default:
+		c.Header("Content-Type", "text/plain; charset=utf-8")
+		c.Status(http.StatusOK)
+		if _, err := io.Copy(c.Writer, logs); err != nil {
+			h.logger.Warn("Failed to send node container logs",
+				zap.Error(err),
+				zap.String("node_id", id))
+		}
+	}
+}
+
+// streamLogs sends each log line as a "log" event, followed by an "end" event
+// once the container stops
+func (h *NodeHandler) streamLogs(c *gin.Context, logs io.Reader, nodeID string) {
+	// The stream lasts as long as the client wants it, past the server's
+	// write timeout
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		h.logger.Warn("Failed to clear write deadline", zap.Error(err))
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	scanner := bufio.NewScanner(logs)
+	scanner.Buffer(make([]byte, 64*1024), maxLogLineSize)
+	for scanner.Scan() {
+		c.SSEvent("log", scanner.Text())
+		c.Writer.Flush()
+	}
+	if err := scanner.Err(); err != nil && c.Request.Context().Err() == nil {
+		h.logger.Warn("Failed to stream node container logs",
+			zap.Error(err),
+			zap.String("node_id", nodeID))
+		c.SSEvent("error", err.Error())
+		c.Writer.Flush()
+		return
+	}
+	c.SSEvent("end", "")
+	c.Writer.Flush()
+}
+
+// maxLogLineSize is the longest log line streamed as one event
+const maxLogLineSize = 1024 * 1024
+
+// logOptionsQuery parses the tail, since, timestamps and follow query
+// parameters of a logs request, writing a 400 response if one is invalid.
+// tail is a number of lines or "all", 100 by default; since is an RFC 3339
+// time or a duration before now like "15m".
+func logOptionsQuery(c *gin.Context) (docker.LogOptions, bool) {
+	opts := docker.LogOptions{
+		Tail:       100,
+		Timestamps: c.Query("timestamps") == "true",
+		Follow:     c.Query("follow") == "true",
+	}
+
+	invalid := func(message string) (docker.LogOptions, bool) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid query parameters",
+			"message": message,
+		})
+		return docker.LogOptions{}, false
+	}
+
+	if raw := c.Query("tail"); raw == "all" {
+		opts.Tail = -1
+	} else if raw != "" {
+		tail, err := strconv.Atoi(raw)
+		if err != nil || tail < 0 {
+			return invalid("tail must be a number of lines or all")
+		}
+		opts.Tail = tail
+	}
+
+	if raw := c.Query("since"); raw != "" {
+		if d, err := time.ParseDuration(raw); err == nil && d > 0 {
+			opts.Since = time.Now().Add(-d)
+		} else if t, err := time.Parse(time.RFC3339, raw); err == nil {
+			opts.Since = t
+		} else {
+			return invalid("since must be an RFC 3339 time or a duration like 15m")
+		}
+	}
+
+	return opts, true
+}
+
+// nodeResources returns the container limits of a create request, with
+// defaults from the config for the ones left out
+func (h *NodeHandler) nodeResources(req *models.CreateNodeRequest) models.NodeResources {
+	resources := models.NodeResources{
+		MaxServers: req.MaxServers,
+		CPUCores:   req.CPUCores,
+		MemoryMB:   req.MemoryMB,
+		StorageMB:  req.StorageMB,
+	}
+	if resources.MaxServers == 0 {
+		resources.MaxServers = h.cfg.DefaultNodeMaxServers
+	}
+	if resources.CPUCores == 0 {
+		resources.CPUCores = h.cfg.DefaultNodeCPUCores
+	}
+	if resources.MemoryMB == 0 {
+		resources.MemoryMB = h.cfg.DefaultNodeMemoryMB
+	}
+	if resources.StorageMB == 0 {
+		resources.StorageMB = h.cfg.DefaultNodeStorageMB
+	}
+	return resources
+}
+
 // Helper function to count nodes by status
 func countNodesByStatus(nodes []*models.Node, status models.NodeStatus) int {
 	count := 0
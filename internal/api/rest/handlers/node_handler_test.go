@@ -0,0 +1,135 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/game-server/controller/internal/api/rest/handlers"
+	"github.com/game-server/controller/internal/core/models"
+	"github.com/game-server/controller/internal/core/repository/repositorytest"
+	"github.com/game-server/controller/internal/docker"
+	"github.com/game-server/controller/internal/docker/dockertest"
+	"github.com/game-server/controller/internal/node"
+	"github.com/game-server/controller/internal/scheduler"
+	"github.com/game-server/controller/pkg/config"
+	"go.uber.org/zap"
+)
+
+// newNodeRouter serves the node routes with nodes kept in memory and node
+// containers on a fake local runtime
+func newNodeRouter() (*gin.Engine, *repositorytest.Nodes, *dockertest.Runtime) {
+	gin.SetMode(gin.TestMode)
+	logger := zap.NewNop()
+
+	cfg := &config.Config{
+		NodeAgentImage:           "node:test",
+		DefaultHeartbeatInterval: 30,
+		DefaultNodeMaxServers:    10,
+		DefaultNodeCPUCores:      2,
+		DefaultNodeMemoryMB:      4096,
+		DefaultNodeStorageMB:     20480,
+		LocalHostPlacement:       true,
+	}
+
+	runtime := dockertest.NewRuntime()
+	hosts := docker.NewHosts(logger)
+	hosts.Add(&docker.Host{ID: models.LocalHostID, Runtime: runtime})
+
+	nodes := repositorytest.NewNodes()
+	nodeMgr := node.NewManager(nodes, repositorytest.NewServers(), hosts, cfg, logger)
+	sched := scheduler.NewScheduler(nil, nil, nil, nil, nil, nodeMgr, nil, nil, cfg, logger)
+
+	router := gin.New()
+	handlers.NewNodeHandler(nodeMgr, sched, cfg, logger).RegisterRoutes(router.Group("/api/v1"))
+
+	return router, nodes, runtime
+}
+
+func postNode(router *gin.Engine, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/nodes", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestCreateNodeStartsContainerWithLimits(t *testing.T) {
+	router, nodes, runtime := newNodeRouter()
+
+	w := postNode(router, `{"name": "node-1", "game_type": "minecraft", "host_id": "local", "cpu_cores": 4, "memory_mb": 8192}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want 201: %s", w.Code, w.Body.String())
+	}
+
+	var resp struct {
+		Node struct {
+			ID          string `json:"id"`
+			HostID      string `json:"host_id"`
+			ContainerID string `json:"container_id"`
+		} `json:"node"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Node.HostID != models.LocalHostID {
+		t.Errorf("host_id = %q, want local", resp.Node.HostID)
+	}
+
+	stored, err := nodes.GetByID(context.Background(), resp.Node.ID)
+	if err != nil || stored == nil {
+		t.Fatalf("node %s was not stored: %v", resp.Node.ID, err)
+	}
+	want := models.NodeResources{MaxServers: 10, CPUCores: 4, MemoryMB: 8192, StorageMB: 20480}
+	if stored.NodeResources != want {
+		t.Errorf("stored limits = %+v, want %+v", stored.NodeResources, want)
+	}
+
+	spec, ok := runtime.Spec(resp.Node.ContainerID)
+	if !ok {
+		t.Fatalf("container %s was not created", resp.Node.ContainerID)
+	}
+	if spec.Labels["game-server.node-id"] != resp.Node.ID {
+		t.Errorf("container node-id label = %q, want %q", spec.Labels["game-server.node-id"], resp.Node.ID)
+	}
+	if spec.NanoCPUs != 4e9 || spec.MemoryBytes != 8192*1024*1024 {
+		t.Errorf("container limits = %d CPUs, %d bytes, want 4 CPUs and 8 GiB", spec.NanoCPUs, spec.MemoryBytes)
+	}
+	if containers := runtime.Containers(); len(containers) != 1 || containers[0].Status != "running" {
+		t.Errorf("containers = %+v, want one running", containers)
+	}
+}
+
+func TestCreateNodeRemovesNodeWhenContainerFails(t *testing.T) {
+	router, nodes, runtime := newNodeRouter()
+	runtime.Fail("CreateContainer", errors.New("no space left on device"))
+
+	w := postNode(router, `{"name": "node-1", "game_type": "minecraft", "host_id": "local"}`)
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500: %s", w.Code, w.Body.String())
+	}
+
+	if stored, _ := nodes.List(context.Background(), nil); len(stored) != 0 {
+		t.Errorf("nodes after a failed create = %+v, want none", stored)
+	}
+	if volumes := runtime.Volumes(); len(volumes) != 0 {
+		t.Errorf("volumes after a failed create = %v, want none", volumes)
+	}
+}
+
+func TestCreateNodeRejectsMissingGameType(t *testing.T) {
+	router, nodes, _ := newNodeRouter()
+
+	w := postNode(router, `{"name": "node-1"}`)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", w.Code)
+	}
+	if stored, _ := nodes.List(context.Background(), nil); len(stored) != 0 {
+		t.Errorf("a node was stored for an invalid request")
+	}
+}
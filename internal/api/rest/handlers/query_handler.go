@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/game-server/controller/internal/core/repository"
+	"github.com/game-server/controller/internal/query"
+	"go.uber.org/zap"
+)
+
+// QueryHandler handles REST API requests for game query probes
+type QueryHandler struct {
+	prober     *query.Prober
+	serverRepo *repository.ServerRepository
+	logger     *zap.Logger
+}
+
+// NewQueryHandler creates a new query handler
+func NewQueryHandler(prober *query.Prober, serverRepo *repository.ServerRepository, logger *zap.Logger) *QueryHandler {
+	return &QueryHandler{
+		prober:     prober,
+		serverRepo: serverRepo,
+		logger:     logger,
+	}
+}
+
+// RegisterRoutes registers the query probe routes
+func (h *QueryHandler) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/servers/:id/query", h.GetServerQuery)
+	router.GET("/probes", h.ListProbes)
+}
+
+// GetServerQuery returns the latest probe of a server, probing it first if
+// refresh=true or if it has not been probed yet
+func (h *QueryHandler) GetServerQuery(c *gin.Context) {
+	id := c.Param("id")
+
+	result, exists := h.prober.Get(id)
+	if exists && c.Query("refresh") != "true" {
+		c.JSON(http.StatusOK, result)
+		return
+	}
+
+	server, err := h.serverRepo.GetByID(c.Request.Context(), id)
+	if err != nil {
+		h.logger.Error("Failed to get server", zap.Error(err), zap.String("server_id", id))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get server",
+			"message": err.Error(),
+		})
+		return
+	}
+	if server == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Server not found",
+		})
+		return
+	}
+
+	if query.ProtocolFor(server) == query.ProtocolNone {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Query not supported",
+			"message": "server has no query protocol",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, h.prober.ProbeServer(c.Request.Context(), server))
+}
+
+// ListProbes returns the latest probe results of all running servers
+func (h *QueryHandler) ListProbes(c *gin.Context) {
+	results := h.prober.List(c.Query("unresponsive") == "true")
+
+	c.JSON(http.StatusOK, gin.H{
+		"probes": results,
+		"count":  len(results),
+	})
+}
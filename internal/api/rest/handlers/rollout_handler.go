@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/game-server/controller/internal/core/models"
+	"github.com/game-server/controller/internal/scheduler"
+	"go.uber.org/zap"
+)
+
+// RolloutHandler handles REST API requests for rolling node agent upgrades
+type RolloutHandler struct {
+	scheduler *scheduler.Scheduler
+	logger    *zap.Logger
+}
+
+// NewRolloutHandler creates a new rollout handler
+func NewRolloutHandler(scheduler *scheduler.Scheduler, logger *zap.Logger) *RolloutHandler {
+	return &RolloutHandler{
+		scheduler: scheduler,
+		logger:    logger,
+	}
+}
+
+// RegisterRoutes registers the rollout routes
+func (h *RolloutHandler) RegisterRoutes(router *gin.RouterGroup) {
+	rollouts := router.Group("/rollouts")
+	{
+		rollouts.GET("", h.ListRollouts)
+		rollouts.POST("", h.StartRollout)
+		rollouts.GET("/:id", h.GetRollout)
+		rollouts.POST("/:id/pause", h.PauseRollout)
+		rollouts.POST("/:id/resume", h.ResumeRollout)
+		rollouts.POST("/:id/cancel", h.CancelRollout)
+	}
+}
+
+// ListRollouts returns all rollouts since the controller started
+func (h *RolloutHandler) ListRollouts(c *gin.Context) {
+	rollouts := h.scheduler.ListRollouts()
+
+	c.JSON(http.StatusOK, gin.H{
+		"rollouts": rollouts,
+		"count":    len(rollouts),
+	})
+}
+
+// StartRollout starts a rolling upgrade of node agents
+func (h *RolloutHandler) StartRollout(c *gin.Context) {
+	var req models.RolloutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	rollout, err := h.scheduler.StartRollout(c.Request.Context(), req)
+	if err != nil {
+		if respondRolloutError(c, err) {
+			return
+		}
+		h.logger.Error("Failed to start rollout", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to start rollout",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, rollout)
+}
+
+// GetRollout returns the progress of a rollout
+func (h *RolloutHandler) GetRollout(c *gin.Context) {
+	rollout, err := h.scheduler.GetRollout(c.Param("id"))
+	if err != nil {
+		respondRolloutError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rollout)
+}
+
+// PauseRollout pauses a running rollout after its current batch
+func (h *RolloutHandler) PauseRollout(c *gin.Context) {
+	rollout, err := h.scheduler.PauseRollout(c.Param("id"))
+	if err != nil {
+		respondRolloutError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rollout)
+}
+
+// ResumeRollout resumes a paused rollout, retrying failed nodes
+func (h *RolloutHandler) ResumeRollout(c *gin.Context) {
+	rollout, err := h.scheduler.ResumeRollout(c.Param("id"))
+	if err != nil {
+		respondRolloutError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rollout)
+}
+
+// CancelRollout cancels a running or paused rollout
+func (h *RolloutHandler) CancelRollout(c *gin.Context) {
+	rollout, err := h.scheduler.CancelRollout(c.Param("id"))
+	if err != nil {
+		respondRolloutError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rollout)
+}
+
+// respondRolloutError writes a client error if err is a rollout error
+func respondRolloutError(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, scheduler.ErrRolloutNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Rollout not found",
+			"message": err.Error(),
+		})
+	case errors.Is(err, scheduler.ErrRolloutInProgress), errors.Is(err, scheduler.ErrRolloutState):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "Rollout conflict",
+			"message": err.Error(),
+		})
+	case errors.Is(err, scheduler.ErrInvalidRollout):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+	default:
+		return false
+	}
+	return true
+}
@@ -1,10 +1,15 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/game-server/controller/internal/core/models"
+	"github.com/game-server/controller/internal/gametype"
+	"github.com/game-server/controller/internal/minecraft"
 	"github.com/game-server/controller/internal/node"
 	"github.com/game-server/controller/internal/scheduler"
 	"go.uber.org/zap"
@@ -39,7 +44,17 @@ func (h *ServerHandler) RegisterRoutes(router *gin.RouterGroup) {
 		servers.GET("/:id/status", h.GetServerStatus)
 		servers.GET("/:id/logs", h.GetServerLogs)
 		servers.GET("/:id/metrics", h.GetServerMetrics)
+		servers.GET("/:id/properties", h.GetServerProperties)
+		servers.POST("/:id/rcon", h.ExecuteRCON)
+		servers.GET("/:id/players/:list", h.ListPlayers)
+		servers.POST("/:id/players/:list", h.AddPlayer)
+		servers.DELETE("/:id/players/:list/:name", h.RemovePlayer)
+		servers.POST("/:id/wake", h.WakeServer)
+		servers.POST("/:id/migrate", h.MigrateServer)
+		servers.POST("/:id/clone", h.CloneServer)
 	}
+
+	router.GET("/hibernation", h.GetHibernationReport)
 }
 
 // ListServers returns a list of all servers
@@ -135,9 +150,17 @@ func (h *ServerHandler) CreateServer(c *gin.Context) {
 		return
 	}
 
+	// Restoring a template's world can outlast the server's write timeout
+	if req.TemplateID != "" {
+		extendWriteDeadline(c, scheduler.CreateServerDuration(true), h.logger)
+	}
+
 	ctx := c.Request.Context()
 	result, err := h.scheduler.CreateServer(ctx, &req)
 	if err != nil {
+		if respondValidationError(c, err) || respondTemplateError(c, err) {
+			return
+		}
 		h.logger.Error("Failed to create server", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to create server",
@@ -166,13 +189,28 @@ func (h *ServerHandler) UpdateServer(c *gin.Context) {
 		return
 	}
 
+	// A requested restart is waited for and can outlast the server's write
+	// timeout
+	if req.Restart {
+		extendWriteDeadline(c, scheduler.RestartDuration(models.StopOptions{}), h.logger)
+	}
+
 	ctx := c.Request.Context()
 	if err := h.scheduler.UpdateServer(ctx, id, req); err != nil {
+		if respondValidationError(c, err) {
+			return
+		}
 		h.logger.Error("Failed to update server", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{
+		response := gin.H{
 			"error":   "Failed to update server",
 			"message": err.Error(),
-		})
+		}
+		var restartErr *scheduler.RestartError
+		if errors.As(err, &restartErr) {
+			response["error"] = "Server updated but restart failed"
+			response["phase"] = restartErr.Phase
+		}
+		c.JSON(http.StatusInternalServerError, response)
 		return
 	}
 
@@ -209,6 +247,7 @@ func (h *ServerHandler) ServerAction(c *gin.Context) {
 
 	var req struct {
 		Action string `json:"action" binding:"required"`
+		models.StopOptions
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -220,6 +259,18 @@ func (h *ServerHandler) ServerAction(c *gin.Context) {
 
 	ctx := c.Request.Context()
 
+	// Graceful stops and restarts wait for the node and can outlast the
+	// server's write timeout
+	var wait time.Duration
+	if req.Action == "restart" {
+		wait = scheduler.RestartDuration(req.StopOptions)
+	} else if req.Action == "stop" && req.Graceful {
+		wait = scheduler.GracefulStopDuration(req.StopOptions)
+	}
+	if wait > 0 {
+		extendWriteDeadline(c, wait, h.logger)
+	}
+
 	switch req.Action {
 	case "start":
 		if err := h.scheduler.StartServer(ctx, id); err != nil {
@@ -234,6 +285,20 @@ func (h *ServerHandler) ServerAction(c *gin.Context) {
 		})
 
 	case "stop":
+		if req.Graceful {
+			if err := h.scheduler.StopServerGracefully(ctx, id, req.StopOptions); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"error":   "Failed to stop server",
+					"message": err.Error(),
+				})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{
+				"message": "Server stopped",
+			})
+			return
+		}
+
 		if err := h.scheduler.StopServer(ctx, id); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"error":   "Failed to stop server",
@@ -246,15 +311,20 @@ func (h *ServerHandler) ServerAction(c *gin.Context) {
 		})
 
 	case "restart":
-		if err := h.scheduler.RestartServer(ctx, id); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
+		if err := h.scheduler.RestartServer(ctx, id, req.StopOptions); err != nil {
+			response := gin.H{
 				"error":   "Failed to restart server",
 				"message": err.Error(),
-			})
+			}
+			var restartErr *scheduler.RestartError
+			if errors.As(err, &restartErr) {
+				response["phase"] = restartErr.Phase
+			}
+			c.JSON(http.StatusInternalServerError, response)
 			return
 		}
 		c.JSON(http.StatusOK, gin.H{
-			"message": "Server restarting...",
+			"message": "Server restarted",
 		})
 
 	case "reinstall":
@@ -353,3 +423,358 @@ func (h *ServerHandler) GetServerMetrics(c *gin.Context) {
 		"metrics":   metrics,
 	})
 }
+
+// GetServerProperties returns the rendered server.properties of a server and
+// the drift report against the file last reported by the node
+func (h *ServerHandler) GetServerProperties(c *gin.Context) {
+	id := c.Param("id")
+
+	status, err := h.scheduler.GetPropertiesStatus(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Server properties not available",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// ExecuteRCON runs a console command on a server over RCON
+func (h *ServerHandler) ExecuteRCON(c *gin.Context) {
+	id := c.Param("id")
+
+	var req struct {
+		Command string `json:"command" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	response, err := h.scheduler.ExecuteRCON(c.Request.Context(), id, req.Command)
+	if err != nil {
+		h.logger.Error("Failed to execute RCON command",
+			zap.Error(err),
+			zap.String("server_id", id))
+		c.JSON(http.StatusBadGateway, gin.H{
+			"error":   "Failed to execute RCON command",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"server_id": id,
+		"command":   req.Command,
+		"response":  response,
+	})
+}
+
+// ListPlayers returns the entries of a whitelist, ops or bans list
+func (h *ServerHandler) ListPlayers(c *gin.Context) {
+	id := c.Param("id")
+
+	list, err := minecraft.ParsePlayerList(c.Param("list"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid player list",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	entries, err := h.scheduler.ListPlayers(c.Request.Context(), id, list)
+	if err != nil {
+		h.logger.Error("Failed to list players",
+			zap.Error(err),
+			zap.String("server_id", id),
+			zap.String("list", string(list)))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to list players",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"server_id": id,
+		"list":      list,
+		"players":   entries,
+		"count":     len(entries),
+	})
+}
+
+// AddPlayer adds a player to a whitelist, ops or bans list
+func (h *ServerHandler) AddPlayer(c *gin.Context) {
+	id := c.Param("id")
+
+	list, err := minecraft.ParsePlayerList(c.Param("list"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid player list",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	var req models.PlayerListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+	if err := minecraft.ValidatePlayerName(req.Name); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	entry, err := h.scheduler.AddPlayer(c.Request.Context(), id, list, req)
+	if err != nil {
+		if errors.Is(err, scheduler.ErrOpLevelRunning) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":   "Server is running",
+				"message": err.Error(),
+			})
+			return
+		}
+		h.logger.Error("Failed to add player",
+			zap.Error(err),
+			zap.String("server_id", id),
+			zap.String("list", string(list)))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to add player",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, entry)
+}
+
+// RemovePlayer removes a player from a whitelist, ops or bans list
+func (h *ServerHandler) RemovePlayer(c *gin.Context) {
+	id := c.Param("id")
+	name := c.Param("name")
+
+	list, err := minecraft.ParsePlayerList(c.Param("list"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid player list",
+			"message": err.Error(),
+		})
+		return
+	}
+	if err := minecraft.ValidatePlayerName(name); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if err := h.scheduler.RemovePlayer(c.Request.Context(), id, list, name); err != nil {
+		if errors.Is(err, scheduler.ErrPlayerNotInList) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "Player not found",
+				"message": err.Error(),
+			})
+			return
+		}
+		h.logger.Error("Failed to remove player",
+			zap.Error(err),
+			zap.String("server_id", id),
+			zap.String("list", string(list)))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to remove player",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// WakeServer starts a hibernating server
+func (h *ServerHandler) WakeServer(c *gin.Context) {
+	id := c.Param("id")
+
+	if err := h.scheduler.WakeServer(c.Request.Context(), id); err != nil {
+		if errors.Is(err, scheduler.ErrNotHibernating) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":   "Server is not hibernating",
+				"message": err.Error(),
+			})
+			return
+		}
+		h.logger.Error("Failed to wake server",
+			zap.Error(err),
+			zap.String("server_id", id))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to wake server",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Server waking up...",
+	})
+}
+
+// MigrateServer moves a server to another node and waits until it is done
+func (h *ServerHandler) MigrateServer(c *gin.Context) {
+	id := c.Param("id")
+
+	var req models.MigrateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Copying the data can outlast the server's write timeout
+	extendWriteDeadline(c, scheduler.MigrationDuration(req.StopOptions), h.logger)
+
+	// A migration rolls back when cancelled, so it must not stop when the
+	// client disconnects
+	ctx := context.WithoutCancel(c.Request.Context())
+	if err := h.scheduler.MigrateServer(ctx, id, req.TargetNodeID, req.StopOptions); err != nil {
+		if errors.Is(err, scheduler.ErrSameNode) || errors.Is(err, node.ErrCrossHost) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid target node",
+				"message": err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, scheduler.ErrMigrationInProgress) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":   "Server is being migrated",
+				"message": err.Error(),
+			})
+			return
+		}
+		response := gin.H{
+			"error":   "Failed to migrate server",
+			"message": err.Error(),
+		}
+		var migrationErr *scheduler.MigrationError
+		if errors.As(err, &migrationErr) {
+			response["phase"] = migrationErr.Phase
+		}
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Server migrated",
+		"node_id": req.TargetNodeID,
+	})
+}
+
+// CloneServer creates a copy of a server, optionally with its world
+func (h *ServerHandler) CloneServer(c *gin.Context) {
+	id := c.Param("id")
+
+	var req models.CloneServerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	extendWriteDeadline(c, scheduler.CreateServerDuration(req.IncludeWorld), h.logger)
+
+	result, err := h.scheduler.CloneServer(c.Request.Context(), id, req)
+	if err != nil {
+		if respondValidationError(c, err) {
+			return
+		}
+		h.logger.Error("Failed to clone server",
+			zap.Error(err),
+			zap.String("server_id", id))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to clone server",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"server_id":   result.ServerID,
+		"server_info": result.ServerInfo,
+		"message":     result.Message,
+	})
+}
+
+// GetHibernationReport returns the capacity freed by hibernating servers
+func (h *ServerHandler) GetHibernationReport(c *gin.Context) {
+	report, err := h.scheduler.GetHibernationReport(c.Request.Context())
+	if err != nil {
+		h.logger.Error("Failed to get hibernation report", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get hibernation report",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// respondValidationError writes field-level errors if err is a schema validation error
+func respondValidationError(c *gin.Context, err error) bool {
+	var validationErr *gametype.ValidationError
+	if !errors.As(err, &validationErr) {
+		return false
+	}
+
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error":   "Invalid configuration",
+		"message": validationErr.Error(),
+		"errors":  validationErr.Errors,
+	})
+	return true
+}
+
+// respondTemplateError writes a client error if err is about the template or
+// config of a create request
+func respondTemplateError(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, scheduler.ErrTemplateNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Template not found",
+			"message": err.Error(),
+		})
+	case errors.Is(err, scheduler.ErrInvalidTemplate), errors.Is(err, scheduler.ErrMissingConfig):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+	default:
+		return false
+	}
+	return true
+}
+
+// extendWriteDeadline lets a handler that runs for up to d respond after the
+// server's write timeout
+func extendWriteDeadline(c *gin.Context, d time.Duration, logger *zap.Logger) {
+	deadline := time.Now().Add(d + 10*time.Second)
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(deadline); err != nil {
+		logger.Warn("Failed to extend write deadline", zap.Error(err))
+	}
+}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/game-server/controller/internal/sessions"
+	"go.uber.org/zap"
+)
+
+// SessionHandler handles REST API requests for player sessions and analytics
+type SessionHandler struct {
+	tracker *sessions.Tracker
+	logger  *zap.Logger
+}
+
+// NewSessionHandler creates a new session handler
+func NewSessionHandler(tracker *sessions.Tracker, logger *zap.Logger) *SessionHandler {
+	return &SessionHandler{
+		tracker: tracker,
+		logger:  logger,
+	}
+}
+
+// RegisterRoutes registers the session and analytics routes
+func (h *SessionHandler) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/servers/:id/sessions", h.ListSessions)
+	router.GET("/servers/:id/sessions/online", h.ListOnlinePlayers)
+	router.GET("/servers/:id/analytics/peaks", h.GetDailyPeaks)
+	router.GET("/servers/:id/analytics/unique-players", h.GetWeeklyUniquePlayers)
+	router.GET("/analytics/usage", h.GetUsage)
+}
+
+// ListSessions returns the session history of a server, optionally for one player
+func (h *SessionHandler) ListSessions(c *gin.Context) {
+	id := c.Param("id")
+	player := c.Query("player")
+
+	limit, ok := intQuery(c, "limit", 50, 500)
+	if !ok {
+		return
+	}
+
+	history, err := h.tracker.History(c.Request.Context(), id, player, limit)
+	if err != nil {
+		h.respondError(c, "Failed to list player sessions", err, id)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"server_id": id,
+		"sessions":  history,
+		"count":     len(history),
+	})
+}
+
+// ListOnlinePlayers returns the players currently online on a server
+func (h *SessionHandler) ListOnlinePlayers(c *gin.Context) {
+	id := c.Param("id")
+
+	online, err := h.tracker.OnlinePlayers(c.Request.Context(), id)
+	if err != nil {
+		h.respondError(c, "Failed to list online players", err, id)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"server_id": id,
+		"players":   online,
+		"count":     len(online),
+	})
+}
+
+// GetDailyPeaks returns the peak concurrent players of a server per day
+func (h *SessionHandler) GetDailyPeaks(c *gin.Context) {
+	id := c.Param("id")
+
+	days, ok := intQuery(c, "days", 30, 365)
+	if !ok {
+		return
+	}
+
+	peaks, err := h.tracker.DailyPeaks(c.Request.Context(), id, days)
+	if err != nil {
+		h.respondError(c, "Failed to compute peak players", err, id)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"server_id": id,
+		"days":      peaks,
+	})
+}
+
+// GetWeeklyUniquePlayers returns the unique players of a server per week
+func (h *SessionHandler) GetWeeklyUniquePlayers(c *gin.Context) {
+	id := c.Param("id")
+
+	weeks, ok := intQuery(c, "weeks", 12, 104)
+	if !ok {
+		return
+	}
+
+	unique, err := h.tracker.WeeklyUniquePlayers(c.Request.Context(), id, weeks)
+	if err != nil {
+		h.respondError(c, "Failed to compute unique players", err, id)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"server_id": id,
+		"weeks":     unique,
+	})
+}
+
+// GetUsage returns player activity of all servers, to find unused servers
+func (h *SessionHandler) GetUsage(c *gin.Context) {
+	days, ok := intQuery(c, "days", 7, 365)
+	if !ok {
+		return
+	}
+
+	usage, err := h.tracker.Usage(c.Request.Context(), days)
+	if err != nil {
+		h.respondError(c, "Failed to compute server usage", err, "")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"days":    days,
+		"servers": usage,
+	})
+}
+
+func (h *SessionHandler) respondError(c *gin.Context, message string, err error, serverID string) {
+	h.logger.Error(message, zap.Error(err), zap.String("server_id", serverID))
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error":   message,
+		"message": err.Error(),
+	})
+}
+
+// intQuery parses a positive integer query parameter, writing a 400 response
+// if it is invalid
+func intQuery(c *gin.Context, name string, defaultValue, max int) (int, bool) {
+	raw := c.Query(name)
+	if raw == "" {
+		return defaultValue, true
+	}
+
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < 1 || value > max {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid query parameters",
+			"message": name + " must be between 1 and " + strconv.Itoa(max),
+		})
+		return 0, false
+	}
+
+	return value, true
+}
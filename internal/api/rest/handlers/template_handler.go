@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/game-server/controller/internal/core/models"
+	"github.com/game-server/controller/internal/scheduler"
+	"go.uber.org/zap"
+)
+
+// TemplateHandler handles REST API requests for server templates
+type TemplateHandler struct {
+	scheduler *scheduler.Scheduler
+	logger    *zap.Logger
+}
+
+// NewTemplateHandler creates a new template handler
+func NewTemplateHandler(scheduler *scheduler.Scheduler, logger *zap.Logger) *TemplateHandler {
+	return &TemplateHandler{
+		scheduler: scheduler,
+		logger:    logger,
+	}
+}
+
+// RegisterRoutes registers the template routes
+func (h *TemplateHandler) RegisterRoutes(router *gin.RouterGroup) {
+	templates := router.Group("/templates")
+	{
+		templates.GET("", h.ListTemplates)
+		templates.POST("", h.CreateTemplate)
+		templates.GET("/:id", h.GetTemplate)
+		templates.DELETE("/:id", h.DeleteTemplate)
+	}
+}
+
+// ListTemplates returns all templates
+func (h *TemplateHandler) ListTemplates(c *gin.Context) {
+	templates, err := h.scheduler.ListTemplates(c.Request.Context())
+	if err != nil {
+		h.logger.Error("Failed to list templates", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to list templates",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"templates": templates,
+		"count":     len(templates),
+	})
+}
+
+// CreateTemplate saves a template from a config or an existing server
+func (h *TemplateHandler) CreateTemplate(c *gin.Context) {
+	var req models.CreateTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Archiving a world can outlast the server's write timeout
+	if req.IncludeWorld {
+		extendWriteDeadline(c, scheduler.CreateServerDuration(true), h.logger)
+	}
+
+	template, err := h.scheduler.CreateTemplate(c.Request.Context(), &req)
+	if err != nil {
+		if respondValidationError(c, err) || respondTemplateError(c, err) {
+			return
+		}
+		h.logger.Error("Failed to create template", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to create template",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, template)
+}
+
+// GetTemplate returns a template
+func (h *TemplateHandler) GetTemplate(c *gin.Context) {
+	id := c.Param("id")
+
+	template, err := h.scheduler.GetTemplate(c.Request.Context(), id)
+	if err != nil {
+		if respondTemplateError(c, err) {
+			return
+		}
+		h.logger.Error("Failed to get template",
+			zap.Error(err),
+			zap.String("template_id", id))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get template",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+// DeleteTemplate deletes a template and its world archive
+func (h *TemplateHandler) DeleteTemplate(c *gin.Context) {
+	id := c.Param("id")
+
+	if err := h.scheduler.DeleteTemplate(c.Request.Context(), id); err != nil {
+		if respondTemplateError(c, err) {
+			return
+		}
+		h.logger.Error("Failed to delete template",
+			zap.Error(err),
+			zap.String("template_id", id))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to delete template",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
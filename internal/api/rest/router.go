@@ -12,9 +12,12 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/game-server/controller/internal/api/rest/handlers"
 	"github.com/game-server/controller/internal/core/repository"
-	"github.com/game-server/controller/internal/docker"
+	"github.com/game-server/controller/internal/gc"
+	"github.com/game-server/controller/internal/gametype"
 	"github.com/game-server/controller/internal/node"
+	"github.com/game-server/controller/internal/query"
 	"github.com/game-server/controller/internal/scheduler"
+	"github.com/game-server/controller/internal/sessions"
 	"github.com/game-server/controller/pkg/config"
 	"go.uber.org/zap"
 )
@@ -26,8 +29,12 @@ type Server struct {
 	cfg          *config.Config
 	nodeRepo     *node.Manager
 	serverRepo   *repository.ServerRepository
+	gameTypeRepo *repository.GameTypeRepository
 	scheduler    *scheduler.Scheduler
-	containerMgr *docker.ContainerManager
+	gameTypes    *gametype.Registry
+	prober       *query.Prober
+	tracker      *sessions.Tracker
+	collector    *gc.Collector
 	logger       *zap.Logger
 }
 
@@ -36,8 +43,12 @@ func NewServer(
 	cfg *config.Config,
 	nodeRepo *node.Manager,
 	serverRepo *repository.ServerRepository,
+	gameTypeRepo *repository.GameTypeRepository,
 	scheduler *scheduler.Scheduler,
-	containerMgr *docker.ContainerManager,
+	gameTypes *gametype.Registry,
+	prober *query.Prober,
+	tracker *sessions.Tracker,
+	collector *gc.Collector,
 	logger *zap.Logger,
 ) *Server {
 	// Set Gin mode based on environment
@@ -55,8 +66,12 @@ func NewServer(
 		cfg:          cfg,
 		nodeRepo:     nodeRepo,
 		serverRepo:   serverRepo,
+		gameTypeRepo: gameTypeRepo,
 		scheduler:    scheduler,
-		containerMgr: containerMgr,
+		gameTypes:    gameTypes,
+		prober:       prober,
+		tracker:      tracker,
+		collector:    collector,
 		logger:       logger,
 	}
 }
@@ -112,18 +127,47 @@ func (s *Server) registerRoutes() {
 	v1 := s.router.Group("/api/v1")
 	{
 		// Register node handler
-		nodeHandler := handlers.NewNodeHandler(s.nodeRepo, s.scheduler, s.containerMgr, s.cfg, s.logger)
+		nodeHandler := handlers.NewNodeHandler(s.nodeRepo, s.scheduler, s.cfg, s.logger)
 		nodeHandler.RegisterRoutes(v1)
 
 		// Register server handler
 		serverHandler := handlers.NewServerHandler(s.nodeRepo, s.scheduler, s.logger)
 		serverHandler.RegisterRoutes(v1)
 
+		// Register game type handler
+		gameTypeHandler := handlers.NewGameTypeHandler(s.gameTypes, s.gameTypeRepo, s.logger)
+		gameTypeHandler.RegisterRoutes(v1)
+
+		// Register query probe handler
+		queryHandler := handlers.NewQueryHandler(s.prober, s.serverRepo, s.logger)
+		queryHandler.RegisterRoutes(v1)
+
+		// Register player session handler
+		sessionHandler := handlers.NewSessionHandler(s.tracker, s.logger)
+		sessionHandler.RegisterRoutes(v1)
+
+		// Register fleet handler
+		fleetHandler := handlers.NewFleetHandler(s.scheduler, s.logger)
+		fleetHandler.RegisterRoutes(v1)
+
+		// Register template handler
+		templateHandler := handlers.NewTemplateHandler(s.scheduler, s.logger)
+		templateHandler.RegisterRoutes(v1)
+
+		// Register rollout handler
+		rolloutHandler := handlers.NewRolloutHandler(s.scheduler, s.logger)
+		rolloutHandler.RegisterRoutes(v1)
+
+		// Register host handler
+		hostHandler := handlers.NewHostHandler(s.scheduler, s.logger)
+		hostHandler.RegisterRoutes(v1)
+
+		// Register GC handler
+		gcHandler := handlers.NewGCHandler(s.collector, s.logger)
+		gcHandler.RegisterRoutes(v1)
+
 		// Metrics endpoint
 		v1.GET("/metrics", s.getClusterMetrics)
-
-		// Game types endpoint
-		v1.GET("/game-types", s.getGameTypes)
 	}
 }
 
@@ -181,23 +225,6 @@ func (s *Server) getClusterMetrics(c *gin.Context) {
 	})
 }
 
-// getGameTypes returns the list of supported game types
-func (s *Server) getGameTypes(c *gin.Context) {
-	// Only Minecraft is supported for now
-	gameTypes := []gin.H{
-		{
-			"id":          "minecraft",
-			"name":        "Minecraft",
-			"description": "Minecraft Java Edition server",
-			"default_port": 25565,
-		},
-	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"game_types": gameTypes,
-	})
-}
-
 // LoggerMiddleware returns a gin middleware for logging
 func LoggerMiddleware(logger *zap.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -241,7 +268,7 @@ func CORSMiddleware() gin.HandlerFunc {
 
 // RunServer starts the REST API server (standalone function for testing)
 func RunServer(cfg *config.Config, logger *zap.Logger) error {
-	server := NewServer(nil, nil, nil, nil, nil, logger)
+	server := NewServer(nil, nil, nil, nil, nil, nil, nil, nil, nil, logger)
 	
 	if err := server.Start(); err != nil {
 		return err
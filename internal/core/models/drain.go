@@ -0,0 +1,85 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// DrainPolicy is what happens to each server when its node is drained
+type DrainPolicy string
+
+const (
+	// DrainPolicyStop gracefully stops servers, they stay on the node
+	DrainPolicyStop DrainPolicy = "stop"
+	// DrainPolicyMigrate moves servers to other online nodes of their game type
+	DrainPolicyMigrate DrainPolicy = "migrate"
+)
+
+// ParseDrainPolicy validates a drain policy. An empty policy is DrainPolicyStop.
+func ParseDrainPolicy(policy string) (DrainPolicy, error) {
+	switch p := DrainPolicy(policy); p {
+	case "":
+		return DrainPolicyStop, nil
+	case DrainPolicyStop, DrainPolicyMigrate:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown drain policy %q, must be stop or migrate", policy)
+}
+
+// DrainOptions controls how a node is drained. Servers are always stopped
+// gracefully; the stop options set the countdown and timeout.
+type DrainOptions struct {
+	Policy DrainPolicy `json:"policy"`
+	// Force deletes allocated fleet servers instead of waiting until they
+	// are released or their allocation expires
+	Force bool `json:"force"`
+	StopOptions
+	// ExcludeTargets are nodes that migrated servers must not be moved to
+	ExcludeTargets []string `json:"-"`
+}
+
+// DrainState is the state of a node drain
+type DrainState string
+
+const (
+	DrainStateRunning   DrainState = "running"
+	DrainStateCompleted DrainState = "completed" // the node is empty and in maintenance
+	DrainStateFailed    DrainState = "failed"    // some servers could not be evacuated, the node stays draining
+	DrainStateCancelled DrainState = "cancelled"
+)
+
+// DrainServerState is the state of one server during a node drain
+type DrainServerState string
+
+const (
+	DrainServerPending   DrainServerState = "pending"
+	DrainServerAllocated DrainServerState = "allocated" // fleet server waiting for its allocation to end
+	DrainServerStopping  DrainServerState = "stopping"
+	DrainServerStopped   DrainServerState = "stopped"
+	DrainServerMigrating DrainServerState = "migrating"
+	DrainServerMigrated  DrainServerState = "migrated"
+	DrainServerDeleted   DrainServerState = "deleted" // fleet servers are replaced on other nodes
+	DrainServerFailed    DrainServerState = "failed"
+)
+
+// DrainProgress reports the progress of a node drain
+type DrainProgress struct {
+	NodeID      string                `json:"node_id"`
+	Policy      DrainPolicy           `json:"policy"`
+	State       DrainState            `json:"state"`
+	Total       int                   `json:"total"`
+	Done        int                   `json:"done"`
+	Failed      int                   `json:"failed"`
+	Servers     []DrainServerProgress `json:"servers"`
+	StartedAt   time.Time             `json:"started_at"`
+	CompletedAt *time.Time            `json:"completed_at,omitempty"`
+}
+
+// DrainServerProgress is the progress of one server during a node drain
+type DrainServerProgress struct {
+	ServerID     string           `json:"server_id"`
+	Name         string           `json:"name"`
+	State        DrainServerState `json:"state"`
+	TargetNodeID string           `json:"target_node_id,omitempty"` // set when the server is migrated
+	Error        string           `json:"error,omitempty"`
+}
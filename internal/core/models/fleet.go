@@ -0,0 +1,158 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Fleet is a pool of identical servers kept at a desired size
+type Fleet struct {
+	ID                   string               `json:"id" db:"id"`
+	Name                 string               `json:"name" db:"name"`
+	GameType             string               `json:"game_type" db:"game_type"`
+	Config               ServerConfig         `json:"config" db:"-"`
+	Requirements         ResourceRequirements `json:"requirements" db:"-"`
+	Replicas             int                  `json:"replicas" db:"replicas"`
+	AllocationTTLSeconds int                  `json:"allocation_ttl_seconds" db:"allocation_ttl_seconds"` // 0 means allocations never expire
+	Autoscale            *AutoscalePolicy     `json:"autoscale,omitempty" db:"-"`
+	CreatedAt            time.Time            `json:"created_at" db:"created_at"`
+	UpdatedAt            time.Time            `json:"updated_at" db:"updated_at"`
+}
+
+// FleetServerState is the state of a server within a fleet
+type FleetServerState string
+
+const (
+	FleetServerStateReady     FleetServerState = "ready"     // running and not allocated
+	FleetServerStateAllocated FleetServerState = "allocated" // handed to a matchmaker
+	FleetServerStatePending   FleetServerState = "pending"   // installing, starting or stopped
+	FleetServerStateUnhealthy FleetServerState = "unhealthy" // in error, will be replaced
+)
+
+// FleetStatus is a fleet with the state of its servers
+type FleetStatus struct {
+	Fleet     *Fleet `json:"fleet"`
+	Total     int    `json:"total"`
+	Ready     int    `json:"ready"`
+	Allocated int    `json:"allocated"`
+	Pending   int    `json:"pending"`
+	Unhealthy int    `json:"unhealthy"`
+}
+
+// CreateFleetRequest represents a request to create a fleet
+type CreateFleetRequest struct {
+	Name                 string               `json:"name" binding:"required"`
+	GameType             string               `json:"game_type" binding:"required"`
+	Config               ServerConfig         `json:"config" binding:"required"`
+	Requirements         ResourceRequirements `json:"requirements"`
+	Replicas             int                  `json:"replicas" binding:"min=0"`
+	AllocationTTLSeconds int                  `json:"allocation_ttl_seconds" binding:"min=0"`
+	Autoscale            *AutoscalePolicy     `json:"autoscale"`
+}
+
+// UpdateFleetRequest represents a request to update a fleet. The config only
+// applies to servers created afterwards.
+type UpdateFleetRequest struct {
+	Config               *ServerConfig         `json:"config"`
+	Requirements         *ResourceRequirements `json:"requirements"`
+	Replicas             *int                  `json:"replicas" binding:"omitempty,min=0"`
+	AllocationTTLSeconds *int                  `json:"allocation_ttl_seconds" binding:"omitempty,min=0"`
+	Autoscale            *AutoscalePolicy      `json:"autoscale"`
+	DisableAutoscale     bool                  `json:"disable_autoscale"`
+}
+
+// AutoscalePolicy sets a fleet's replicas from its load. The largest
+// replica count asked for by the buffer and the load target is used, within
+// MinReplicas and MaxReplicas.
+type AutoscalePolicy struct {
+	MinReplicas   int     `json:"min_replicas" binding:"min=0"`
+	MaxReplicas   int     `json:"max_replicas" binding:"min=1"`
+	BufferSize    int     `json:"buffer_size,omitempty" binding:"min=0"`           // ready servers to keep on top of allocated ones
+	BufferPercent int     `json:"buffer_percent,omitempty" binding:"min=0,max=99"` // share of replicas to keep ready
+	TargetLoad    float64 `json:"target_load,omitempty" binding:"min=0,max=1"`     // target PlayerCount/MaxPlayers across the fleet
+	DryRun        bool    `json:"dry_run"`                                         // record decisions without applying them
+}
+
+// Validate checks that the policy is consistent
+func (p *AutoscalePolicy) Validate() error {
+	if p.MaxReplicas < 1 {
+		return fmt.Errorf("max_replicas must be at least 1")
+	}
+	if p.MaxReplicas < p.MinReplicas {
+		return fmt.Errorf("max_replicas (%d) must not be less than min_replicas (%d)", p.MaxReplicas, p.MinReplicas)
+	}
+	if p.BufferPercent < 0 || p.BufferPercent > 99 {
+		return fmt.Errorf("buffer_percent must be between 1 and 99")
+	}
+	if p.BufferSize > 0 && p.BufferPercent > 0 {
+		return fmt.Errorf("buffer_size and buffer_percent are mutually exclusive")
+	}
+	if p.BufferSize == 0 && p.BufferPercent == 0 && p.TargetLoad == 0 {
+		return fmt.Errorf("one of buffer_size, buffer_percent or target_load is required")
+	}
+	// Percentages and loads of an empty fleet are zero, so without a ready
+	// server to allocate or join it would never scale up again
+	if p.BufferSize == 0 && p.MinReplicas < 1 {
+		return fmt.Errorf("min_replicas must be at least 1 with buffer_percent or target_load")
+	}
+	return nil
+}
+
+// ScaleDecision is what the autoscaler decided for a fleet and why
+type ScaleDecision struct {
+	FleetID         string  `json:"fleet_id"`
+	CurrentReplicas int     `json:"current_replicas"`
+	DesiredReplicas int     `json:"desired_replicas"`
+	Ready           int     `json:"ready"`
+	Allocated       int     `json:"allocated"`
+	Players         int     `json:"players"`
+	Capacity        int     `json:"capacity"`
+	Load            float64 `json:"load"`
+	Reason          string  `json:"reason"`
+	DryRun          bool    `json:"dry_run"`
+}
+
+// FleetEventType is the type of a fleet event
+type FleetEventType string
+
+const (
+	FleetEventScaleUp   FleetEventType = "scale_up"
+	FleetEventScaleDown FleetEventType = "scale_down"
+)
+
+// FleetEvent records an autoscaler decision
+type FleetEvent struct {
+	ID           string         `json:"id" db:"id"`
+	FleetID      string         `json:"fleet_id" db:"fleet_id"`
+	Type         FleetEventType `json:"type" db:"type"`
+	FromReplicas int            `json:"from_replicas" db:"from_replicas"`
+	ToReplicas   int            `json:"to_replicas" db:"to_replicas"`
+	Reason       string         `json:"reason" db:"reason"`
+	DryRun       bool           `json:"dry_run" db:"dry_run"`
+	CreatedAt    time.Time      `json:"created_at" db:"created_at"`
+}
+
+// Allocation is a ready server handed to a matchmaker
+type Allocation struct {
+	ServerID    string     `json:"server_id"`
+	FleetID     string     `json:"fleet_id"`
+	NodeID      string     `json:"node_id"`
+	IPAddress   string     `json:"ip_address"`
+	Port        int        `json:"port"`
+	AllocatedAt time.Time  `json:"allocated_at"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+}
+
+// FleetServerStateOf returns the fleet state of a server
+func FleetServerStateOf(server *Server) FleetServerState {
+	switch {
+	case server.AllocatedAt.Valid:
+		return FleetServerStateAllocated
+	case server.Status == ServerStatusRunning:
+		return FleetServerStateReady
+	case server.Status == ServerStatusError:
+		return FleetServerStateUnhealthy
+	default:
+		return FleetServerStatePending
+	}
+}
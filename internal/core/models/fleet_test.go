@@ -0,0 +1,63 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/game-server/controller/internal/core/models"
+)
+
+func TestAutoscalePolicyValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy models.AutoscalePolicy
+		valid  bool
+	}{
+		{
+			name:   "buffer size from zero",
+			policy: models.AutoscalePolicy{MinReplicas: 0, MaxReplicas: 10, BufferSize: 2},
+			valid:  true,
+		},
+		{
+			name:   "buffer percent from zero",
+			policy: models.AutoscalePolicy{MinReplicas: 0, MaxReplicas: 10, BufferPercent: 20},
+		},
+		{
+			name:   "target load from zero",
+			policy: models.AutoscalePolicy{MinReplicas: 0, MaxReplicas: 10, TargetLoad: 0.7},
+		},
+		{
+			name:   "buffer percent from one",
+			policy: models.AutoscalePolicy{MinReplicas: 1, MaxReplicas: 10, BufferPercent: 20},
+			valid:  true,
+		},
+		{
+			name:   "target load with a buffer size from zero",
+			policy: models.AutoscalePolicy{MinReplicas: 0, MaxReplicas: 10, BufferSize: 1, TargetLoad: 0.7},
+			valid:  true,
+		},
+		{
+			name:   "no max replicas",
+			policy: models.AutoscalePolicy{MinReplicas: 1, BufferSize: 2},
+		},
+		{
+			name:   "buffer percent of 100",
+			policy: models.AutoscalePolicy{MinReplicas: 1, MaxReplicas: 10, BufferPercent: 100},
+		},
+		{
+			name:   "no scaling rule",
+			policy: models.AutoscalePolicy{MinReplicas: 1, MaxReplicas: 10},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Validate()
+			if tt.valid && err != nil {
+				t.Errorf("Validate() = %v, want nil", err)
+			}
+			if !tt.valid && err == nil {
+				t.Error("Validate() = nil, want an error")
+			}
+		})
+	}
+}
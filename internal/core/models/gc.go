@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// OrphanKind is the kind of Docker resource left behind by a deleted node
+type OrphanKind string
+
+const (
+	OrphanKindContainer OrphanKind = "container"
+	OrphanKindVolume    OrphanKind = "volume"
+)
+
+// Orphan is a node container or volume whose node is not in the database,
+// or is on another host
+type Orphan struct {
+	Kind        OrphanKind `json:"kind"`
+	HostID      string     `json:"host_id"`
+	Name        string     `json:"name"`
+	ContainerID string     `json:"container_id,omitempty"`
+	NodeID      string     `json:"node_id"` // from the container label or volume name
+	FirstSeen   time.Time  `json:"first_seen"`
+	RemovableAt time.Time  `json:"removable_at"` // once the grace period is over
+	Removed     bool       `json:"removed,omitempty"`
+	Error       string     `json:"error,omitempty"`
+}
+
+// GCReport is the result of looking for orphaned node containers and
+// volumes, and removing them unless it is a dry run
+type GCReport struct {
+	DryRun    bool      `json:"dry_run"`
+	Orphans   []Orphan  `json:"orphans"`
+	Removed   int       `json:"removed"`
+	Errors    []string  `json:"errors,omitempty"` // hosts that could not be scanned
+	ScannedAt time.Time `json:"scanned_at"`
+}
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// LocalHostID is the host of nodes on the controller's own container
+// runtime, configured by container_runtime
+const LocalHostID = "local"
+
+// Host is a Docker endpoint that node containers can be placed on
+type Host struct {
+	ID       string `json:"id" db:"id"`
+	Name     string `json:"name" db:"name"`
+	Endpoint string `json:"endpoint" db:"endpoint"` // e.g. tcp://10.0.0.5:2376
+	// PEM-encoded TLS certificates, for daemons started with --tlsverify.
+	// The key is stored encrypted and never returned.
+	TLSCACert string `json:"tls_ca_cert,omitempty" db:"tls_ca_cert"`
+	TLSCert   string `json:"tls_cert,omitempty" db:"tls_cert"`
+	TLSKey    string `json:"-" db:"tls_key"`
+	// ControllerAddress is the gRPC address node agents on the host reach the
+	// controller at, the controller's gRPC address if empty
+	ControllerAddress string `json:"controller_address,omitempty" db:"controller_address"`
+	NetworkName       string `json:"network_name,omitempty" db:"network_name"` // Docker network node containers join
+	HostCapacity
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// HostCapacity is what a host can give to node containers. Zero means
+// unlimited.
+type HostCapacity struct {
+	MaxNodes int   `json:"max_nodes" db:"max_nodes"`
+	CPUCores int   `json:"cpu_cores" db:"cpu_cores"`
+	MemoryMB int64 `json:"memory_mb" db:"memory_mb"`
+}
+
+// HostStatus is a host with its connection state and the nodes placed on it
+type HostStatus struct {
+	*Host
+	Local     bool   `json:"local"` // the controller's own container runtime
+	Connected bool   `json:"connected"`
+	Error     string `json:"error,omitempty"` // why the host is not connected
+	Runtime   string `json:"runtime,omitempty"`
+	Version   string `json:"version,omitempty"`
+	Nodes     int    `json:"nodes"`
+	// Allocated are the limits of the nodes on the host
+	AllocatedCPUCores int   `json:"allocated_cpu_cores"`
+	AllocatedMemoryMB int64 `json:"allocated_memory_mb"`
+}
+
+// HostPlacementStrategy is how a host is picked for a new node that does not
+// name one
+type HostPlacementStrategy string
+
+const (
+	// HostPlacementSpread picks the host with the fewest nodes
+	HostPlacementSpread HostPlacementStrategy = "spread"
+	// HostPlacementPack fills the host with the most nodes that still has
+	// capacity, keeping other hosts free
+	HostPlacementPack HostPlacementStrategy = "pack"
+)
+
+// ParseHostPlacementStrategy validates a host placement strategy, defaulting
+// to spread
+func ParseHostPlacementStrategy(s string) (HostPlacementStrategy, error) {
+	switch HostPlacementStrategy(s) {
+	case "", HostPlacementSpread:
+		return HostPlacementSpread, nil
+	case HostPlacementPack:
+		return HostPlacementPack, nil
+	}
+	return "", fmt.Errorf("unknown host placement strategy %q, must be %q or %q", s, HostPlacementSpread, HostPlacementPack)
+}
+
+// CreateHostRequest represents a request to register a host
+type CreateHostRequest struct {
+	Name              string `json:"name" binding:"required"`
+	Endpoint          string `json:"endpoint" binding:"required"`
+	TLSCACert         string `json:"tls_ca_cert"`
+	TLSCert           string `json:"tls_cert"`
+	TLSKey            string `json:"tls_key"`
+	ControllerAddress string `json:"controller_address"`
+	NetworkName       string `json:"network_name"`
+	// Capacity, defaulting to the CPUs and memory the daemon reports
+	MaxNodes int   `json:"max_nodes" binding:"omitempty,min=0"`
+	CPUCores int   `json:"cpu_cores" binding:"omitempty,min=0"`
+	MemoryMB int64 `json:"memory_mb" binding:"omitempty,min=0"`
+}
+
+// UpdateHostRequest represents a request to change a host's capacity. Nodes
+// already on the host are not moved.
+type UpdateHostRequest struct {
+	ControllerAddress *string `json:"controller_address"`
+	MaxNodes          *int    `json:"max_nodes" binding:"omitempty,min=0"`
+	CPUCores          *int    `json:"cpu_cores" binding:"omitempty,min=0"`
+	MemoryMB          *int64  `json:"memory_mb" binding:"omitempty,min=0"`
+}
@@ -14,6 +14,10 @@ const (
 	NodeStatusMaintenance NodeStatus = "maintenance"
 	NodeStatusUnknown     NodeStatus = "unknown"
 	NodeStatusUnhealthy   NodeStatus = "unhealthy"
+	NodeStatusDraining    NodeStatus = "draining" // no new placements, servers being evacuated
+	// NodeStatusReconnecting is a node recovered after a controller restart
+	// whose agent has not registered again yet
+	NodeStatusReconnecting NodeStatus = "reconnecting"
 )
 
 // Node represents a game server node in the system
@@ -23,13 +27,39 @@ type Node struct {
 	Port             int            `json:"port" db:"port"`
 	Status           NodeStatus     `json:"status" db:"status"`
 	GameType         string         `json:"game_type" db:"game_type"`
+	HostID           string         `json:"host_id" db:"host_id"` // container host, LocalHostID if on the controller's runtime
 	AgentVersion     string         `json:"agent_version" db:"agent_version"`
 	HeartbeatInterval int           `json:"heartbeat_interval" db:"heartbeat_interval"`
 	LastHeartbeat     time.Time     `json:"last_heartbeat" db:"last_heartbeat"`
+	Capabilities     []string       `json:"capabilities,omitempty" db:"-"` // reported by the agent when it registers
+	NodeResources
 	CreatedAt        time.Time      `json:"created_at" db:"created_at"`
 	UpdatedAt        time.Time      `json:"updated_at" db:"updated_at"`
 }
 
+// NodeResources are the limits of a node agent container. Zero means the
+// node was created without limits.
+type NodeResources struct {
+	MaxServers int   `json:"max_servers" db:"max_servers"`
+	CPUCores   int   `json:"cpu_cores" db:"cpu_cores"`
+	MemoryMB   int64 `json:"memory_mb" db:"memory_mb"`
+	StorageMB  int64 `json:"storage_mb" db:"storage_mb"`
+}
+
+// NodeUpgrade is the result of upgrading a node's agent image
+type NodeUpgrade struct {
+	NodeID          string `json:"node_id"`
+	Image           string `json:"image"`
+	PreviousImage   string `json:"previous_image"`
+	PreviousImageID string `json:"previous_image_id,omitempty"` // what a failed upgrade is rolled back to
+	AgentVersion    string `json:"agent_version"`               // reported by the agent after the upgrade
+}
+
+// Node capabilities reported by agents
+const (
+	NodeCapabilityRestartServer = "restart_server"
+)
+
 // NodeMetrics represents real-time metrics for a node
 type NodeMetrics struct {
 	NodeID           string    `json:"node_id"`
@@ -40,9 +70,23 @@ type NodeMetrics struct {
 	NetworkOutBytes  int64     `json:"network_out_bytes"`
 	ActiveConnections int32    `json:"active_connections"`
 	LoadAverage      float64   `json:"load_average"`
+	// Memory and block I/O of the node container, from container stats
+	MemoryUsageBytes int64     `json:"memory_usage_bytes,omitempty"`
+	MemoryLimitBytes int64     `json:"memory_limit_bytes,omitempty"`
+	BlockReadBytes   int64     `json:"block_read_bytes,omitempty"`
+	BlockWriteBytes  int64     `json:"block_write_bytes,omitempty"`
+	Source           MetricsSource `json:"source"`
 	Timestamp        time.Time `json:"timestamp"`
 }
 
+// MetricsSource is where node metrics come from
+type MetricsSource string
+
+const (
+	MetricsSourceAgent     MetricsSource = "agent"     // reported by the node agent
+	MetricsSourceContainer MetricsSource = "container" // sampled from the container runtime
+)
+
 // NodeHealth represents the health status of a node
 type NodeHealth string
 
@@ -58,6 +102,14 @@ type CreateNodeRequest struct {
 	Name              string   `json:"name" binding:"required"`
 	Port              int      `json:"port" binding:"omitempty,min=1,max=65535"`
 	GameType          string   `json:"game_type" binding:"required"`
+	// HostID is the container host to run the node on, picked by the host
+	// placement strategy if empty
+	HostID            string   `json:"host_id"`
+	// Container limits, defaulting to the node defaults in the config
+	MaxServers        int      `json:"max_servers" binding:"omitempty,min=1,max=1000"`
+	CPUCores          int      `json:"cpu_cores" binding:"omitempty,min=1,max=256"`
+	MemoryMB          int64    `json:"memory_mb" binding:"omitempty,min=512"`
+	StorageMB         int64    `json:"storage_mb" binding:"omitempty,min=1024"`
 }
 
 // UpdateNodeRequest represents a request to update node configuration
@@ -67,6 +119,11 @@ type UpdateNodeRequest struct {
 	GameType          *string    `json:"game_type"`
 	HeartbeatInterval *int       `json:"heartbeat_interval"`
 	Status            *NodeStatus `json:"status"`
+	// Changing a limit recreates the node container
+	MaxServers        *int       `json:"max_servers" binding:"omitempty,min=1,max=1000"`
+	CPUCores          *int       `json:"cpu_cores" binding:"omitempty,min=1,max=256"`
+	MemoryMB          *int64     `json:"memory_mb" binding:"omitempty,min=512"`
+	StorageMB         *int64     `json:"storage_mb" binding:"omitempty,min=1024"`
 }
 
 // NodeEvent represents an event from a node
@@ -92,4 +149,5 @@ const (
 	EventTypeMetricsUpdate      EventType = "metrics_update"
 	EventTypeLog                EventType = "log"
 	EventTypeHeartbeat          EventType = "heartbeat"
+	EventTypeServerProperties   EventType = "server_properties"
 )
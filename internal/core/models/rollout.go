@@ -0,0 +1,101 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// RolloutServerPolicy is what happens to the active servers of a node before
+// its agent is upgraded
+type RolloutServerPolicy string
+
+const (
+	// RolloutServerPolicyNone upgrades only nodes without active servers,
+	// a node with active servers fails
+	RolloutServerPolicyNone RolloutServerPolicy = "none"
+	// RolloutServerPolicyStop stops servers gracefully and starts them again
+	// after the upgrade
+	RolloutServerPolicyStop RolloutServerPolicy = "stop"
+	// RolloutServerPolicyDrain migrates servers to other nodes, they stay there
+	RolloutServerPolicyDrain RolloutServerPolicy = "drain"
+)
+
+// ParseRolloutServerPolicy validates a rollout server policy. An empty
+// policy is RolloutServerPolicyNone.
+func ParseRolloutServerPolicy(policy string) (RolloutServerPolicy, error) {
+	switch p := RolloutServerPolicy(policy); p {
+	case "":
+		return RolloutServerPolicyNone, nil
+	case RolloutServerPolicyNone, RolloutServerPolicyStop, RolloutServerPolicyDrain:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown server policy %q, must be none, stop or drain", policy)
+}
+
+// RolloutRequest starts a rolling upgrade of node agents
+type RolloutRequest struct {
+	Image        string   `json:"image"`         // defaults to the configured agent image
+	AgentVersion string   `json:"agent_version"` // version upgraded agents must report, if set
+	NodeIDs      []string `json:"node_ids"`      // defaults to every node
+	GameType     string   `json:"game_type"`     // only nodes of this game type
+	BatchSize    int      `json:"batch_size" binding:"min=0"`
+	ServerPolicy string   `json:"server_policy"`
+	// HealthCheckSeconds is how long an upgraded node must stay connected and
+	// healthy before the next batch starts
+	HealthCheckSeconds int `json:"health_check_seconds" binding:"min=0"`
+	StopOptions
+}
+
+// RolloutState is the state of a rolling upgrade
+type RolloutState string
+
+const (
+	RolloutStateRunning   RolloutState = "running"
+	RolloutStatePaused    RolloutState = "paused" // by request or after a node failed
+	RolloutStateCompleted RolloutState = "completed"
+	RolloutStateCancelled RolloutState = "cancelled"
+)
+
+// RolloutNodeState is the state of one node during a rolling upgrade
+type RolloutNodeState string
+
+const (
+	RolloutNodePending   RolloutNodeState = "pending"
+	RolloutNodePreparing RolloutNodeState = "preparing" // stopping or draining servers
+	RolloutNodeUpgrading RolloutNodeState = "upgrading"
+	RolloutNodeVerifying RolloutNodeState = "verifying" // health check after the upgrade
+	RolloutNodeUpgraded  RolloutNodeState = "upgraded"
+	RolloutNodeFailed    RolloutNodeState = "failed" // retried when the rollout resumes
+)
+
+// Rollout reports the progress of a rolling upgrade
+type Rollout struct {
+	ID           string                `json:"id"`
+	Image        string                `json:"image"`
+	AgentVersion string                `json:"agent_version,omitempty"`
+	BatchSize    int                   `json:"batch_size"`
+	ServerPolicy RolloutServerPolicy   `json:"server_policy"`
+	State        RolloutState          `json:"state"`
+	PauseReason  string                `json:"pause_reason,omitempty"`
+	Total        int                   `json:"total"`
+	Upgraded     int                   `json:"upgraded"`
+	Failed       int                   `json:"failed"`
+	Nodes        []RolloutNodeProgress `json:"nodes"`
+	StartedAt    time.Time             `json:"started_at"`
+	CompletedAt  *time.Time            `json:"completed_at,omitempty"`
+}
+
+// RolloutNodeProgress is the progress of one node during a rolling upgrade
+type RolloutNodeProgress struct {
+	NodeID        string           `json:"node_id"`
+	Name          string           `json:"name"`
+	State         RolloutNodeState `json:"state"`
+	PreviousImage string           `json:"previous_image,omitempty"`
+	AgentVersion  string           `json:"agent_version,omitempty"`
+	// StoppedServers are started again once the node is upgraded
+	StoppedServers []string `json:"stopped_servers,omitempty"`
+	// Drained is set while the node is drained for its upgrade. A failed
+	// node stays drained until the rollout is resumed or it is undrained.
+	Drained bool   `json:"drained,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
@@ -17,6 +17,8 @@ const (
 	ServerStatusStarting   ServerStatus = "starting"
 	ServerStatusStopping   ServerStatus = "stopping"
 	ServerStatusBackingUp  ServerStatus = "backing_up"
+	ServerStatusHibernating ServerStatus = "hibernating"
+	ServerStatusMigrating  ServerStatus = "migrating"
 )
 
 // Server represents a game server instance
@@ -41,6 +43,17 @@ type Server struct {
 	QueryPort     int            `json:"query_port" db:"query_port"`
 	RCONPort      int            `json:"rcon_port" db:"rcon_port"`
 	IPAddress     string         `json:"ip_address" db:"ip_address"`
+	QueryType     string         `json:"query_type" db:"query_type"`
+	
+	// Hibernation
+	IdlePolicy         *IdlePolicy  `json:"idle_policy,omitempty" db:"-"`
+	HibernatedAt       sql.NullTime `json:"hibernated_at" db:"hibernated_at"`
+	HibernatedMemoryMB int64        `json:"hibernated_memory_mb" db:"hibernated_memory_mb"` // memory in use when the server was hibernated
+	
+	// Fleet allocation
+	FleetID             string       `json:"fleet_id,omitempty" db:"fleet_id"`
+	AllocatedAt         sql.NullTime `json:"allocated_at" db:"allocated_at"`
+	AllocationExpiresAt sql.NullTime `json:"allocation_expires_at" db:"allocation_expires_at"`
 	
 	// Metrics
 	PlayerCount   int            `json:"player_count" db:"player_count"`
@@ -64,6 +77,7 @@ type ServerConfig struct {
 	WorldName     string            `json:"world_name"`
 	OnlineMode    bool              `json:"online_mode"`
 	QueryType     string            `json:"query_type"`
+	IdlePolicy    *IdlePolicy       `json:"idle_policy"`
 	AutoStart     bool              `json:"auto_start"`
 	AutoRestart   bool              `json:"auto_restart"`
 	RestartDelay  int               `json:"restart_delay_seconds" binding:"min=0"`
@@ -71,6 +85,12 @@ type ServerConfig struct {
 	BackupSchedule string           `json:"backup_schedule"`
 }
 
+// IdlePolicy hibernates a server after it has had no players for a while
+type IdlePolicy struct {
+	IdleMinutes int      `json:"idle_minutes"`         // 0 disables hibernation
+	WakeTimes   []string `json:"wake_times,omitempty"` // daily "HH:MM" times (UTC) to wake the server
+}
+
 // ResourceRequirements represents the resource requirements for a server
 type ResourceRequirements struct {
 	MinCPUCores       int   `json:"min_cpu_cores" binding:"min=1"`
@@ -98,12 +118,17 @@ type ServerMetrics struct {
 	Timestamp      time.Time `json:"timestamp"`
 }
 
-// CreateServerRequest represents a request to create a new server
+// CreateServerRequest represents a request to create a new server. With a
+// template ID the game type, config and requirements come from the template.
 type CreateServerRequest struct {
 	NodeID      string              `json:"node_id" binding:"required"`
-	GameType    string              `json:"game_type" binding:"required"`
-	Config      ServerConfig        `json:"config" binding:"required"`
-	Requirements ResourceRequirements `json:"requirements"`
+	GameType    string              `json:"game_type"`
+	Config      *ServerConfig       `json:"config"`
+	Requirements *ResourceRequirements `json:"requirements"`
+	TemplateID  string              `json:"template_id"`
+	Name        string              `json:"name"`  // overrides the template's server name
+	FleetID     string              `json:"-"` // set by the fleet reconciler
+	World       *WorldSource        `json:"-"` // set when cloning or from a template with a world
 }
 
 // UpdateServerRequest represents a request to update server configuration
@@ -123,6 +148,32 @@ const (
 	ServerActionBackup   ServerAction = "backup"
 )
 
+// StopOptions controls how a server is stopped
+type StopOptions struct {
+	// Graceful announces the stop to players and saves the world first
+	Graceful         bool   `json:"graceful"`
+	CountdownSeconds int    `json:"countdown_seconds" binding:"min=0"`
+	// Message is broadcast during the countdown; {seconds} is replaced with
+	// the remaining time
+	Message        string `json:"message"`
+	SkipSave       bool   `json:"skip_save"`
+	TimeoutSeconds int    `json:"timeout_seconds" binding:"min=0"`
+}
+
+// MigrateRequest represents a request to move a server to another node. The
+// stop options apply when the server is running.
+type MigrateRequest struct {
+	TargetNodeID string `json:"target_node_id" binding:"required"`
+	StopOptions
+}
+
+// PlayerListRequest adds a player to a whitelist, ops or bans list
+type PlayerListRequest struct {
+	Name   string `json:"name" binding:"required"`
+	Level  int    `json:"level" binding:"min=0,max=4"` // ops only, rejected while the server is running
+	Reason string `json:"reason"`                      // bans only
+}
+
 // ServerLog represents a log entry from a server
 type ServerLog struct {
 	ID         string    `json:"id"`
@@ -151,6 +202,7 @@ type ServerFilters struct {
 	Status    ServerStatus  `query:"status"`
 	GameType  string        `query:"game_type"`
 	HasPlayer *bool         `query:"has_player"`
+	FleetID   string        `query:"fleet_id"`
 	Limit     int           `query:"limit" binding:"omitempty,min=1,max=100"`
 	Offset    int           `query:"offset" binding:"omitempty,min=0"`
 }
@@ -172,3 +224,38 @@ type ServerInfo struct {
 	RCONPort    int    `json:"rcon_port"`
 	IPAddress   string `json:"ip_address"`
 }
+
+// ServerPropertiesReport is the payload of a server_properties event, sent by
+// the node agent with the server.properties it found on disk
+type ServerPropertiesReport struct {
+	ServerID   string            `json:"server_id"`
+	Properties map[string]string `json:"properties"`
+	ReportedAt time.Time         `json:"reported_at"`
+}
+
+// ServerEvent is the payload of server_started, server_stopped and
+// server_error events
+type ServerEvent struct {
+	ServerID string `json:"server_id"`
+	Message  string `json:"message,omitempty"`
+}
+
+// PlayerSession is a period a player was online on a server
+type PlayerSession struct {
+	ID              string     `json:"id" db:"id"`
+	ServerID        string     `json:"server_id" db:"server_id"`
+	PlayerName      string     `json:"player_name" db:"player_name"`
+	JoinedAt        time.Time  `json:"joined_at" db:"joined_at"`
+	LeftAt          *time.Time `json:"left_at" db:"left_at"`
+	DurationSeconds int64      `json:"duration_seconds" db:"-"`
+	Online          bool       `json:"online" db:"-"`
+}
+
+// ServerUsage summarizes player activity on a server over a period
+type ServerUsage struct {
+	ServerID        string `json:"server_id"`
+	Name            string `json:"name"`
+	UniquePlayers   int    `json:"unique_players"`
+	Sessions        int    `json:"sessions"`
+	PlaytimeSeconds int64  `json:"playtime_seconds"`
+}
@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// ServerTemplate is a saved server configuration that new servers can be
+// created from, optionally with a copy of a world
+type ServerTemplate struct {
+	ID             string               `json:"id" db:"id"`
+	Name           string               `json:"name" db:"name"`
+	GameType       string               `json:"game_type" db:"game_type"`
+	Config         ServerConfig         `json:"config" db:"-"`
+	Requirements   ResourceRequirements `json:"requirements" db:"-"`
+	HasWorld       bool                 `json:"has_world" db:"has_world"`
+	HostID         string               `json:"host_id,omitempty" db:"host_id"`                   // host holding the world archive
+	SourceServerID string               `json:"source_server_id,omitempty" db:"source_server_id"` // server the template was saved from
+	CreatedAt      time.Time            `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time            `json:"updated_at" db:"updated_at"`
+}
+
+// CreateTemplateRequest represents a request to save a template, either from
+// a game type and config or from an existing server
+type CreateTemplateRequest struct {
+	Name         string                `json:"name" binding:"required"`
+	GameType     string                `json:"game_type"`
+	Config       *ServerConfig         `json:"config"`
+	Requirements *ResourceRequirements `json:"requirements"`
+	// ServerID saves the config of an existing server instead
+	ServerID     string `json:"server_id"`
+	IncludeWorld bool   `json:"include_world"` // server_id only
+}
+
+// CloneServerRequest represents a request to create a copy of a server
+type CloneServerRequest struct {
+	Name         string                `json:"name" binding:"required"`
+	IncludeWorld bool                  `json:"include_world"`
+	Requirements *ResourceRequirements `json:"requirements"`
+}
+
+// WorldSource is the world a new server starts with instead of generating one
+type WorldSource struct {
+	// HostID is the host the world is on. Worlds are copied with helper
+	// containers, so the new server is placed on a node of this host.
+	HostID string
+	// TemplateID restores the world archive of a template
+	TemplateID string
+	// ServerID and NodeID copy the world of an existing server
+	ServerID  string
+	NodeID    string
+	WorldName string
+}
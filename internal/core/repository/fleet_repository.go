@@ -0,0 +1,374 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/game-server/controller/internal/core/models"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// FleetRepository handles database operations for fleets and allocations
+type FleetRepository struct {
+	db     *Database
+	logger *zap.Logger
+}
+
+// NewFleetRepository creates a new fleet repository
+func NewFleetRepository(db *Database, logger *zap.Logger) *FleetRepository {
+	return &FleetRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Create creates a new fleet in the database
+func (r *FleetRepository) Create(ctx context.Context, fleet *models.Fleet) error {
+	fleet.ID = uuid.New().String()
+	fleet.CreatedAt = time.Now()
+	fleet.UpdatedAt = time.Now()
+
+	configJSON, requirementsJSON, autoscaleJSON, err := marshalFleet(fleet)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO fleets (
+			id, name, game_type, config, requirements, replicas,
+			allocation_ttl_seconds, autoscale, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+
+	_, err = r.db.ExecContext(ctx, query,
+		fleet.ID, fleet.Name, fleet.GameType, configJSON, requirementsJSON, fleet.Replicas,
+		fleet.AllocationTTLSeconds, autoscaleJSON, fleet.CreatedAt, fleet.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create fleet: %w", err)
+	}
+
+	r.logger.Info("Fleet created",
+		zap.String("fleet_id", fleet.ID),
+		zap.String("name", fleet.Name))
+
+	return nil
+}
+
+// GetByID retrieves a fleet by ID
+func (r *FleetRepository) GetByID(ctx context.Context, id string) (*models.Fleet, error) {
+	query := `
+		SELECT id, name, game_type, config, requirements, replicas,
+			allocation_ttl_seconds, autoscale, created_at, updated_at
+		FROM fleets WHERE id = $1
+	`
+
+	fleet, err := scanFleet(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fleet: %w", err)
+	}
+
+	return fleet, nil
+}
+
+// List retrieves all fleets
+func (r *FleetRepository) List(ctx context.Context) ([]*models.Fleet, error) {
+	query := `
+		SELECT id, name, game_type, config, requirements, replicas,
+			allocation_ttl_seconds, autoscale, created_at, updated_at
+		FROM fleets ORDER BY name
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list fleets: %w", err)
+	}
+	defer rows.Close()
+
+	fleets := []*models.Fleet{}
+	for rows.Next() {
+		fleet, err := scanFleet(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan fleet: %w", err)
+		}
+		fleets = append(fleets, fleet)
+	}
+
+	return fleets, nil
+}
+
+// Update updates a fleet in the database
+func (r *FleetRepository) Update(ctx context.Context, fleet *models.Fleet) error {
+	fleet.UpdatedAt = time.Now()
+
+	configJSON, requirementsJSON, autoscaleJSON, err := marshalFleet(fleet)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE fleets SET
+			config = $1, requirements = $2, replicas = $3,
+			allocation_ttl_seconds = $4, autoscale = $5, updated_at = $6
+		WHERE id = $7
+	`
+
+	_, err = r.db.ExecContext(ctx, query,
+		configJSON, requirementsJSON, fleet.Replicas,
+		fleet.AllocationTTLSeconds, autoscaleJSON, fleet.UpdatedAt, fleet.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update fleet: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateReplicas sets the desired replicas of a fleet
+func (r *FleetRepository) UpdateReplicas(ctx context.Context, id string, replicas int) error {
+	query := `UPDATE fleets SET replicas = $1, updated_at = $2 WHERE id = $3`
+
+	_, err := r.db.ExecContext(ctx, query, replicas, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to update fleet replicas: %w", err)
+	}
+
+	return nil
+}
+
+// Delete deletes a fleet from the database
+func (r *FleetRepository) Delete(ctx context.Context, id string) error {
+	query := `DELETE FROM fleets WHERE id = $1`
+
+	_, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete fleet: %w", err)
+	}
+
+	return nil
+}
+
+// Allocate atomically marks one ready server of a fleet as allocated and
+// returns its ID, or "" if no server is ready. Concurrent allocations skip
+// rows locked by each other, so a server is never handed out twice. Servers
+// on draining or maintenance nodes are not handed out.
+func (r *FleetRepository) Allocate(ctx context.Context, fleetID string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	var expiresAt interface{}
+	if ttl > 0 {
+		expiresAt = now.Add(ttl)
+	}
+
+	query := `
+		UPDATE servers SET allocated_at = $1, allocation_expires_at = $2, updated_at = $1
+		WHERE id = (
+			SELECT id FROM servers
+			WHERE fleet_id = $3 AND status = $4 AND allocated_at IS NULL
+				AND node_id NOT IN (SELECT id FROM nodes WHERE status IN ($5, $6))
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id
+	`
+
+	var serverID string
+	err := r.db.QueryRowContext(ctx, query, now, expiresAt, fleetID, models.ServerStatusRunning,
+		models.NodeStatusDraining, models.NodeStatusMaintenance).Scan(&serverID)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to allocate server: %w", err)
+	}
+
+	return serverID, nil
+}
+
+// Release clears the allocation of a server. It returns false if the server
+// was not allocated.
+func (r *FleetRepository) Release(ctx context.Context, serverID string) (bool, error) {
+	query := `
+		UPDATE servers SET allocated_at = NULL, allocation_expires_at = NULL, updated_at = $1
+		WHERE id = $2 AND allocated_at IS NOT NULL
+	`
+
+	result, err := r.db.ExecContext(ctx, query, time.Now(), serverID)
+	if err != nil {
+		return false, fmt.Errorf("failed to release server: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to release server: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// ClaimForRemoval marks a fleet server that is neither allocated nor has
+// players as stopping, so it can no longer be allocated, and reports whether
+// it did. An allocation running at the same time wins or loses on the row
+// lock, so a server is never removed after it was handed out.
+func (r *FleetRepository) ClaimForRemoval(ctx context.Context, serverID string) (bool, error) {
+	query := `
+		UPDATE servers SET status = $1, updated_at = $2
+		WHERE id = $3 AND allocated_at IS NULL AND player_count = 0
+		RETURNING id
+	`
+
+	var id string
+	err := r.db.QueryRowContext(ctx, query, models.ServerStatusStopping, time.Now(), serverID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to claim server for removal: %w", err)
+	}
+
+	return true, nil
+}
+
+// ReleaseExpired clears allocations whose TTL has passed and returns the
+// released server IDs
+func (r *FleetRepository) ReleaseExpired(ctx context.Context) ([]string, error) {
+	query := `
+		UPDATE servers SET allocated_at = NULL, allocation_expires_at = NULL, updated_at = $1
+		WHERE allocation_expires_at IS NOT NULL AND allocation_expires_at <= $1
+		RETURNING id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to release expired allocations: %w", err)
+	}
+	defer rows.Close()
+
+	var released []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan released server: %w", err)
+		}
+		released = append(released, id)
+	}
+
+	return released, nil
+}
+
+// CreateEvent records a fleet event
+func (r *FleetRepository) CreateEvent(ctx context.Context, event *models.FleetEvent) error {
+	event.ID = uuid.New().String()
+	event.CreatedAt = time.Now()
+
+	query := `
+		INSERT INTO fleet_events (
+			id, fleet_id, type, from_replicas, to_replicas, reason, dry_run, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		event.ID, event.FleetID, event.Type, event.FromReplicas, event.ToReplicas,
+		event.Reason, event.DryRun, event.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create fleet event: %w", err)
+	}
+
+	return nil
+}
+
+// ListEvents returns the most recent events of a fleet, newest first
+func (r *FleetRepository) ListEvents(ctx context.Context, fleetID string, limit int) ([]*models.FleetEvent, error) {
+	query := `
+		SELECT id, fleet_id, type, from_replicas, to_replicas, reason, dry_run, created_at
+		FROM fleet_events WHERE fleet_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, fleetID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list fleet events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []*models.FleetEvent{}
+	for rows.Next() {
+		var event models.FleetEvent
+		if err := rows.Scan(
+			&event.ID, &event.FleetID, &event.Type, &event.FromReplicas, &event.ToReplicas,
+			&event.Reason, &event.DryRun, &event.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan fleet event: %w", err)
+		}
+		events = append(events, &event)
+	}
+
+	return events, nil
+}
+
+// rowScanner is implemented by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanFleet(row rowScanner) (*models.Fleet, error) {
+	var fleet models.Fleet
+	var configJSON, requirementsJSON []byte
+	var autoscaleJSON sql.NullString
+
+	if err := row.Scan(
+		&fleet.ID, &fleet.Name, &fleet.GameType, &configJSON, &requirementsJSON, &fleet.Replicas,
+		&fleet.AllocationTTLSeconds, &autoscaleJSON, &fleet.CreatedAt, &fleet.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(configJSON, &fleet.Config); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal fleet config: %w", err)
+	}
+	if err := json.Unmarshal(requirementsJSON, &fleet.Requirements); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal fleet requirements: %w", err)
+	}
+	if autoscaleJSON.Valid && autoscaleJSON.String != "" {
+		fleet.Autoscale = &models.AutoscalePolicy{}
+		if err := json.Unmarshal([]byte(autoscaleJSON.String), fleet.Autoscale); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal autoscale policy: %w", err)
+		}
+	}
+
+	return &fleet, nil
+}
+
+// marshalFleet encodes the JSON columns of a fleet. A fleet without an
+// autoscale policy stores NULL.
+func marshalFleet(fleet *models.Fleet) ([]byte, []byte, interface{}, error) {
+	configJSON, err := json.Marshal(fleet.Config)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to marshal fleet config: %w", err)
+	}
+
+	requirementsJSON, err := json.Marshal(fleet.Requirements)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to marshal fleet requirements: %w", err)
+	}
+
+	var autoscaleJSON interface{}
+	if fleet.Autoscale != nil {
+		data, err := json.Marshal(fleet.Autoscale)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to marshal autoscale policy: %w", err)
+		}
+		autoscaleJSON = string(data)
+	}
+
+	return configJSON, requirementsJSON, autoscaleJSON, nil
+}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/game-server/controller/internal/gametype"
+	"go.uber.org/zap"
+)
+
+// GameTypeRepository handles database operations for imported game types
+type GameTypeRepository struct {
+	db     *Database
+	logger *zap.Logger
+}
+
+// NewGameTypeRepository creates a new game type repository
+func NewGameTypeRepository(db *Database, logger *zap.Logger) *GameTypeRepository {
+	return &GameTypeRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Save creates or replaces a game type definition
+func (r *GameTypeRepository) Save(ctx context.Context, def *gametype.Definition) error {
+	definitionJSON, err := json.Marshal(def)
+	if err != nil {
+		return fmt.Errorf("failed to marshal game type: %w", err)
+	}
+
+	now := time.Now()
+	query := `
+		INSERT INTO game_types (id, name, source, definition, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (id) DO UPDATE SET
+			name = EXCLUDED.name, source = EXCLUDED.source,
+			definition = EXCLUDED.definition, updated_at = EXCLUDED.updated_at
+	`
+
+	_, err = r.db.ExecContext(ctx, query,
+		def.ID, def.Name, def.Source, definitionJSON, now, now,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save game type: %w", err)
+	}
+
+	r.logger.Info("Game type saved",
+		zap.String("game_type", def.ID),
+		zap.String("source", string(def.Source)))
+
+	return nil
+}
+
+// List retrieves all stored game type definitions
+func (r *GameTypeRepository) List(ctx context.Context) ([]*gametype.Definition, error) {
+	query := `SELECT definition FROM game_types ORDER BY id`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list game types: %w", err)
+	}
+	defer rows.Close()
+
+	var defs []*gametype.Definition
+	for rows.Next() {
+		var definitionJSON []byte
+		if err := rows.Scan(&definitionJSON); err != nil {
+			return nil, fmt.Errorf("failed to scan game type: %w", err)
+		}
+
+		var def gametype.Definition
+		if err := json.Unmarshal(definitionJSON, &def); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal game type: %w", err)
+		}
+		defs = append(defs, &def)
+	}
+
+	return defs, nil
+}
+
+// CountUsers counts the servers and fleets of a game type
+func (r *GameTypeRepository) CountUsers(ctx context.Context, id string) (servers int, fleets int, err error) {
+	query := `
+		SELECT
+			(SELECT COUNT(*) FROM servers WHERE game_type = $1),
+			(SELECT COUNT(*) FROM fleets WHERE game_type = $1)
+	`
+
+	if err := r.db.QueryRowContext(ctx, query, id).Scan(&servers, &fleets); err != nil {
+		return 0, 0, fmt.Errorf("failed to count game type users: %w", err)
+	}
+
+	return servers, fleets, nil
+}
+
+// Delete deletes a stored game type definition
+func (r *GameTypeRepository) Delete(ctx context.Context, id string) error {
+	query := `DELETE FROM game_types WHERE id = $1`
+
+	_, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete game type: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/game-server/controller/internal/core/models"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// HostRepository handles database operations for container hosts
+type HostRepository struct {
+	db     *Database
+	logger *zap.Logger
+}
+
+// NewHostRepository creates a new host repository
+func NewHostRepository(db *Database, logger *zap.Logger) *HostRepository {
+	return &HostRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Create creates a new host in the database. The TLS key must already be
+// encrypted.
+func (r *HostRepository) Create(ctx context.Context, host *models.Host) error {
+	if host.ID == "" {
+		host.ID = uuid.New().String()
+	}
+	host.CreatedAt = time.Now()
+	host.UpdatedAt = time.Now()
+
+	query := `
+		INSERT INTO hosts (
+			id, name, endpoint, tls_ca_cert, tls_cert, tls_key,
+			controller_address, network_name, max_nodes, cpu_cores, memory_mb,
+			created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		host.ID, host.Name, host.Endpoint, host.TLSCACert, host.TLSCert, host.TLSKey,
+		host.ControllerAddress, host.NetworkName, host.MaxNodes, host.CPUCores, host.MemoryMB,
+		host.CreatedAt, host.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create host: %w", err)
+	}
+
+	r.logger.Info("Host created",
+		zap.String("host_id", host.ID),
+		zap.String("name", host.Name),
+		zap.String("endpoint", host.Endpoint))
+
+	return nil
+}
+
+// GetByID retrieves a host by ID
+func (r *HostRepository) GetByID(ctx context.Context, id string) (*models.Host, error) {
+	query := `
+		SELECT id, name, endpoint, tls_ca_cert, tls_cert, tls_key,
+			controller_address, network_name, max_nodes, cpu_cores, memory_mb,
+			created_at, updated_at
+		FROM hosts WHERE id = $1
+	`
+
+	host, err := scanHost(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get host: %w", err)
+	}
+
+	return host, nil
+}
+
+// GetByName retrieves a host by name
+func (r *HostRepository) GetByName(ctx context.Context, name string) (*models.Host, error) {
+	query := `
+		SELECT id, name, endpoint, tls_ca_cert, tls_cert, tls_key,
+			controller_address, network_name, max_nodes, cpu_cores, memory_mb,
+			created_at, updated_at
+		FROM hosts WHERE name = $1
+	`
+
+	host, err := scanHost(r.db.QueryRowContext(ctx, query, name))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get host: %w", err)
+	}
+
+	return host, nil
+}
+
+// List retrieves all hosts
+func (r *HostRepository) List(ctx context.Context) ([]*models.Host, error) {
+	query := `
+		SELECT id, name, endpoint, tls_ca_cert, tls_cert, tls_key,
+			controller_address, network_name, max_nodes, cpu_cores, memory_mb,
+			created_at, updated_at
+		FROM hosts ORDER BY name
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list hosts: %w", err)
+	}
+	defer rows.Close()
+
+	hosts := []*models.Host{}
+	for rows.Next() {
+		host, err := scanHost(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan host: %w", err)
+		}
+		hosts = append(hosts, host)
+	}
+
+	return hosts, nil
+}
+
+// Update updates the controller address and capacity of a host
+func (r *HostRepository) Update(ctx context.Context, host *models.Host) error {
+	host.UpdatedAt = time.Now()
+
+	query := `
+		UPDATE hosts SET
+			controller_address = $1, max_nodes = $2, cpu_cores = $3, memory_mb = $4, updated_at = $5
+		WHERE id = $6
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		host.ControllerAddress, host.MaxNodes, host.CPUCores, host.MemoryMB, host.UpdatedAt, host.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update host: %w", err)
+	}
+
+	return nil
+}
+
+// Delete deletes a host from the database. Hosts with nodes cannot be
+// deleted.
+func (r *HostRepository) Delete(ctx context.Context, id string) error {
+	query := `DELETE FROM hosts WHERE id = $1`
+
+	_, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete host: %w", err)
+	}
+
+	return nil
+}
+
+func scanHost(row rowScanner) (*models.Host, error) {
+	var host models.Host
+	var caCert, cert, key, controllerAddress, networkName sql.NullString
+
+	if err := row.Scan(
+		&host.ID, &host.Name, &host.Endpoint, &caCert, &cert, &key,
+		&controllerAddress, &networkName, &host.MaxNodes, &host.CPUCores, &host.MemoryMB,
+		&host.CreatedAt, &host.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	host.TLSCACert = caCert.String
+	host.TLSCert = cert.String
+	host.TLSKey = key.String
+	host.ControllerAddress = controllerAddress.String
+	host.NetworkName = networkName.String
+
+	return &host, nil
+}
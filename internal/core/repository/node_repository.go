@@ -25,22 +25,27 @@ func NewNodeRepository(db *Database, logger *zap.Logger) *NodeRepository {
 	}
 }
 
-// Create creates a new node in the database
+// Create creates a new node in the database. A preset ID is kept, since
+// node agents register with the ID their container was created with.
 func (r *NodeRepository) Create(ctx context.Context, node *models.Node) error {
-	node.ID = uuid.New().String()
+	if node.ID == "" {
+		node.ID = uuid.New().String()
+	}
 	node.CreatedAt = time.Now()
 	node.UpdatedAt = time.Now()
 
 	query := `
 		INSERT INTO nodes (
 			id, name, port, status, game_type,
-			agent_version, heartbeat_interval, created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			agent_version, heartbeat_interval, max_servers, cpu_cores,
+			memory_mb, storage_mb, host_id, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
 	`
 
 	_, err := r.db.ExecContext(ctx, query,
 		node.ID, node.Name, node.Port, node.Status, node.GameType,
-		node.AgentVersion, node.HeartbeatInterval,
+		node.AgentVersion, node.HeartbeatInterval, node.MaxServers, node.CPUCores,
+		node.MemoryMB, node.StorageMB, hostIDValue(node.HostID),
 		node.CreatedAt, node.UpdatedAt,
 	)
 
@@ -60,18 +65,20 @@ func (r *NodeRepository) GetByID(ctx context.Context, id string) (*models.Node,
 	query := `
 		SELECT id, name, port, status, game_type,
 			agent_version, heartbeat_interval, last_heartbeat,
-			created_at, updated_at
+			max_servers, cpu_cores, memory_mb, storage_mb,
+			host_id, created_at, updated_at
 		FROM nodes WHERE id = $1
 	`
 
 	var node models.Node
 	var lastHeartbeat sql.NullTime
-	var agentVersion sql.NullString
+	var agentVersion, hostID sql.NullString
 
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
 		&node.ID, &node.Name, &node.Port, &node.Status, &node.GameType,
 		&agentVersion, &node.HeartbeatInterval, &lastHeartbeat,
-		&node.CreatedAt, &node.UpdatedAt,
+		&node.MaxServers, &node.CPUCores, &node.MemoryMB, &node.StorageMB,
+		&hostID, &node.CreatedAt, &node.UpdatedAt,
 	)
 
 	if err == sql.ErrNoRows {
@@ -89,6 +96,8 @@ func (r *NodeRepository) GetByID(ctx context.Context, id string) (*models.Node,
 		node.LastHeartbeat = lastHeartbeat.Time
 	}
 
+	node.HostID = hostIDOf(hostID)
+
 	return &node, nil
 }
 
@@ -97,18 +106,20 @@ func (r *NodeRepository) GetByName(ctx context.Context, name string) (*models.No
 	query := `
 		SELECT id, name, port, status, game_type,
 			agent_version, heartbeat_interval, last_heartbeat,
-			created_at, updated_at
+			max_servers, cpu_cores, memory_mb, storage_mb,
+			host_id, created_at, updated_at
 		FROM nodes WHERE name = $1
 	`
 
 	var node models.Node
 	var lastHeartbeat sql.NullTime
-	var agentVersion sql.NullString
+	var agentVersion, hostID sql.NullString
 
 	err := r.db.QueryRowContext(ctx, query, name).Scan(
 		&node.ID, &node.Name, &node.Port, &node.Status, &node.GameType,
 		&agentVersion, &node.HeartbeatInterval, &lastHeartbeat,
-		&node.CreatedAt, &node.UpdatedAt,
+		&node.MaxServers, &node.CPUCores, &node.MemoryMB, &node.StorageMB,
+		&hostID, &node.CreatedAt, &node.UpdatedAt,
 	)
 
 	if err == sql.ErrNoRows {
@@ -126,6 +137,8 @@ func (r *NodeRepository) GetByName(ctx context.Context, name string) (*models.No
 		node.LastHeartbeat = lastHeartbeat.Time
 	}
 
+	node.HostID = hostIDOf(hostID)
+
 	return &node, nil
 }
 
@@ -138,7 +151,8 @@ func (r *NodeRepository) List(ctx context.Context, status *models.NodeStatus) ([
 		query = `
 			SELECT id, name, port, status, game_type,
 				agent_version, heartbeat_interval, last_heartbeat,
-				created_at, updated_at
+				max_servers, cpu_cores, memory_mb, storage_mb,
+				host_id, created_at, updated_at
 			FROM nodes WHERE status = $1 ORDER BY created_at DESC
 		`
 		args = []interface{}{*status}
@@ -146,7 +160,8 @@ func (r *NodeRepository) List(ctx context.Context, status *models.NodeStatus) ([
 		query = `
 			SELECT id, name, port, status, game_type,
 				agent_version, heartbeat_interval, last_heartbeat,
-				created_at, updated_at
+				max_servers, cpu_cores, memory_mb, storage_mb,
+				host_id, created_at, updated_at
 			FROM nodes ORDER BY created_at DESC
 		`
 	}
@@ -161,12 +176,13 @@ func (r *NodeRepository) List(ctx context.Context, status *models.NodeStatus) ([
 	for rows.Next() {
 		var node models.Node
 		var lastHeartbeat sql.NullTime
-		var agentVersion sql.NullString
+		var agentVersion, hostID sql.NullString
 
 		if err := rows.Scan(
 			&node.ID, &node.Name, &node.Port, &node.Status, &node.GameType,
 			&agentVersion, &node.HeartbeatInterval, &lastHeartbeat,
-			&node.CreatedAt, &node.UpdatedAt,
+			&node.MaxServers, &node.CPUCores, &node.MemoryMB, &node.StorageMB,
+			&hostID, &node.CreatedAt, &node.UpdatedAt,
 		); err != nil {
 			return nil, fmt.Errorf("failed to scan node: %w", err)
 		}
@@ -179,6 +195,8 @@ func (r *NodeRepository) List(ctx context.Context, status *models.NodeStatus) ([
 			node.LastHeartbeat = lastHeartbeat.Time
 		}
 
+		node.HostID = hostIDOf(hostID)
+
 		nodes = append(nodes, &node)
 	}
 
@@ -208,6 +226,25 @@ func (r *NodeRepository) Update(ctx context.Context, node *models.Node) error {
 	return nil
 }
 
+// UpdateResources sets the container limits of a node
+func (r *NodeRepository) UpdateResources(ctx context.Context, id string, resources models.NodeResources) error {
+	query := `
+		UPDATE nodes SET
+			max_servers = $1, cpu_cores = $2, memory_mb = $3, storage_mb = $4, updated_at = $5
+		WHERE id = $6
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		resources.MaxServers, resources.CPUCores, resources.MemoryMB, resources.StorageMB,
+		time.Now(), id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update node resources: %w", err)
+	}
+
+	return nil
+}
+
 // UpdateHeartbeat updates the last heartbeat time
 func (r *NodeRepository) UpdateHeartbeat(ctx context.Context, id string, heartbeat time.Time) error {
 	query := `UPDATE nodes SET last_heartbeat = $1, updated_at = $2 WHERE id = $3`
@@ -254,3 +291,20 @@ func (r *NodeRepository) CountByStatus(ctx context.Context) (map[models.NodeStat
 
 	return result, nil
 }
+
+// hostIDValue stores nodes on the local host without a host ID
+func hostIDValue(hostID string) interface{} {
+	if hostID == "" || hostID == models.LocalHostID {
+		return nil
+	}
+	return hostID
+}
+
+// hostIDOf returns the host of a node, which is the local host without a
+// host ID
+func hostIDOf(hostID sql.NullString) string {
+	if !hostID.Valid {
+		return models.LocalHostID
+	}
+	return hostID.String
+}
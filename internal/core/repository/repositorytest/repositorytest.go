@@ -0,0 +1,174 @@
+// Package repositorytest provides in-memory stores for testing code that
+// persists nodes and servers without a database.
+package repositorytest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/game-server/controller/internal/core/models"
+)
+
+// Nodes keeps nodes in memory and behaves like repository.NodeRepository:
+// getters return copies, a missing node is nil, and Update only changes the
+// columns the database update does.
+type Nodes struct {
+	nodes map[string]*models.Node
+	mu    sync.Mutex
+}
+
+// NewNodes creates a store holding nodes
+func NewNodes(nodes ...*models.Node) *Nodes {
+	s := &Nodes{nodes: make(map[string]*models.Node)}
+	for _, node := range nodes {
+		s.Create(context.Background(), node)
+	}
+	return s
+}
+
+// Create stores a new node. Nodes without a host are local.
+func (s *Nodes) Create(ctx context.Context, node *models.Node) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.nodes[node.ID]; exists {
+		return fmt.Errorf("failed to create node: duplicate id %s", node.ID)
+	}
+
+	now := time.Now()
+	node.CreatedAt = now
+	node.UpdatedAt = now
+
+	stored := *node
+	if stored.HostID == "" {
+		stored.HostID = models.LocalHostID
+	}
+	s.nodes[node.ID] = &stored
+	return nil
+}
+
+// GetByID returns a node, or nil if it does not exist
+func (s *Nodes) GetByID(ctx context.Context, id string) (*models.Node, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	node, exists := s.nodes[id]
+	if !exists {
+		return nil, nil
+	}
+	found := *node
+	return &found, nil
+}
+
+// List returns the nodes with a status, or all if status is nil, newest
+// first
+func (s *Nodes) List(ctx context.Context, status *models.NodeStatus) ([]*models.Node, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var nodes []*models.Node
+	for _, node := range s.nodes {
+		if status != nil && node.Status != *status {
+			continue
+		}
+		found := *node
+		nodes = append(nodes, &found)
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].CreatedAt.After(nodes[j].CreatedAt)
+	})
+	return nodes, nil
+}
+
+// Update changes a node's name, port, status, game type and heartbeat
+func (s *Nodes) Update(ctx context.Context, node *models.Node) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	node.UpdatedAt = time.Now()
+
+	stored, exists := s.nodes[node.ID]
+	if !exists {
+		return nil
+	}
+	stored.Name = node.Name
+	stored.Port = node.Port
+	stored.Status = node.Status
+	stored.GameType = node.GameType
+	stored.HeartbeatInterval = node.HeartbeatInterval
+	stored.LastHeartbeat = node.LastHeartbeat
+	stored.UpdatedAt = node.UpdatedAt
+	return nil
+}
+
+// UpdateResources sets the container limits of a node
+func (s *Nodes) UpdateResources(ctx context.Context, id string, resources models.NodeResources) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if stored, exists := s.nodes[id]; exists {
+		stored.NodeResources = resources
+		stored.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+// Delete removes a node. Deleting a missing node is not an error.
+func (s *Nodes) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.nodes, id)
+	return nil
+}
+
+// Servers keeps servers in memory for code that only needs to find and
+// delete them by node
+type Servers struct {
+	servers map[string]*models.Server
+	mu      sync.Mutex
+}
+
+// NewServers creates a store holding servers
+func NewServers(servers ...*models.Server) *Servers {
+	s := &Servers{servers: make(map[string]*models.Server)}
+	for _, server := range servers {
+		stored := *server
+		s.servers[server.ID] = &stored
+	}
+	return s
+}
+
+// ByNodeID returns the IDs of a node's servers, sorted
+func (s *Servers) ByNodeID(nodeID string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var ids []string
+	for _, server := range s.servers {
+		if server.NodeID == nodeID {
+			ids = append(ids, server.ID)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// DeleteByNodeID deletes the servers of a node and returns how many there
+// were
+func (s *Servers) DeleteByNodeID(ctx context.Context, nodeID string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count := 0
+	for id, server := range s.servers {
+		if server.NodeID == nodeID {
+			delete(s.servers, id)
+			count++
+		}
+	}
+	return count, nil
+}
@@ -43,14 +43,19 @@ func (r *ServerRepository) Create(ctx context.Context, server *models.Server) er
 		return fmt.Errorf("failed to marshal env vars: %w", err)
 	}
 
+	idlePolicyJSON, err := marshalIdlePolicy(server.IdlePolicy)
+	if err != nil {
+		return err
+	}
+
 	query := `
 		INSERT INTO servers (
 			id, name, node_id, game_type, instance_id, status,
 			version, settings, env_vars, max_players, world_name, online_mode,
 			port, query_port, rcon_port, ip_address, player_count,
 			cpu_usage, memory_usage, uptime_seconds,
-			created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22)
+			created_at, updated_at, query_type, idle_policy, fleet_id
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, NULLIF($25, ''))
 	`
 
 	_, err = r.db.ExecContext(ctx, query,
@@ -58,7 +63,7 @@ func (r *ServerRepository) Create(ctx context.Context, server *models.Server) er
 		server.Version, settingsJSON, envVarsJSON, server.MaxPlayers, server.WorldName, server.OnlineMode,
 		server.Port, server.QueryPort, server.RCONPort, server.IPAddress, server.PlayerCount,
 		server.CPUUsage, server.MemoryUsage, server.UptimeSeconds,
-		server.CreatedAt, server.UpdatedAt,
+		server.CreatedAt, server.UpdatedAt, server.QueryType, idlePolicyJSON, server.FleetID,
 	)
 
 	if err != nil {
@@ -80,20 +85,25 @@ func (r *ServerRepository) GetByID(ctx context.Context, id string) (*models.Serv
 			version, settings, env_vars, max_players, world_name, online_mode,
 			port, query_port, rcon_port, ip_address, player_count,
 			cpu_usage, memory_usage, uptime_seconds,
-			created_at, updated_at, started_at
+			created_at, updated_at, started_at, query_type,
+			idle_policy, hibernated_at, hibernated_memory_mb,
+			COALESCE(fleet_id, ''), allocated_at, allocation_expires_at
 		FROM servers WHERE id = $1
 	`
 
 	var server models.Server
 	var settingsJSON, envVarsJSON []byte
 	var startedAt sql.NullTime
+	var idlePolicyJSON sql.NullString
 
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
 		&server.ID, &server.Name, &server.NodeID, &server.GameType, &server.InstanceID, &server.Status,
 		&server.Version, &settingsJSON, &envVarsJSON, &server.MaxPlayers, &server.WorldName, &server.OnlineMode,
 		&server.Port, &server.QueryPort, &server.RCONPort, &server.IPAddress, &server.PlayerCount,
 		&server.CPUUsage, &server.MemoryUsage, &server.UptimeSeconds,
-		&server.CreatedAt, &server.UpdatedAt, &startedAt,
+		&server.CreatedAt, &server.UpdatedAt, &startedAt, &server.QueryType,
+		&idlePolicyJSON, &server.HibernatedAt, &server.HibernatedMemoryMB,
+		&server.FleetID, &server.AllocatedAt, &server.AllocationExpiresAt,
 	)
 
 	if err == sql.ErrNoRows {
@@ -115,6 +125,10 @@ func (r *ServerRepository) GetByID(ctx context.Context, id string) (*models.Serv
 		server.StartedAt = startedAt
 	}
 
+	if server.IdlePolicy, err = unmarshalIdlePolicy(idlePolicyJSON); err != nil {
+		return nil, err
+	}
+
 	return &server, nil
 }
 
@@ -125,7 +139,9 @@ func (r *ServerRepository) List(ctx context.Context, filters *models.ServerFilte
 			version, settings, env_vars, max_players, world_name, online_mode,
 			port, query_port, rcon_port, ip_address, player_count,
 			cpu_usage, memory_usage, uptime_seconds,
-			created_at, updated_at, started_at
+			created_at, updated_at, started_at, query_type,
+			idle_policy, hibernated_at, hibernated_memory_mb,
+			COALESCE(fleet_id, ''), allocated_at, allocation_expires_at
 		FROM servers WHERE 1=1
 	`
 
@@ -150,6 +166,12 @@ func (r *ServerRepository) List(ctx context.Context, filters *models.ServerFilte
 		argNum++
 	}
 
+	if filters.FleetID != "" {
+		query += fmt.Sprintf(" AND fleet_id = $%d", argNum)
+		args = append(args, filters.FleetID)
+		argNum++
+	}
+
 	if filters.HasPlayer != nil {
 		if *filters.HasPlayer {
 			query += fmt.Sprintf(" AND player_count > $%d", argNum)
@@ -184,13 +206,16 @@ func (r *ServerRepository) List(ctx context.Context, filters *models.ServerFilte
 		var server models.Server
 		var settingsJSON, envVarsJSON []byte
 		var startedAt sql.NullTime
+		var idlePolicyJSON sql.NullString
 
 		if err := rows.Scan(
 			&server.ID, &server.Name, &server.NodeID, &server.GameType, &server.InstanceID, &server.Status,
 			&server.Version, &settingsJSON, &envVarsJSON, &server.MaxPlayers, &server.WorldName, &server.OnlineMode,
 			&server.Port, &server.QueryPort, &server.RCONPort, &server.IPAddress, &server.PlayerCount,
 			&server.CPUUsage, &server.MemoryUsage, &server.UptimeSeconds,
-			&server.CreatedAt, &server.UpdatedAt, &startedAt,
+			&server.CreatedAt, &server.UpdatedAt, &startedAt, &server.QueryType,
+		&idlePolicyJSON, &server.HibernatedAt, &server.HibernatedMemoryMB,
+		&server.FleetID, &server.AllocatedAt, &server.AllocationExpiresAt,
 		); err != nil {
 			return nil, fmt.Errorf("failed to scan server: %w", err)
 		}
@@ -207,6 +232,10 @@ func (r *ServerRepository) List(ctx context.Context, filters *models.ServerFilte
 			server.StartedAt = startedAt
 		}
 
+		if server.IdlePolicy, err = unmarshalIdlePolicy(idlePolicyJSON); err != nil {
+			return nil, err
+		}
+
 		servers = append(servers, &server)
 	}
 
@@ -227,13 +256,19 @@ func (r *ServerRepository) Update(ctx context.Context, server *models.Server) er
 		return fmt.Errorf("failed to marshal env vars: %w", err)
 	}
 
+	idlePolicyJSON, err := marshalIdlePolicy(server.IdlePolicy)
+	if err != nil {
+		return err
+	}
+
 	query := `
 		UPDATE servers SET
 			name = $1, status = $2, version = $3, settings = $4, env_vars = $5,
 			max_players = $6, world_name = $7, online_mode = $8,
 			player_count = $9, cpu_usage = $10, memory_usage = $11,
-			uptime_seconds = $12, updated_at = $13, started_at = $14
-		WHERE id = $15
+			uptime_seconds = $12, updated_at = $13, started_at = $14, query_type = $15,
+			idle_policy = $16
+		WHERE id = $17
 	`
 
 	var startedAt interface{}
@@ -247,7 +282,8 @@ func (r *ServerRepository) Update(ctx context.Context, server *models.Server) er
 		server.Name, server.Status, server.Version, settingsJSON, envVarsJSON,
 		server.MaxPlayers, server.WorldName, server.OnlineMode,
 		server.PlayerCount, server.CPUUsage, server.MemoryUsage,
-		server.UptimeSeconds, server.UpdatedAt, startedAt, server.ID,
+		server.UptimeSeconds, server.UpdatedAt, startedAt, server.QueryType,
+		idlePolicyJSON, server.ID,
 	)
 
 	if err != nil {
@@ -269,6 +305,51 @@ func (r *ServerRepository) UpdateStatus(ctx context.Context, id string, status m
 	return nil
 }
 
+// SetHibernated marks a server as hibernating, recording the memory it held
+func (r *ServerRepository) SetHibernated(ctx context.Context, id string, memoryMB int64) error {
+	query := `
+		UPDATE servers SET status = $1, hibernated_at = $2, hibernated_memory_mb = $3, updated_at = $2
+		WHERE id = $4
+	`
+
+	_, err := r.db.ExecContext(ctx, query, models.ServerStatusHibernating, time.Now(), memoryMB, id)
+	if err != nil {
+		return fmt.Errorf("failed to set server hibernated: %w", err)
+	}
+
+	return nil
+}
+
+// ClearHibernated clears the hibernation state of a server
+func (r *ServerRepository) ClearHibernated(ctx context.Context, id string) error {
+	query := `
+		UPDATE servers SET hibernated_at = NULL, hibernated_memory_mb = 0, updated_at = $1
+		WHERE id = $2
+	`
+
+	_, err := r.db.ExecContext(ctx, query, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to clear server hibernation: %w", err)
+	}
+
+	return nil
+}
+
+// UpdatePlacement moves a server to a node with the given address and ports
+func (r *ServerRepository) UpdatePlacement(ctx context.Context, id, nodeID, ipAddress string, port, queryPort, rconPort int) error {
+	query := `
+		UPDATE servers SET node_id = $1, ip_address = $2, port = $3, query_port = $4, rcon_port = $5, updated_at = $6
+		WHERE id = $7
+	`
+
+	_, err := r.db.ExecContext(ctx, query, nodeID, ipAddress, port, queryPort, rconPort, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to update server placement: %w", err)
+	}
+
+	return nil
+}
+
 // Delete deletes a server from the database
 func (r *ServerRepository) Delete(ctx context.Context, id string) error {
 	query := `DELETE FROM servers WHERE id = $1`
@@ -281,6 +362,108 @@ func (r *ServerRepository) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
+// SetRCONPassword stores the encrypted RCON password of a server
+func (r *ServerRepository) SetRCONPassword(ctx context.Context, id string, encrypted string) error {
+	query := `UPDATE servers SET rcon_password = $1, updated_at = $2 WHERE id = $3`
+
+	_, err := r.db.ExecContext(ctx, query, encrypted, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to set rcon password: %w", err)
+	}
+
+	return nil
+}
+
+// GetRCONPassword retrieves the encrypted RCON password of a server, or "" if none is set
+func (r *ServerRepository) GetRCONPassword(ctx context.Context, id string) (string, error) {
+	query := `SELECT rcon_password FROM servers WHERE id = $1`
+
+	var encrypted sql.NullString
+	err := r.db.QueryRowContext(ctx, query, id).Scan(&encrypted)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("server not found: %s", id)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get rcon password: %w", err)
+	}
+
+	return encrypted.String, nil
+}
+
+// SetPropertiesReport stores the server.properties last read from a server's
+// node. Secrets must already be redacted.
+func (r *ServerRepository) SetPropertiesReport(ctx context.Context, report *models.ServerPropertiesReport) error {
+	data, err := json.Marshal(report.Properties)
+	if err != nil {
+		return fmt.Errorf("failed to marshal properties: %w", err)
+	}
+
+	query := `UPDATE servers SET reported_properties = $1, properties_reported_at = $2 WHERE id = $3`
+
+	_, err = r.db.ExecContext(ctx, query, string(data), report.ReportedAt, report.ServerID)
+	if err != nil {
+		return fmt.Errorf("failed to set properties report: %w", err)
+	}
+
+	return nil
+}
+
+// GetPropertiesReport retrieves the server.properties last read from a
+// server's node, or nil if none was read
+func (r *ServerRepository) GetPropertiesReport(ctx context.Context, id string) (*models.ServerPropertiesReport, error) {
+	query := `SELECT reported_properties, properties_reported_at FROM servers WHERE id = $1`
+
+	var data sql.NullString
+	var reportedAt sql.NullTime
+	err := r.db.QueryRowContext(ctx, query, id).Scan(&data, &reportedAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("server not found: %s", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get properties report: %w", err)
+	}
+	if !data.Valid {
+		return nil, nil
+	}
+
+	report := &models.ServerPropertiesReport{ServerID: id, ReportedAt: reportedAt.Time}
+	if err := json.Unmarshal([]byte(data.String), &report.Properties); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal properties: %w", err)
+	}
+
+	return report, nil
+}
+
+// ClearPropertiesReport forgets the server.properties read from a server's
+// node, for a server that moved to another node
+func (r *ServerRepository) ClearPropertiesReport(ctx context.Context, id string) error {
+	query := `UPDATE servers SET reported_properties = NULL, properties_reported_at = NULL WHERE id = $1`
+
+	_, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to clear properties report: %w", err)
+	}
+
+	return nil
+}
+
+// AnyRCONPassword retrieves the encrypted RCON password of any server, or ""
+// if no server has one
+func (r *ServerRepository) AnyRCONPassword(ctx context.Context) (string, error) {
+	query := `SELECT rcon_password FROM servers WHERE rcon_password IS NOT NULL AND rcon_password <> '' LIMIT 1`
+
+	var encrypted string
+	err := r.db.QueryRowContext(ctx, query).Scan(&encrypted)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get rcon password: %w", err)
+	}
+
+	return encrypted, nil
+}
+
 // CountByNode counts servers by node ID
 func (r *ServerRepository) CountByNode(ctx context.Context, nodeID string) (int, error) {
 	query := `SELECT COUNT(*) FROM servers WHERE node_id = $1`
@@ -343,3 +526,29 @@ func (r *ServerRepository) GetByNodeID(ctx context.Context, nodeID string) ([]*m
 	filters := &models.ServerFilters{NodeID: nodeID}
 	return r.List(ctx, filters)
 }
+
+// marshalIdlePolicy encodes an idle policy for storage, NULL if there is none
+func marshalIdlePolicy(policy *models.IdlePolicy) (interface{}, error) {
+	if policy == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(policy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal idle policy: %w", err)
+	}
+	return string(data), nil
+}
+
+// unmarshalIdlePolicy decodes a stored idle policy
+func unmarshalIdlePolicy(data sql.NullString) (*models.IdlePolicy, error) {
+	if !data.Valid || data.String == "" {
+		return nil, nil
+	}
+
+	var policy models.IdlePolicy
+	if err := json.Unmarshal([]byte(data.String), &policy); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal idle policy: %w", err)
+	}
+	return &policy, nil
+}
@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/game-server/controller/internal/core/models"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// SessionRepository handles database operations for player sessions
+type SessionRepository struct {
+	db     *Database
+	logger *zap.Logger
+}
+
+// NewSessionRepository creates a new session repository
+func NewSessionRepository(db *Database, logger *zap.Logger) *SessionRepository {
+	return &SessionRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Open starts a session for each player
+func (r *SessionRepository) Open(ctx context.Context, serverID string, players []string, at time.Time) error {
+	query := `
+		INSERT INTO player_sessions (id, server_id, player_name, joined_at)
+		VALUES ($1, $2, $3, $4)
+	`
+
+	for _, player := range players {
+		if _, err := r.db.ExecContext(ctx, query, uuid.New().String(), serverID, player, at); err != nil {
+			return fmt.Errorf("failed to open player session: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Close ends the open sessions of the given players
+func (r *SessionRepository) Close(ctx context.Context, serverID string, players []string, at time.Time) error {
+	query := `
+		UPDATE player_sessions SET left_at = $1
+		WHERE server_id = $2 AND player_name = $3 AND left_at IS NULL
+	`
+
+	for _, player := range players {
+		if _, err := r.db.ExecContext(ctx, query, at, serverID, player); err != nil {
+			return fmt.Errorf("failed to close player session: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// CloseAll ends all open sessions of a server
+func (r *SessionRepository) CloseAll(ctx context.Context, serverID string, at time.Time) error {
+	query := `UPDATE player_sessions SET left_at = $1 WHERE server_id = $2 AND left_at IS NULL`
+
+	if _, err := r.db.ExecContext(ctx, query, at, serverID); err != nil {
+		return fmt.Errorf("failed to close player sessions: %w", err)
+	}
+
+	return nil
+}
+
+// ListOpen retrieves the open sessions of a server
+func (r *SessionRepository) ListOpen(ctx context.Context, serverID string) ([]*models.PlayerSession, error) {
+	query := `
+		SELECT id, server_id, player_name, joined_at, left_at
+		FROM player_sessions
+		WHERE server_id = $1 AND left_at IS NULL
+		ORDER BY joined_at
+	`
+
+	return r.query(ctx, query, serverID)
+}
+
+// ListByPlayer retrieves the most recent sessions of a server, optionally
+// only those of one player
+func (r *SessionRepository) ListByPlayer(ctx context.Context, serverID, player string, limit int) ([]*models.PlayerSession, error) {
+	query := `
+		SELECT id, server_id, player_name, joined_at, left_at
+		FROM player_sessions
+		WHERE server_id = $1 AND ($2 = '' OR player_name = $2)
+		ORDER BY joined_at DESC
+		LIMIT $3
+	`
+
+	return r.query(ctx, query, serverID, player, limit)
+}
+
+// ListOverlapping retrieves the sessions of a server that were open at any
+// time between from and to
+func (r *SessionRepository) ListOverlapping(ctx context.Context, serverID string, from, to time.Time) ([]*models.PlayerSession, error) {
+	query := `
+		SELECT id, server_id, player_name, joined_at, left_at
+		FROM player_sessions
+		WHERE server_id = $1 AND joined_at < $3 AND (left_at IS NULL OR left_at > $2)
+		ORDER BY joined_at
+	`
+
+	return r.query(ctx, query, serverID, from, to)
+}
+
+// UsageSince aggregates unique players and session counts per server for
+// sessions that were open at any time since from
+func (r *SessionRepository) UsageSince(ctx context.Context, from time.Time) (map[string]*models.ServerUsage, error) {
+	query := `
+		SELECT server_id, COUNT(DISTINCT player_name), COUNT(*),
+			COALESCE(SUM(EXTRACT(EPOCH FROM (COALESCE(left_at, $2) - GREATEST(joined_at, $1)))), 0)
+		FROM player_sessions
+		WHERE left_at IS NULL OR left_at > $1
+		GROUP BY server_id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, from, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate player sessions: %w", err)
+	}
+	defer rows.Close()
+
+	usage := make(map[string]*models.ServerUsage)
+	for rows.Next() {
+		var u models.ServerUsage
+		var playtime float64
+		if err := rows.Scan(&u.ServerID, &u.UniquePlayers, &u.Sessions, &playtime); err != nil {
+			return nil, fmt.Errorf("failed to scan server usage: %w", err)
+		}
+		u.PlaytimeSeconds = int64(playtime)
+		usage[u.ServerID] = &u
+	}
+
+	return usage, nil
+}
+
+func (r *SessionRepository) query(ctx context.Context, query string, args ...interface{}) ([]*models.PlayerSession, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list player sessions: %w", err)
+	}
+	defer rows.Close()
+
+	now := time.Now()
+	sessions := []*models.PlayerSession{}
+	for rows.Next() {
+		var session models.PlayerSession
+		var leftAt sql.NullTime
+
+		if err := rows.Scan(&session.ID, &session.ServerID, &session.PlayerName, &session.JoinedAt, &leftAt); err != nil {
+			return nil, fmt.Errorf("failed to scan player session: %w", err)
+		}
+
+		end := now
+		if leftAt.Valid {
+			session.LeftAt = &leftAt.Time
+			end = leftAt.Time
+		} else {
+			session.Online = true
+		}
+		session.DurationSeconds = int64(end.Sub(session.JoinedAt).Seconds())
+
+		sessions = append(sessions, &session)
+	}
+
+	return sessions, nil
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// SettingsRepository handles database operations for controller settings
+type SettingsRepository struct {
+	db     *Database
+	logger *zap.Logger
+}
+
+// NewSettingsRepository creates a new settings repository
+func NewSettingsRepository(db *Database, logger *zap.Logger) *SettingsRepository {
+	return &SettingsRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Get retrieves a setting, or "" if it is not set
+func (r *SettingsRepository) Get(ctx context.Context, name string) (string, error) {
+	query := `SELECT value FROM controller_settings WHERE name = $1`
+
+	var value string
+	err := r.db.QueryRowContext(ctx, query, name).Scan(&value)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get setting: %w", err)
+	}
+
+	return value, nil
+}
+
+// Create stores a setting that is not set yet
+func (r *SettingsRepository) Create(ctx context.Context, name, value string) error {
+	query := `INSERT INTO controller_settings (name, value, created_at) VALUES ($1, $2, $3)`
+
+	_, err := r.db.ExecContext(ctx, query, name, value, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to create setting: %w", err)
+	}
+
+	return nil
+}
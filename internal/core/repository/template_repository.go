@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/game-server/controller/internal/core/models"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// TemplateRepository handles database operations for server templates
+type TemplateRepository struct {
+	db     *Database
+	logger *zap.Logger
+}
+
+// NewTemplateRepository creates a new template repository
+func NewTemplateRepository(db *Database, logger *zap.Logger) *TemplateRepository {
+	return &TemplateRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Create creates a new template in the database. A preset ID is kept, so a
+// world archive can be saved under the ID before the row exists.
+func (r *TemplateRepository) Create(ctx context.Context, template *models.ServerTemplate) error {
+	if template.ID == "" {
+		template.ID = uuid.New().String()
+	}
+	template.CreatedAt = time.Now()
+	template.UpdatedAt = time.Now()
+
+	configJSON, err := json.Marshal(template.Config)
+	if err != nil {
+		return fmt.Errorf("failed to marshal template config: %w", err)
+	}
+	requirementsJSON, err := json.Marshal(template.Requirements)
+	if err != nil {
+		return fmt.Errorf("failed to marshal template requirements: %w", err)
+	}
+
+	var sourceServerID interface{}
+	if template.SourceServerID != "" {
+		sourceServerID = template.SourceServerID
+	}
+
+	query := `
+		INSERT INTO server_templates (
+			id, name, game_type, config, requirements, has_world,
+			source_server_id, host_id, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+
+	_, err = r.db.ExecContext(ctx, query,
+		template.ID, template.Name, template.GameType, configJSON, requirementsJSON, template.HasWorld,
+		sourceServerID, hostIDValue(template.HostID), template.CreatedAt, template.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create template: %w", err)
+	}
+
+	r.logger.Info("Template created",
+		zap.String("template_id", template.ID),
+		zap.String("name", template.Name))
+
+	return nil
+}
+
+// GetByID retrieves a template by ID
+func (r *TemplateRepository) GetByID(ctx context.Context, id string) (*models.ServerTemplate, error) {
+	query := `
+		SELECT id, name, game_type, config, requirements, has_world,
+			source_server_id, host_id, created_at, updated_at
+		FROM server_templates WHERE id = $1
+	`
+
+	template, err := scanTemplate(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get template: %w", err)
+	}
+
+	return template, nil
+}
+
+// List retrieves all templates
+func (r *TemplateRepository) List(ctx context.Context) ([]*models.ServerTemplate, error) {
+	query := `
+		SELECT id, name, game_type, config, requirements, has_world,
+			source_server_id, host_id, created_at, updated_at
+		FROM server_templates ORDER BY name
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list templates: %w", err)
+	}
+	defer rows.Close()
+
+	templates := []*models.ServerTemplate{}
+	for rows.Next() {
+		template, err := scanTemplate(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan template: %w", err)
+		}
+		templates = append(templates, template)
+	}
+
+	return templates, nil
+}
+
+// Delete deletes a template from the database
+func (r *TemplateRepository) Delete(ctx context.Context, id string) error {
+	query := `DELETE FROM server_templates WHERE id = $1`
+
+	_, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete template: %w", err)
+	}
+
+	return nil
+}
+
+func scanTemplate(row rowScanner) (*models.ServerTemplate, error) {
+	var template models.ServerTemplate
+	var configJSON, requirementsJSON []byte
+	var sourceServerID, hostID sql.NullString
+
+	if err := row.Scan(
+		&template.ID, &template.Name, &template.GameType, &configJSON, &requirementsJSON, &template.HasWorld,
+		&sourceServerID, &hostID, &template.CreatedAt, &template.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(configJSON, &template.Config); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal template config: %w", err)
+	}
+	if err := json.Unmarshal(requirementsJSON, &template.Requirements); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal template requirements: %w", err)
+	}
+	template.SourceServerID = sourceServerID.String
+	if template.HasWorld {
+		template.HostID = hostIDOf(hostID)
+	}
+
+	return &template, nil
+}
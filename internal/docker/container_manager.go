@@ -2,22 +2,20 @@ package docker
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"strconv"
-
-	"github.com/docker/docker/api/types/container"
-	"github.com/docker/docker/api/types/filters"
-	"github.com/docker/docker/api/types/image"
-	"github.com/docker/docker/api/types/network"
-	"github.com/docker/docker/api/types/volume"
-	"github.com/docker/docker/client"
-	"github.com/docker/go-connections/nat"
+	"io"
+	"strings"
+
 	"go.uber.org/zap"
 )
 
-// ContainerManager manages Docker containers for game server nodes
+// agentPort is the gRPC port of a node agent inside its container
+const agentPort = "50051/tcp"
+
+// ContainerManager manages the containers of game server nodes
 type ContainerManager struct {
-	client     *client.Client
+	runtime    ContainerRuntime
 	volumeMgr  *VolumeManager
 	logger     *zap.Logger
 }
@@ -37,30 +35,48 @@ type NodeContainerConfig struct {
 }
 
 // NewContainerManager creates a new container manager
-func NewContainerManager(volumeMgr *VolumeManager, logger *zap.Logger) (*ContainerManager, error) {
-	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
-	if err != nil {
-		return nil, fmt.Errorf("failed to create Docker client: %w", err)
-	}
-
+func NewContainerManager(runtime ContainerRuntime, volumeMgr *VolumeManager, logger *zap.Logger) *ContainerManager {
 	return &ContainerManager{
-		client:    cli,
+		runtime:   runtime,
 		volumeMgr: volumeMgr,
 		logger:    logger,
-	}, nil
+	}
 }
 
 // CreateNodeContainer creates a new node container with volumes
 func (cm *ContainerManager) CreateNodeContainer(ctx context.Context, cfg *NodeContainerConfig) (string, error) {
 	// Pull the latest image first
-	cm.logger.Info("Pulling latest node agent image", zap.String("image", cfg.Image))
-	reader, err := cm.client.ImagePull(ctx, cfg.Image, image.PullOptions{})
-	if err != nil {
-		return "", fmt.Errorf("failed to pull image %s: %w", cfg.Image, err)
+	if err := cm.pullImage(ctx, cfg.Image); err != nil {
+		return "", err
+	}
+
+	return cm.createNodeContainer(ctx, cfg)
+}
+
+// pullImage pulls the node agent image. An image ID cannot be pulled, so it
+// must already be present.
+func (cm *ContainerManager) pullImage(ctx context.Context, image string) error {
+	if strings.HasPrefix(image, "sha256:") {
+		exists, err := cm.runtime.ImageExists(ctx, image)
+		if err != nil {
+			return fmt.Errorf("failed to check image %s: %w", image, err)
+		}
+		if !exists {
+			return fmt.Errorf("%w: image %s", ErrNotFound, image)
+		}
+		return nil
 	}
-	// Close the reader to ensure the pull is complete
-	reader.Close()
 
+	cm.logger.Info("Pulling latest node agent image", zap.String("image", image))
+	if err := cm.runtime.PullImage(ctx, image); err != nil {
+		return fmt.Errorf("failed to pull image %s: %w", image, err)
+	}
+	return nil
+}
+
+// createNodeContainer creates and starts a node container from an image
+// that is already present
+func (cm *ContainerManager) createNodeContainer(ctx context.Context, cfg *NodeContainerConfig) (string, error) {
 	// Create volumes first
 	volumeNames := cm.volumeMgr.GetNodeVolumeNames(cfg.NodeID)
 	if err := cm.createVolumes(ctx, volumeNames); err != nil {
@@ -89,7 +105,7 @@ func (cm *ContainerManager) CreateNodeContainer(ctx context.Context, cfg *NodeCo
 	}
 
 	// Container name
-	containerName := fmt.Sprintf("game-server-node-%s", cfg.NodeID)
+	containerName := nodeContainerName(cfg.NodeID)
 
 	// Build volume binds
 	binds := []string{
@@ -99,77 +115,48 @@ func (cm *ContainerManager) CreateNodeContainer(ctx context.Context, cfg *NodeCo
 	}
 
 	// Container configuration
-	containerConfig := &container.Config{
+	spec := &ContainerSpec{
+		Name:  containerName,
 		Image: cfg.Image,
 		Env:   envVars,
-		ExposedPorts: nat.PortSet{
-			"50051/tcp": struct{}{},
-		},
 		Labels: map[string]string{
 			"game-server.node-id":   cfg.NodeID,
 			"game-server.node-name": cfg.NodeName,
 			"game-server.managed":   "true",
 		},
-	}
-
-	// Host configuration
-	hostConfig := &container.HostConfig{
-		Binds: binds,
-		PortBindings: nat.PortMap{
-			"50051/tcp": []nat.PortBinding{
-				{HostIP: "0.0.0.0"}, // Docker will assign random port
-			},
-		},
-		RestartPolicy: container.RestartPolicy{
-			Name: "unless-stopped",
-		},
-		Resources: container.Resources{
-			NanoCPUs: int64(cfg.TotalCPUCores) * 1e9,
-			Memory:   cfg.TotalMemoryMB * 1024 * 1024,
-		},
-	}
-
-	// Network configuration
-	networkConfig := &network.NetworkingConfig{
-		EndpointsConfig: map[string]*network.EndpointSettings{
-			cfg.NetworkName: {},
-		},
+		Binds:          binds,
+		PublishedPorts: []string{agentPort},
+		Network:        cfg.NetworkName,
+		RestartPolicy:  "unless-stopped",
+		NanoCPUs:       int64(cfg.TotalCPUCores) * 1e9,
+		MemoryBytes:    cfg.TotalMemoryMB * 1024 * 1024,
 	}
 
 	// Create container
-	resp, err := cm.client.ContainerCreate(ctx,
-		containerConfig,
-		hostConfig,
-		networkConfig,
-		nil,
-		containerName,
-	)
+	containerID, err := cm.runtime.CreateContainer(ctx, spec)
 	if err != nil {
 		return "", fmt.Errorf("failed to create container: %w", err)
 	}
 
 	// Start container
-	if err := cm.client.ContainerStart(ctx, resp.ID, container.StartOptions{}); err != nil {
+	if err := cm.runtime.StartContainer(ctx, containerID); err != nil {
 		// Clean up container on start failure
-		_ = cm.client.ContainerRemove(ctx, resp.ID, container.RemoveOptions{Force: true})
+		_ = cm.runtime.RemoveContainer(ctx, containerID, true)
 		return "", fmt.Errorf("failed to start container: %w", err)
 	}
 
 	cm.logger.Info("Node container created and started",
-		zap.String("container_id", resp.ID),
+		zap.String("container_id", containerID),
 		zap.String("node_id", cfg.NodeID),
 		zap.String("container_name", containerName))
 
-	return resp.ID, nil
+	return containerID, nil
 }
 
 // createVolumes creates the volumes for a node
 func (cm *ContainerManager) createVolumes(ctx context.Context, volumeNames []string) error {
 	for _, name := range volumeNames {
-		_, err := cm.client.VolumeCreate(ctx, volume.CreateOptions{
-			Name: name,
-		})
-		if err != nil {
+		if err := cm.runtime.CreateVolume(ctx, name); err != nil {
 			return fmt.Errorf("failed to create volume %s: %w", name, err)
 		}
 		cm.logger.Debug("Created volume", zap.String("volume", name))
@@ -187,8 +174,7 @@ func (cm *ContainerManager) StopNodeContainer(ctx context.Context, nodeID string
 		return fmt.Errorf("container not found for node: %s", nodeID)
 	}
 
-	timeout := 30
-	if err := cm.client.ContainerStop(ctx, containerID, container.StopOptions{Timeout: &timeout}); err != nil {
+	if err := cm.runtime.StopContainer(ctx, containerID, 30); err != nil {
 		return fmt.Errorf("failed to stop container: %w", err)
 	}
 
@@ -199,6 +185,48 @@ func (cm *ContainerManager) StopNodeContainer(ctx context.Context, nodeID string
 	return nil
 }
 
+// StartNodeContainer starts a stopped node container
+func (cm *ContainerManager) StartNodeContainer(ctx context.Context, nodeID string) error {
+	containerID, err := cm.findContainerByNodeID(ctx, nodeID)
+	if err != nil {
+		return err
+	}
+	if containerID == "" {
+		return fmt.Errorf("container not found for node: %s", nodeID)
+	}
+
+	if err := cm.runtime.StartContainer(ctx, containerID); err != nil {
+		return fmt.Errorf("failed to start container: %w", err)
+	}
+
+	cm.logger.Info("Node container started",
+		zap.String("node_id", nodeID),
+		zap.String("container_id", containerID))
+
+	return nil
+}
+
+// RestartNodeContainer stops and starts a node container
+func (cm *ContainerManager) RestartNodeContainer(ctx context.Context, nodeID string) error {
+	containerID, err := cm.findContainerByNodeID(ctx, nodeID)
+	if err != nil {
+		return err
+	}
+	if containerID == "" {
+		return fmt.Errorf("container not found for node: %s", nodeID)
+	}
+
+	if err := cm.runtime.RestartContainer(ctx, containerID, 30); err != nil {
+		return fmt.Errorf("failed to restart container: %w", err)
+	}
+
+	cm.logger.Info("Node container restarted",
+		zap.String("node_id", nodeID),
+		zap.String("container_id", containerID))
+
+	return nil
+}
+
 // RemoveNodeContainer removes a node container
 func (cm *ContainerManager) RemoveNodeContainer(ctx context.Context, nodeID string) error {
 	containerID, err := cm.findContainerByNodeID(ctx, nodeID)
@@ -211,9 +239,7 @@ func (cm *ContainerManager) RemoveNodeContainer(ctx context.Context, nodeID stri
 	}
 
 	// Remove container
-	if err := cm.client.ContainerRemove(ctx, containerID, container.RemoveOptions{
-		Force: true,
-	}); err != nil {
+	if err := cm.runtime.RemoveContainer(ctx, containerID, true); err != nil {
 		return fmt.Errorf("failed to remove container: %w", err)
 	}
 
@@ -224,6 +250,87 @@ func (cm *ContainerManager) RemoveNodeContainer(ctx context.Context, nodeID stri
 	return nil
 }
 
+// RecreateNodeContainer replaces a node's container with one created from
+// cfg, keeping the node's volumes. The image is pulled before the old
+// container is stopped, and the old container is only removed once the new
+// one has started. If the new container cannot be created or started, the
+// old one is restored.
+func (cm *ContainerManager) RecreateNodeContainer(ctx context.Context, cfg *NodeContainerConfig) (string, error) {
+	containerID, err := cm.findContainerByNodeID(ctx, cfg.NodeID)
+	if err != nil {
+		return "", err
+	}
+
+	if err := cm.pullImage(ctx, cfg.Image); err != nil {
+		return "", err
+	}
+
+	if containerID == "" {
+		return cm.createNodeContainer(ctx, cfg)
+	}
+
+	old, err := cm.runtime.InspectContainer(ctx, containerID)
+	if err != nil {
+		return "", fmt.Errorf("failed to inspect container: %w", err)
+	}
+	wasRunning := old.Status == "running"
+
+	if err := cm.runtime.StopContainer(ctx, containerID, 30); err != nil {
+		return "", fmt.Errorf("failed to stop container: %w", err)
+	}
+
+	// Move the old container aside so the new one can take its name
+	name := nodeContainerName(cfg.NodeID)
+	if err := cm.runtime.RenameContainer(ctx, containerID, name+"-replaced"); err != nil {
+		cm.restoreNodeContainer(ctx, containerID, "", wasRunning)
+		return "", fmt.Errorf("failed to rename container: %w", err)
+	}
+
+	newID, err := cm.createNodeContainer(ctx, cfg)
+	if err != nil {
+		cm.restoreNodeContainer(ctx, containerID, name, wasRunning)
+		return "", err
+	}
+
+	if err := cm.runtime.RemoveContainer(ctx, containerID, false); err != nil {
+		cm.logger.Warn("Failed to remove replaced node container",
+			zap.Error(err),
+			zap.String("node_id", cfg.NodeID),
+			zap.String("container_id", containerID))
+	}
+
+	cm.logger.Info("Node container recreated",
+		zap.String("node_id", cfg.NodeID),
+		zap.String("old_container_id", containerID),
+		zap.String("container_id", newID))
+
+	return newID, nil
+}
+
+// restoreNodeContainer gives a node's old container back its name, unless
+// name is empty, and starts it again if it was running
+func (cm *ContainerManager) restoreNodeContainer(ctx context.Context, containerID, name string, start bool) {
+	if name != "" {
+		if err := cm.runtime.RenameContainer(ctx, containerID, name); err != nil {
+			cm.logger.Error("Failed to restore node container name",
+				zap.Error(err),
+				zap.String("container_id", containerID))
+		}
+	}
+	if start {
+		if err := cm.runtime.StartContainer(ctx, containerID); err != nil {
+			cm.logger.Error("Failed to restart node container",
+				zap.Error(err),
+				zap.String("container_id", containerID))
+		}
+	}
+}
+
+// nodeContainerName returns the name of a node's container
+func nodeContainerName(nodeID string) string {
+	return fmt.Sprintf("game-server-node-%s", nodeID)
+}
+
 // GetNodeContainerInfo returns information about a node container
 func (cm *ContainerManager) GetNodeContainerInfo(ctx context.Context, nodeID string) (*ContainerInfo, error) {
 	containerID, err := cm.findContainerByNodeID(ctx, nodeID)
@@ -234,64 +341,88 @@ func (cm *ContainerManager) GetNodeContainerInfo(ctx context.Context, nodeID str
 		return nil, nil
 	}
 
-	info, err := cm.client.ContainerInspect(ctx, containerID)
+	info, err := cm.runtime.InspectContainer(ctx, containerID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to inspect container: %w", err)
 	}
 
-	// Get the host port
-	var hostPort int
-	if ports, ok := info.NetworkSettings.Ports["50051/tcp"]; ok && len(ports) > 0 {
-		hostPort, _ = strconv.Atoi(ports[0].HostPort)
+	info.HostPort = info.Ports[agentPort]
+	info.NodeID = info.Labels["game-server.node-id"]
+
+	return info, nil
+}
+
+// NodeContainerLogs returns the logs of a node container. The caller must
+// close the reader, which ends a followed stream.
+func (cm *ContainerManager) NodeContainerLogs(ctx context.Context, nodeID string, opts LogOptions) (io.ReadCloser, error) {
+	containerID, err := cm.findContainerByNodeID(ctx, nodeID)
+	if err != nil {
+		return nil, err
+	}
+	if containerID == "" {
+		return nil, fmt.Errorf("%w: container for node %s", ErrNotFound, nodeID)
 	}
 
-	return &ContainerInfo{
-		ID:         info.ID,
-		Name:       info.Name,
-		Status:     info.State.Status,
-		HostPort:   hostPort,
-		IPAddress:  info.NetworkSettings.IPAddress,
-		Created:    info.Created,
-		Image:      info.Config.Image,
-	}, nil
+	logs, err := cm.runtime.ContainerLogs(ctx, containerID, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get container logs: %w", err)
+	}
+	return logs, nil
 }
 
-// ListNodeContainers lists all node containers
+// ListNodeContainers lists all running node containers
 func (cm *ContainerManager) ListNodeContainers(ctx context.Context) ([]*ContainerInfo, error) {
-	containers, err := cm.client.ContainerList(ctx, container.ListOptions{
-		Filters: filters.NewArgs(filters.KeyValuePair{
-			Key:   "label",
-			Value: "game-server.managed=true",
-		}),
-	})
+	return cm.listManagedContainers(ctx, false)
+}
+
+// ListAllNodeContainers lists node containers in any state, including
+// stopped ones
+func (cm *ContainerManager) ListAllNodeContainers(ctx context.Context) ([]*ContainerInfo, error) {
+	return cm.listManagedContainers(ctx, true)
+}
+
+// listManagedContainers lists the containers labelled as managed by the
+// controller
+func (cm *ContainerManager) listManagedContainers(ctx context.Context, all bool) ([]*ContainerInfo, error) {
+	containers, err := cm.runtime.ListContainers(ctx, "game-server.managed=true", all)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list containers: %w", err)
 	}
 
-	var result []*ContainerInfo
 	for _, c := range containers {
-		info := &ContainerInfo{
-			ID:      c.ID,
-			Name:    c.Names[0],
-			Status:  c.State,
-			Image:   c.Image,
-			NodeID:  c.Labels["game-server.node-id"],
+		c.NodeID = c.Labels["game-server.node-id"]
+	}
+
+	return containers, nil
+}
+
+// RemoveContainer force-removes a container by ID, for containers that may
+// not belong to a known node
+func (cm *ContainerManager) RemoveContainer(ctx context.Context, containerID string) error {
+	if err := cm.runtime.RemoveContainer(ctx, containerID, true); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil
 		}
-		result = append(result, info)
+		return fmt.Errorf("failed to remove container: %w", err)
 	}
 
-	return result, nil
+	cm.logger.Info("Container removed", zap.String("container_id", containerID))
+
+	return nil
+}
+
+// ContainerStats samples the resource usage of a running container by ID
+func (cm *ContainerManager) ContainerStats(ctx context.Context, containerID string) (*ContainerStats, error) {
+	stats, err := cm.runtime.ContainerStats(ctx, containerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get container stats: %w", err)
+	}
+	return stats, nil
 }
 
 // findContainerByNodeID finds a container by node ID label
 func (cm *ContainerManager) findContainerByNodeID(ctx context.Context, nodeID string) (string, error) {
-	containers, err := cm.client.ContainerList(ctx, container.ListOptions{
-		All: true,
-		Filters: filters.NewArgs(filters.KeyValuePair{
-			Key:   "label",
-			Value: fmt.Sprintf("game-server.node-id=%s", nodeID),
-		}),
-	})
+	containers, err := cm.runtime.ListContainers(ctx, fmt.Sprintf("game-server.node-id=%s", nodeID), true)
 	if err != nil {
 		return "", fmt.Errorf("failed to list containers: %w", err)
 	}
@@ -300,12 +431,15 @@ func (cm *ContainerManager) findContainerByNodeID(ctx context.Context, nodeID st
 		return "", nil
 	}
 
-	return containers[0].ID, nil
-}
+	// A container replaced by RecreateNodeContainer carries the same label
+	// until it is removed
+	for _, c := range containers {
+		if c.Name == "/"+nodeContainerName(nodeID) {
+			return c.ID, nil
+		}
+	}
 
-// Close closes the Docker client
-func (cm *ContainerManager) Close() error {
-	return cm.client.Close()
+	return containers[0].ID, nil
 }
 
 // ContainerInfo holds information about a container
@@ -317,5 +451,8 @@ type ContainerInfo struct {
 	IPAddress string
 	Created   string
 	Image     string
+	ImageID   string // "sha256:...", which stays valid when Image's tag moves
 	NodeID    string
+	Labels    map[string]string
+	Ports     map[string]int // container port ("50051/tcp") to host port
 }
@@ -0,0 +1,415 @@
+package docker
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/client"
+	"github.com/docker/go-connections/nat"
+)
+
+// DockerRuntime is the ContainerRuntime of a Docker daemon
+type DockerRuntime struct {
+	client *client.Client
+}
+
+// NewDockerRuntime connects to the Docker daemon configured by the
+// environment (DOCKER_HOST and friends)
+func NewDockerRuntime() (*DockerRuntime, error) {
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Docker client: %w", err)
+	}
+
+	return &DockerRuntime{client: cli}, nil
+}
+
+// TLSCerts are the PEM-encoded certificates used to reach a Docker daemon
+// that requires TLS, as set up with dockerd --tlsverify
+type TLSCerts struct {
+	CACert string // verifies the daemon, the system roots if empty
+	Cert   string // client certificate, with Key
+	Key    string
+}
+
+// NewRemoteDockerRuntime connects to the Docker daemon at endpoint, for
+// example "tcp://10.0.0.5:2376". certs may be nil for a daemon without TLS.
+func NewRemoteDockerRuntime(endpoint string, certs *TLSCerts) (*DockerRuntime, error) {
+	transport := &http.Transport{}
+	if certs != nil {
+		tlsConfig, err := certs.tlsConfig()
+		if err != nil {
+			return nil, err
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	// The HTTP client must be set before the host, which configures its
+	// transport
+	cli, err := client.NewClientWithOpts(
+		client.WithHTTPClient(&http.Client{Transport: transport}),
+		client.WithHost(endpoint),
+		client.WithAPIVersionNegotiation(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Docker client for %s: %w", endpoint, err)
+	}
+
+	return &DockerRuntime{client: cli}, nil
+}
+
+// tlsConfig builds the client TLS configuration from the certificates
+func (c *TLSCerts) tlsConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if c.CACert != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(c.CACert)) {
+			return nil, fmt.Errorf("failed to parse TLS CA certificate")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if c.Cert != "" || c.Key != "" {
+		cert, err := tls.X509KeyPair([]byte(c.Cert), []byte(c.Key))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse TLS client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// ImageExists reports whether an image is present locally
+func (r *DockerRuntime) ImageExists(ctx context.Context, ref string) (bool, error) {
+	if _, _, err := r.client.ImageInspectWithRaw(ctx, ref); err != nil {
+		if client.IsErrNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// PullImage pulls an image and waits for the pull to complete
+func (r *DockerRuntime) PullImage(ctx context.Context, ref string) error {
+	reader, err := r.client.ImagePull(ctx, ref, image.PullOptions{})
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	// The pull only completes once the progress stream is read
+	_, err = io.Copy(io.Discard, reader)
+	return err
+}
+
+// CreateContainer creates a container and returns its ID
+func (r *DockerRuntime) CreateContainer(ctx context.Context, spec *ContainerSpec) (string, error) {
+	containerConfig := &container.Config{
+		Image:  spec.Image,
+		Cmd:    spec.Cmd,
+		Env:    spec.Env,
+		Labels: spec.Labels,
+	}
+	hostConfig := &container.HostConfig{
+		Binds: spec.Binds,
+		RestartPolicy: container.RestartPolicy{
+			Name: container.RestartPolicyMode(spec.RestartPolicy),
+		},
+		Resources: container.Resources{
+			NanoCPUs: spec.NanoCPUs,
+			Memory:   spec.MemoryBytes,
+		},
+	}
+
+	if len(spec.PublishedPorts) > 0 {
+		containerConfig.ExposedPorts = nat.PortSet{}
+		hostConfig.PortBindings = nat.PortMap{}
+		for _, p := range spec.PublishedPorts {
+			port := nat.Port(p)
+			containerConfig.ExposedPorts[port] = struct{}{}
+			// Docker assigns a random host port
+			hostConfig.PortBindings[port] = []nat.PortBinding{{HostIP: "0.0.0.0"}}
+		}
+	}
+
+	var networkConfig *network.NetworkingConfig
+	if spec.Network != "" {
+		networkConfig = &network.NetworkingConfig{
+			EndpointsConfig: map[string]*network.EndpointSettings{
+				spec.Network: {},
+			},
+		}
+	}
+
+	resp, err := r.client.ContainerCreate(ctx, containerConfig, hostConfig, networkConfig, nil, spec.Name)
+	if err != nil {
+		return "", err
+	}
+	return resp.ID, nil
+}
+
+// StartContainer starts a container
+func (r *DockerRuntime) StartContainer(ctx context.Context, id string) error {
+	return wrapNotFound(r.client.ContainerStart(ctx, id, container.StartOptions{}))
+}
+
+// StopContainer stops a container, killing it after timeout seconds
+func (r *DockerRuntime) StopContainer(ctx context.Context, id string, timeout int) error {
+	return wrapNotFound(r.client.ContainerStop(ctx, id, container.StopOptions{Timeout: &timeout}))
+}
+
+// RestartContainer restarts a container, killing it after timeout seconds
+func (r *DockerRuntime) RestartContainer(ctx context.Context, id string, timeout int) error {
+	return wrapNotFound(r.client.ContainerRestart(ctx, id, container.StopOptions{Timeout: &timeout}))
+}
+
+// RemoveContainer removes a container, stopping it first if force is set
+func (r *DockerRuntime) RemoveContainer(ctx context.Context, id string, force bool) error {
+	return wrapNotFound(r.client.ContainerRemove(ctx, id, container.RemoveOptions{Force: force}))
+}
+
+// RenameContainer gives a container a new name
+func (r *DockerRuntime) RenameContainer(ctx context.Context, id string, name string) error {
+	return wrapNotFound(r.client.ContainerRename(ctx, id, name))
+}
+
+// WaitContainer waits until a container is not running and returns its exit
+// code
+func (r *DockerRuntime) WaitContainer(ctx context.Context, id string) (int64, error) {
+	statusCh, errCh := r.client.ContainerWait(ctx, id, container.WaitConditionNotRunning)
+
+	select {
+	case status := <-statusCh:
+		if status.Error != nil {
+			return 0, fmt.Errorf("%s", status.Error.Message)
+		}
+		return status.StatusCode, nil
+	case err := <-errCh:
+		return 0, wrapNotFound(err)
+	}
+}
+
+// InspectContainer returns information about a container
+func (r *DockerRuntime) InspectContainer(ctx context.Context, id string) (*ContainerInfo, error) {
+	info, err := r.client.ContainerInspect(ctx, id)
+	if err != nil {
+		return nil, wrapNotFound(err)
+	}
+
+	ports := make(map[string]int)
+	for port, bindings := range info.NetworkSettings.Ports {
+		if len(bindings) > 0 {
+			ports[string(port)], _ = strconv.Atoi(bindings[0].HostPort)
+		}
+	}
+
+	// Containers on user-defined networks only have an address per network
+	ipAddress := info.NetworkSettings.IPAddress
+	for _, n := range info.NetworkSettings.Networks {
+		if ipAddress == "" && n.IPAddress != "" {
+			ipAddress = n.IPAddress
+		}
+	}
+
+	return &ContainerInfo{
+		ID:        info.ID,
+		Name:      info.Name,
+		Status:    info.State.Status,
+		Ports:     ports,
+		IPAddress: ipAddress,
+		Created:   info.Created,
+		Image:     info.Config.Image,
+		ImageID:   info.Image,
+		Labels:    info.Config.Labels,
+	}, nil
+}
+
+// ContainerLogs returns a container's stdout and stderr as plain text
+func (r *DockerRuntime) ContainerLogs(ctx context.Context, id string, opts LogOptions) (io.ReadCloser, error) {
+	stream, err := r.client.ContainerLogs(ctx, id, container.LogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Since:      opts.since(),
+		Timestamps: opts.Timestamps,
+		Follow:     opts.Follow,
+		Tail:       opts.tail(),
+	})
+	if err != nil {
+		return nil, wrapNotFound(err)
+	}
+	return demuxLogs(stream), nil
+}
+
+// ContainerStats samples the resource usage of a running container. Docker
+// takes two readings about a second apart to work out the CPU usage.
+func (r *DockerRuntime) ContainerStats(ctx context.Context, id string) (*ContainerStats, error) {
+	resp, err := r.client.ContainerStats(ctx, id, false)
+	if err != nil {
+		return nil, wrapNotFound(err)
+	}
+	defer resp.Body.Close()
+
+	var raw types.StatsJSON
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("failed to decode container stats: %w", err)
+	}
+
+	stats := &ContainerStats{
+		MemoryUsageBytes: int64(raw.MemoryStats.Usage),
+		MemoryLimitBytes: int64(raw.MemoryStats.Limit),
+	}
+
+	cpuDelta := float64(raw.CPUStats.CPUUsage.TotalUsage) - float64(raw.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(raw.CPUStats.SystemUsage) - float64(raw.PreCPUStats.SystemUsage)
+	if cpuDelta > 0 && systemDelta > 0 {
+		cpus := float64(raw.CPUStats.OnlineCPUs)
+		if cpus == 0 {
+			cpus = float64(len(raw.CPUStats.CPUUsage.PercpuUsage))
+		}
+		stats.CPUPercent = cpuDelta / systemDelta * cpus * 100
+	}
+
+	// Like docker stats, leave out the page cache the kernel can reclaim:
+	// inactive_file on cgroup v2, total_inactive_file on v1
+	cache, ok := raw.MemoryStats.Stats["inactive_file"]
+	if !ok {
+		cache = raw.MemoryStats.Stats["total_inactive_file"]
+	}
+	if cache < raw.MemoryStats.Usage {
+		stats.MemoryUsageBytes -= int64(cache)
+	}
+
+	for _, network := range raw.Networks {
+		stats.NetworkRxBytes += int64(network.RxBytes)
+		stats.NetworkTxBytes += int64(network.TxBytes)
+	}
+	for _, entry := range raw.BlkioStats.IoServiceBytesRecursive {
+		switch strings.ToLower(entry.Op) {
+		case "read":
+			stats.BlockReadBytes += int64(entry.Value)
+		case "write":
+			stats.BlockWriteBytes += int64(entry.Value)
+		}
+	}
+
+	return stats, nil
+}
+
+// ListContainers lists the containers with a label, including stopped ones
+// if all is set
+func (r *DockerRuntime) ListContainers(ctx context.Context, label string, all bool) ([]*ContainerInfo, error) {
+	containers, err := r.client.ContainerList(ctx, container.ListOptions{
+		All: all,
+		Filters: filters.NewArgs(filters.KeyValuePair{
+			Key:   "label",
+			Value: label,
+		}),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*ContainerInfo, 0, len(containers))
+	for _, c := range containers {
+		name := ""
+		if len(c.Names) > 0 {
+			name = c.Names[0]
+		}
+		result = append(result, &ContainerInfo{
+			ID:      c.ID,
+			Name:    name,
+			Status:  c.State,
+			Created: time.Unix(c.Created, 0).UTC().Format(time.RFC3339),
+			Image:   c.Image,
+			Labels:  c.Labels,
+		})
+	}
+
+	return result, nil
+}
+
+// CreateVolume creates a volume
+func (r *DockerRuntime) CreateVolume(ctx context.Context, name string) error {
+	_, err := r.client.VolumeCreate(ctx, volume.CreateOptions{Name: name})
+	return err
+}
+
+// RemoveVolume removes a volume
+func (r *DockerRuntime) RemoveVolume(ctx context.Context, name string, force bool) error {
+	return wrapNotFound(r.client.VolumeRemove(ctx, name, force))
+}
+
+// ListVolumes lists the volumes whose name contains nameFilter
+func (r *DockerRuntime) ListVolumes(ctx context.Context, nameFilter string) ([]*VolumeInfo, error) {
+	opts := volume.ListOptions{}
+	if nameFilter != "" {
+		opts.Filters = filters.NewArgs(filters.KeyValuePair{
+			Key:   "name",
+			Value: nameFilter,
+		})
+	}
+
+	volumes, err := r.client.VolumeList(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*VolumeInfo, 0, len(volumes.Volumes))
+	for _, v := range volumes.Volumes {
+		info := &VolumeInfo{
+			Name:      v.Name,
+			CreatedAt: v.CreatedAt,
+		}
+		if v.UsageData != nil && v.UsageData.Size > 0 {
+			info.Size = v.UsageData.Size
+		}
+		result = append(result, info)
+	}
+
+	return result, nil
+}
+
+// Info returns the Docker version and the resources of its machine
+func (r *DockerRuntime) Info(ctx context.Context) (*RuntimeInfo, error) {
+	info, err := r.client.Info(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RuntimeInfo{
+		Name:        "docker",
+		Version:     info.ServerVersion,
+		CPUs:        info.NCPU,
+		MemoryBytes: info.MemTotal,
+	}, nil
+}
+
+// Close closes the Docker client
+func (r *DockerRuntime) Close() error {
+	return r.client.Close()
+}
+
+// wrapNotFound turns Docker's not found errors into ErrNotFound
+func wrapNotFound(err error) error {
+	if err != nil && client.IsErrNotFound(err) {
+		return fmt.Errorf("%w: %v", ErrNotFound, err)
+	}
+	return err
+}
@@ -0,0 +1,617 @@
+// Package dockertest provides an in-memory container runtime for testing
+// code that manages node containers and volumes without a Docker daemon.
+package dockertest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/game-server/controller/internal/docker"
+)
+
+// firstHostPort is the first host port handed out for published ports,
+// like Docker's ephemeral range
+const firstHostPort = 32768
+
+// Runtime is a fake docker.ContainerRuntime that keeps images, containers
+// and volumes in memory. Containers do not run anything: they are running
+// from start until stop, unless their image has an exit code set, in which
+// case they exit right after starting.
+type Runtime struct {
+	images     map[string]string // reference or ID to image ID
+	containers map[string]*fakeContainer
+	volumes    map[string]*docker.VolumeInfo
+	exitCodes  map[string]int64
+	failures   map[string]error
+	nextID     int
+	nextImage  int
+	nextPort   int
+	mu         sync.Mutex
+}
+
+type fakeContainer struct {
+	info docker.ContainerInfo
+	spec docker.ContainerSpec
+	// exited is closed when the container stops, and replaced when it
+	// starts again
+	exited   chan struct{}
+	exitCode int64
+	logs     []logLine
+	stats    docker.ContainerStats
+}
+
+type logLine struct {
+	time time.Time
+	text string
+}
+
+var _ docker.ContainerRuntime = (*Runtime)(nil)
+
+// NewRuntime creates an empty fake runtime. images are present from the
+// start; others must be pulled before a container can use them.
+func NewRuntime(images ...string) *Runtime {
+	r := &Runtime{
+		images:     make(map[string]string),
+		containers: make(map[string]*fakeContainer),
+		volumes:    make(map[string]*docker.VolumeInfo),
+		exitCodes:  make(map[string]int64),
+		failures:   make(map[string]error),
+		nextPort:   firstHostPort,
+	}
+	for _, image := range images {
+		r.addImage(image)
+	}
+	return r
+}
+
+// SetExitCode makes containers from image exit with code right after they
+// start, like helper containers running a short script
+func (r *Runtime) SetExitCode(image string, code int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.exitCodes[image] = code
+}
+
+// Fail makes every call of a runtime method, for example "CreateContainer",
+// return err. A nil err clears the failure.
+func (r *Runtime) Fail(method string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err == nil {
+		delete(r.failures, method)
+		return
+	}
+	r.failures[method] = err
+}
+
+// Containers returns all containers, sorted by name
+func (r *Runtime) Containers() []*docker.ContainerInfo {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := make([]*docker.ContainerInfo, 0, len(r.containers))
+	for _, c := range r.containers {
+		result = append(result, c.snapshot())
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// Spec returns the spec a container was created from
+func (r *Runtime) Spec(id string) (*docker.ContainerSpec, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, exists := r.containers[id]
+	if !exists {
+		return nil, false
+	}
+	spec := c.spec
+	return &spec, true
+}
+
+// WriteLog appends a line to a container's logs, as if the container wrote
+// it to stdout
+func (r *Runtime) WriteLog(id, line string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, err := r.container(id)
+	if err != nil {
+		return err
+	}
+	c.logs = append(c.logs, logLine{time: time.Now().UTC(), text: line})
+	return nil
+}
+
+// SetStats sets the resource usage ContainerStats reports for a container
+func (r *Runtime) SetStats(id string, stats docker.ContainerStats) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, err := r.container(id)
+	if err != nil {
+		return err
+	}
+	c.stats = stats
+	return nil
+}
+
+// Volumes returns the names of all volumes, sorted
+func (r *Runtime) Volumes() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	names := make([]string, 0, len(r.volumes))
+	for name := range r.volumes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ImageExists reports whether an image has been pulled
+func (r *Runtime) ImageExists(ctx context.Context, image string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.failures["ImageExists"]; err != nil {
+		return false, err
+	}
+	_, exists := r.images[image]
+	return exists, nil
+}
+
+// PullImage marks an image as present. Every pull gives the reference a new
+// image ID, as if its tag had moved; images that were pulled before keep
+// their IDs.
+func (r *Runtime) PullImage(ctx context.Context, image string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.failures["PullImage"]; err != nil {
+		return err
+	}
+	if strings.HasPrefix(image, "sha256:") {
+		if _, exists := r.images[image]; !exists {
+			return fmt.Errorf("%w: image %s", docker.ErrNotFound, image)
+		}
+		return nil
+	}
+	r.addImage(image)
+	return nil
+}
+
+// addImage gives an image reference a new ID. The runtime's mutex must be
+// held, or the runtime not yet shared.
+func (r *Runtime) addImage(image string) {
+	r.nextImage++
+	id := fmt.Sprintf("sha256:%064x", r.nextImage)
+	r.images[image] = id
+	r.images[id] = id
+}
+
+// CreateContainer creates a stopped container. Named volumes in the binds
+// are created if they do not exist, as Docker does.
+func (r *Runtime) CreateContainer(ctx context.Context, spec *docker.ContainerSpec) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.failures["CreateContainer"]; err != nil {
+		return "", err
+	}
+	imageID, exists := r.images[spec.Image]
+	if !exists {
+		return "", fmt.Errorf("no such image: %s", spec.Image)
+	}
+	name := "/" + spec.Name
+	if spec.Name != "" {
+		for _, c := range r.containers {
+			if c.info.Name == name {
+				return "", fmt.Errorf("conflict: container name %s is already in use", name)
+			}
+		}
+	}
+
+	r.nextID++
+	id := fmt.Sprintf("%064x", r.nextID)
+	if spec.Name == "" {
+		name = fmt.Sprintf("/fake-%d", r.nextID)
+	}
+
+	for _, bind := range spec.Binds {
+		volume := strings.SplitN(bind, ":", 2)[0]
+		if _, exists := r.volumes[volume]; !exists {
+			r.volumes[volume] = newVolume(volume)
+		}
+	}
+
+	ports := make(map[string]int, len(spec.PublishedPorts))
+	for _, port := range spec.PublishedPorts {
+		ports[port] = r.nextPort
+		r.nextPort++
+	}
+
+	labels := make(map[string]string, len(spec.Labels))
+	for k, v := range spec.Labels {
+		labels[k] = v
+	}
+
+	exited := make(chan struct{})
+	close(exited)
+	r.containers[id] = &fakeContainer{
+		info: docker.ContainerInfo{
+			ID:        id,
+			Name:      name,
+			Status:    "created",
+			IPAddress: fmt.Sprintf("172.17.%d.%d", r.nextID/254, r.nextID%254+1),
+			Created:   time.Now().UTC().Format(time.RFC3339),
+			Image:     spec.Image,
+			ImageID:   imageID,
+			Labels:    labels,
+			Ports:     ports,
+		},
+		spec:   *spec,
+		exited: exited,
+	}
+
+	return id, nil
+}
+
+// StartContainer starts a container. Starting a running container does
+// nothing.
+func (r *Runtime) StartContainer(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.failures["StartContainer"]; err != nil {
+		return err
+	}
+	c, err := r.container(id)
+	if err != nil {
+		return err
+	}
+	if c.info.Status == "running" {
+		return nil
+	}
+
+	c.info.Status = "running"
+	c.exited = make(chan struct{})
+	if code, exits := r.exitCodes[c.spec.Image]; exits {
+		c.stop(code)
+	}
+	return nil
+}
+
+// StopContainer stops a container
+func (r *Runtime) StopContainer(ctx context.Context, id string, timeout int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.failures["StopContainer"]; err != nil {
+		return err
+	}
+	c, err := r.container(id)
+	if err != nil {
+		return err
+	}
+	if c.info.Status == "running" {
+		c.stop(0)
+	}
+	return nil
+}
+
+// RestartContainer stops and starts a container
+func (r *Runtime) RestartContainer(ctx context.Context, id string, timeout int) error {
+	r.mu.Lock()
+	if err := r.failures["RestartContainer"]; err != nil {
+		r.mu.Unlock()
+		return err
+	}
+	c, err := r.container(id)
+	if err == nil && c.info.Status == "running" {
+		c.stop(0)
+	}
+	r.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+	return r.StartContainer(ctx, id)
+}
+
+// RemoveContainer removes a container. A running container is only removed
+// with force.
+func (r *Runtime) RemoveContainer(ctx context.Context, id string, force bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.failures["RemoveContainer"]; err != nil {
+		return err
+	}
+	c, err := r.container(id)
+	if err != nil {
+		return err
+	}
+	if c.info.Status == "running" {
+		if !force {
+			return fmt.Errorf("conflict: container %s is running, stop it or use force", id)
+		}
+		c.stop(137)
+	}
+
+	delete(r.containers, id)
+	return nil
+}
+
+// RenameContainer renames a container, failing if the name is in use
+func (r *Runtime) RenameContainer(ctx context.Context, id string, name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.failures["RenameContainer"]; err != nil {
+		return err
+	}
+	c, err := r.container(id)
+	if err != nil {
+		return err
+	}
+	for _, other := range r.containers {
+		if other != c && other.info.Name == "/"+name {
+			return fmt.Errorf("conflict: container name /%s is already in use", name)
+		}
+	}
+
+	c.info.Name = "/" + name
+	c.spec.Name = name
+	return nil
+}
+
+// WaitContainer waits until a container is not running and returns its exit
+// code
+func (r *Runtime) WaitContainer(ctx context.Context, id string) (int64, error) {
+	r.mu.Lock()
+	if err := r.failures["WaitContainer"]; err != nil {
+		r.mu.Unlock()
+		return 0, err
+	}
+	c, err := r.container(id)
+	if err != nil {
+		r.mu.Unlock()
+		return 0, err
+	}
+	exited := c.exited
+	r.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	case <-exited:
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return c.exitCode, nil
+}
+
+// InspectContainer returns information about a container
+func (r *Runtime) InspectContainer(ctx context.Context, id string) (*docker.ContainerInfo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.failures["InspectContainer"]; err != nil {
+		return nil, err
+	}
+	c, err := r.container(id)
+	if err != nil {
+		return nil, err
+	}
+	return c.snapshot(), nil
+}
+
+// ContainerLogs returns the lines written with WriteLog. Follow is ignored:
+// the logs end with the last line written so far.
+func (r *Runtime) ContainerLogs(ctx context.Context, id string, opts docker.LogOptions) (io.ReadCloser, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.failures["ContainerLogs"]; err != nil {
+		return nil, err
+	}
+	c, err := r.container(id)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := c.logs
+	if !opts.Since.IsZero() {
+		for len(lines) > 0 && !lines[0].time.After(opts.Since) {
+			lines = lines[1:]
+		}
+	}
+	if opts.Tail >= 0 && opts.Tail < len(lines) {
+		lines = lines[len(lines)-opts.Tail:]
+	}
+
+	var b strings.Builder
+	for _, line := range lines {
+		if opts.Timestamps {
+			b.WriteString(line.time.Format(time.RFC3339Nano) + " ")
+		}
+		b.WriteString(line.text + "\n")
+	}
+	return io.NopCloser(strings.NewReader(b.String())), nil
+}
+
+// ContainerStats returns the stats set with SetStats, zero by default. A
+// container that is not running has no stats.
+func (r *Runtime) ContainerStats(ctx context.Context, id string) (*docker.ContainerStats, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.failures["ContainerStats"]; err != nil {
+		return nil, err
+	}
+	c, err := r.container(id)
+	if err != nil {
+		return nil, err
+	}
+	if c.info.Status != "running" {
+		return nil, fmt.Errorf("container %s is not running", id)
+	}
+	stats := c.stats
+	return &stats, nil
+}
+
+// ListContainers lists the containers with a label ("key=value" or "key"),
+// including stopped ones if all is set
+func (r *Runtime) ListContainers(ctx context.Context, label string, all bool) ([]*docker.ContainerInfo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.failures["ListContainers"]; err != nil {
+		return nil, err
+	}
+
+	key, value, hasValue := strings.Cut(label, "=")
+	var result []*docker.ContainerInfo
+	for _, c := range r.containers {
+		if !all && c.info.Status != "running" {
+			continue
+		}
+		v, exists := c.info.Labels[key]
+		if !exists || (hasValue && v != value) {
+			continue
+		}
+		result = append(result, c.snapshot())
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result, nil
+}
+
+// CreateVolume creates a volume. Creating an existing volume does nothing.
+func (r *Runtime) CreateVolume(ctx context.Context, name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.failures["CreateVolume"]; err != nil {
+		return err
+	}
+	if _, exists := r.volumes[name]; !exists {
+		r.volumes[name] = newVolume(name)
+	}
+	return nil
+}
+
+// RemoveVolume removes a volume that no container uses
+func (r *Runtime) RemoveVolume(ctx context.Context, name string, force bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.failures["RemoveVolume"]; err != nil {
+		return err
+	}
+	if _, exists := r.volumes[name]; !exists {
+		return fmt.Errorf("%w: volume %s", docker.ErrNotFound, name)
+	}
+	for id, c := range r.containers {
+		for _, bind := range c.spec.Binds {
+			if strings.SplitN(bind, ":", 2)[0] == name {
+				return fmt.Errorf("volume %s is in use by container %s", name, id)
+			}
+		}
+	}
+
+	delete(r.volumes, name)
+	return nil
+}
+
+// ListVolumes lists the volumes whose name contains nameFilter
+func (r *Runtime) ListVolumes(ctx context.Context, nameFilter string) ([]*docker.VolumeInfo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.failures["ListVolumes"]; err != nil {
+		return nil, err
+	}
+
+	var result []*docker.VolumeInfo
+	for name, v := range r.volumes {
+		if strings.Contains(name, nameFilter) {
+			volume := *v
+			result = append(result, &volume)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result, nil
+}
+
+// Info returns a fixed description of a 4-CPU, 8 GiB machine
+func (r *Runtime) Info(ctx context.Context) (*docker.RuntimeInfo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.failures["Info"]; err != nil {
+		return nil, err
+	}
+	return &docker.RuntimeInfo{
+		Name:        "fake",
+		Version:     "0.0.0",
+		CPUs:        4,
+		MemoryBytes: 8 << 30,
+	}, nil
+}
+
+// Close does nothing
+func (r *Runtime) Close() error {
+	return nil
+}
+
+// container looks up a container by ID or name. r.mu must be held.
+func (r *Runtime) container(id string) (*fakeContainer, error) {
+	if c, exists := r.containers[id]; exists {
+		return c, nil
+	}
+	for _, c := range r.containers {
+		if c.info.Name == "/"+id {
+			return c, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: container %s", docker.ErrNotFound, id)
+}
+
+// stop marks a running container as exited. The runtime's mutex must be
+// held.
+func (c *fakeContainer) stop(code int64) {
+	c.info.Status = "exited"
+	c.exitCode = code
+	close(c.exited)
+}
+
+// snapshot copies a container's info so callers cannot change it
+func (c *fakeContainer) snapshot() *docker.ContainerInfo {
+	info := c.info
+	info.Labels = make(map[string]string, len(c.info.Labels))
+	for k, v := range c.info.Labels {
+		info.Labels[k] = v
+	}
+	info.Ports = make(map[string]int, len(c.info.Ports))
+	for k, v := range c.info.Ports {
+		info.Ports[k] = v
+	}
+	return &info
+}
+
+func newVolume(name string) *docker.VolumeInfo {
+	return &docker.VolumeInfo{
+		Name:      name,
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+}
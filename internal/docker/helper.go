@@ -0,0 +1,210 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"go.uber.org/zap"
+)
+
+// TemplatesVolumeName is the volume holding the world archives of server
+// templates, as <template ID>.tar.gz
+const TemplatesVolumeName = "game-server-templates"
+
+// serverIDPattern matches the IDs used as server and template file names, so
+// they are safe to use in helper shell commands
+var serverIDPattern = regexp.MustCompile(`^[A-Za-z0-9-]+$`)
+
+// worldNamePattern matches world directory names safe to use in helper shell
+// commands
+var worldNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-][A-Za-z0-9_.-]*$`)
+
+// CopyServerData copies a server's data directory from one node's servers
+// volume to another's, replacing any existing copy on the target. The data
+// of a server lives in <servers volume>/<server ID>.
+func (cm *ContainerManager) CopyServerData(ctx context.Context, helperImage, serverID, fromNodeID, toNodeID string) error {
+	if !serverIDPattern.MatchString(serverID) {
+		return fmt.Errorf("invalid server ID: %s", serverID)
+	}
+
+	from := cm.volumeMgr.GetNodeVolumeNames(fromNodeID)[0]
+	to := cm.volumeMgr.GetNodeVolumeNames(toNodeID)[0]
+
+	binds := []string{
+		fmt.Sprintf("%s:/from:ro", from),
+		fmt.Sprintf("%s:/to", to),
+	}
+	script := fmt.Sprintf("test -d /from/%[1]s && rm -rf /to/%[1]s && cp -a /from/%[1]s /to/%[1]s", serverID)
+
+	if err := cm.runHelper(ctx, helperImage, binds, script); err != nil {
+		return fmt.Errorf("failed to copy server data: %w", err)
+	}
+
+	cm.logger.Info("Server data copied",
+		zap.String("server_id", serverID),
+		zap.String("from_volume", from),
+		zap.String("to_volume", to))
+
+	return nil
+}
+
+// RemoveServerData deletes a server's data directory from a node's servers
+// volume
+func (cm *ContainerManager) RemoveServerData(ctx context.Context, helperImage, nodeID, serverID string) error {
+	if !serverIDPattern.MatchString(serverID) {
+		return fmt.Errorf("invalid server ID: %s", serverID)
+	}
+
+	volumeName := cm.volumeMgr.GetNodeVolumeNames(nodeID)[0]
+	binds := []string{fmt.Sprintf("%s:/data", volumeName)}
+
+	if err := cm.runHelper(ctx, helperImage, binds, "rm -rf /data/"+serverID); err != nil {
+		return fmt.Errorf("failed to remove server data: %w", err)
+	}
+
+	return nil
+}
+
+// CopyServerWorld copies the world directory of one server into another
+// server's data directory, which may be on a different node
+func (cm *ContainerManager) CopyServerWorld(ctx context.Context, helperImage, world, fromNodeID, fromServerID, toNodeID, toServerID string) error {
+	if err := validateHelperNames(world, fromServerID, toServerID); err != nil {
+		return err
+	}
+
+	from := cm.volumeMgr.GetNodeVolumeNames(fromNodeID)[0]
+	to := cm.volumeMgr.GetNodeVolumeNames(toNodeID)[0]
+
+	binds := []string{
+		fmt.Sprintf("%s:/from:ro", from),
+		fmt.Sprintf("%s:/to", to),
+	}
+	src, dst := "/from", "/to"
+	// Servers on the same node share a volume
+	if from == to {
+		binds = []string{fmt.Sprintf("%s:/data", from)}
+		src, dst = "/data", "/data"
+	}
+	script := fmt.Sprintf("test -d %[1]s/%[2]s/%[3]s && mkdir -p %[4]s/%[5]s && rm -rf %[4]s/%[5]s/%[3]s && cp -a %[1]s/%[2]s/%[3]s %[4]s/%[5]s/%[3]s",
+		src, fromServerID, world, dst, toServerID)
+
+	if err := cm.runHelper(ctx, helperImage, binds, script); err != nil {
+		return fmt.Errorf("failed to copy world: %w", err)
+	}
+
+	cm.logger.Info("Server world copied",
+		zap.String("world", world),
+		zap.String("from_server", fromServerID),
+		zap.String("to_server", toServerID))
+
+	return nil
+}
+
+// SaveTemplateWorld archives the world directory of a server for a template
+func (cm *ContainerManager) SaveTemplateWorld(ctx context.Context, helperImage, world, nodeID, serverID, templateID string) error {
+	if err := validateHelperNames(world, serverID, templateID); err != nil {
+		return err
+	}
+
+	binds := []string{
+		fmt.Sprintf("%s:/from:ro", cm.volumeMgr.GetNodeVolumeNames(nodeID)[0]),
+		fmt.Sprintf("%s:/templates", TemplatesVolumeName),
+	}
+	script := fmt.Sprintf("tar czf /templates/%[3]s.tar.gz -C /from/%[1]s %[2]s", serverID, world, templateID)
+
+	if err := cm.runHelper(ctx, helperImage, binds, script); err != nil {
+		return fmt.Errorf("failed to save template world: %w", err)
+	}
+
+	return nil
+}
+
+// RestoreTemplateWorld extracts a template's world archive into a server's
+// data directory
+func (cm *ContainerManager) RestoreTemplateWorld(ctx context.Context, helperImage, templateID, nodeID, serverID string) error {
+	if err := validateHelperNames("", serverID, templateID); err != nil {
+		return err
+	}
+
+	binds := []string{
+		fmt.Sprintf("%s:/templates:ro", TemplatesVolumeName),
+		fmt.Sprintf("%s:/to", cm.volumeMgr.GetNodeVolumeNames(nodeID)[0]),
+	}
+	script := fmt.Sprintf("mkdir -p /to/%[1]s && tar xzf /templates/%[2]s.tar.gz -C /to/%[1]s", serverID, templateID)
+
+	if err := cm.runHelper(ctx, helperImage, binds, script); err != nil {
+		return fmt.Errorf("failed to restore template world: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveTemplateWorld deletes a template's world archive
+func (cm *ContainerManager) RemoveTemplateWorld(ctx context.Context, helperImage, templateID string) error {
+	if err := validateHelperNames("", templateID); err != nil {
+		return err
+	}
+
+	binds := []string{fmt.Sprintf("%s:/templates", TemplatesVolumeName)}
+	if err := cm.runHelper(ctx, helperImage, binds, fmt.Sprintf("rm -f /templates/%s.tar.gz", templateID)); err != nil {
+		return fmt.Errorf("failed to remove template world: %w", err)
+	}
+
+	return nil
+}
+
+// validateHelperNames checks a world name (if set) and IDs before they are
+// used in a helper shell command
+func validateHelperNames(world string, ids ...string) error {
+	if world != "" && !worldNamePattern.MatchString(world) {
+		return fmt.Errorf("invalid world name: %s", world)
+	}
+	for _, id := range ids {
+		if !serverIDPattern.MatchString(id) {
+			return fmt.Errorf("invalid ID: %s", id)
+		}
+	}
+	return nil
+}
+
+// runHelper runs a shell script in a short-lived container with the given
+// volume binds and waits for it to exit successfully
+func (cm *ContainerManager) runHelper(ctx context.Context, helperImage string, binds []string, script string) error {
+	exists, err := cm.runtime.ImageExists(ctx, helperImage)
+	if err != nil {
+		return fmt.Errorf("failed to inspect image %s: %w", helperImage, err)
+	}
+	if !exists {
+		if err := cm.runtime.PullImage(ctx, helperImage); err != nil {
+			return fmt.Errorf("failed to pull image %s: %w", helperImage, err)
+		}
+	}
+
+	containerID, err := cm.runtime.CreateContainer(ctx, &ContainerSpec{
+		Image: helperImage,
+		Cmd:   []string{"sh", "-c", script},
+		Labels: map[string]string{
+			"game-server.helper": "true",
+		},
+		Binds: binds,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create helper container: %w", err)
+	}
+	defer cm.runtime.RemoveContainer(context.Background(), containerID, true)
+
+	if err := cm.runtime.StartContainer(ctx, containerID); err != nil {
+		return fmt.Errorf("failed to start helper container: %w", err)
+	}
+
+	exitCode, err := cm.runtime.WaitContainer(ctx, containerID)
+	if err != nil {
+		return fmt.Errorf("failed to wait for helper container: %w", err)
+	}
+	if exitCode != 0 {
+		return fmt.Errorf("helper container exited with code %d", exitCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+package docker
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// ErrUnknownHost is returned for a container host that is not connected
+var ErrUnknownHost = errors.New("unknown container host")
+
+// Host is a connected container host with the managers of the node
+// containers and volumes on it
+type Host struct {
+	ID      string
+	Runtime ContainerRuntime
+	// ControllerAddress is the gRPC address node agents on the host reach
+	// the controller at, the controller's own if empty
+	ControllerAddress string
+	NetworkName       string // Docker network node containers join, none if empty
+
+	// Set by Add
+	Containers *ContainerManager
+	Volumes    *VolumeManager
+}
+
+// Hosts holds the connected container hosts, keyed by host ID
+type Hosts struct {
+	hosts  map[string]*Host
+	mu     sync.RWMutex
+	logger *zap.Logger
+}
+
+// NewHosts creates an empty set of hosts
+func NewHosts(logger *zap.Logger) *Hosts {
+	return &Hosts{
+		hosts:  make(map[string]*Host),
+		logger: logger,
+	}
+}
+
+// Add connects a host, creating the managers of its node containers and
+// volumes. A host with the same ID is replaced, and its runtime closed unless
+// the new host reuses it.
+func (h *Hosts) Add(host *Host) {
+	logger := h.logger.With(zap.String("host_id", host.ID))
+	host.Volumes = NewVolumeManager(host.Runtime, logger)
+	host.Containers = NewContainerManager(host.Runtime, host.Volumes, logger)
+
+	h.mu.Lock()
+	previous := h.hosts[host.ID]
+	h.hosts[host.ID] = host
+	h.mu.Unlock()
+
+	if previous != nil && previous.Runtime != host.Runtime {
+		previous.Runtime.Close()
+	}
+}
+
+// Remove disconnects a host and closes its runtime
+func (h *Hosts) Remove(id string) {
+	h.mu.Lock()
+	host, exists := h.hosts[id]
+	delete(h.hosts, id)
+	h.mu.Unlock()
+
+	if exists {
+		host.Runtime.Close()
+	}
+}
+
+// Get returns a connected host
+func (h *Hosts) Get(id string) (*Host, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	host, exists := h.hosts[id]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownHost, id)
+	}
+	return host, nil
+}
+
+// List returns the connected hosts, sorted by ID
+func (h *Hosts) List() []*Host {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	result := make([]*Host, 0, len(h.hosts))
+	for _, host := range h.hosts {
+		result = append(result, host)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result
+}
+
+// Close disconnects all hosts
+func (h *Hosts) Close() {
+	h.mu.Lock()
+	hosts := h.hosts
+	h.hosts = make(map[string]*Host)
+	h.mu.Unlock()
+
+	for _, host := range hosts {
+		host.Runtime.Close()
+	}
+}
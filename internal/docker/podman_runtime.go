@@ -0,0 +1,581 @@
+package docker
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// podmanAPIBase is the libpod REST API prefix. Podman 4.0 and later serve
+// this version.
+const podmanAPIBase = "http://podman/v4.0.0/libpod"
+
+// PodmanRuntime is the ContainerRuntime of a Podman service, talking to the
+// libpod REST API on its socket. It works with rootless Podman.
+type PodmanRuntime struct {
+	client *http.Client
+	socket string
+}
+
+// NewPodmanRuntime connects to the Podman socket at socketPath. An empty
+// path uses the rootless socket under XDG_RUNTIME_DIR if that is set, and
+// the system socket otherwise.
+func NewPodmanRuntime(socketPath string) (*PodmanRuntime, error) {
+	socketPath = strings.TrimPrefix(socketPath, "unix://")
+	if socketPath == "" {
+		socketPath = "/run/podman/podman.sock"
+		if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+			socketPath = filepath.Join(dir, "podman", "podman.sock")
+		}
+	}
+	if _, err := os.Stat(socketPath); err != nil {
+		return nil, fmt.Errorf("failed to find Podman socket: %w", err)
+	}
+
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socketPath)
+		},
+	}
+
+	return &PodmanRuntime{
+		client: &http.Client{Transport: transport},
+		socket: socketPath,
+	}, nil
+}
+
+// podmanSpec is the subset of libpod's SpecGenerator the controller sets
+type podmanSpec struct {
+	Name           string                `json:"name,omitempty"`
+	Image          string                `json:"image"`
+	Command        []string              `json:"command,omitempty"`
+	Env            map[string]string     `json:"env,omitempty"`
+	Labels         map[string]string     `json:"labels,omitempty"`
+	Volumes        []podmanNamedVolume   `json:"volumes,omitempty"`
+	PortMappings   []podmanPortMapping   `json:"portmappings,omitempty"`
+	Networks       map[string]struct{}   `json:"networks,omitempty"`
+	RestartPolicy  string                `json:"restart_policy,omitempty"`
+	ResourceLimits *podmanResourceLimits `json:"resource_limits,omitempty"`
+}
+
+type podmanNamedVolume struct {
+	Name    string   `json:"Name"`
+	Dest    string   `json:"Dest"`
+	Options []string `json:"Options,omitempty"`
+}
+
+type podmanPortMapping struct {
+	ContainerPort uint16 `json:"container_port"`
+	HostPort      uint16 `json:"host_port"` // zero picks a random port
+	Protocol      string `json:"protocol"`
+}
+
+type podmanResourceLimits struct {
+	CPU    *podmanCPU    `json:"cpu,omitempty"`
+	Memory *podmanMemory `json:"memory,omitempty"`
+}
+
+type podmanCPU struct {
+	Quota  int64  `json:"quota"`
+	Period uint64 `json:"period"`
+}
+
+type podmanMemory struct {
+	Limit int64 `json:"limit"`
+}
+
+// cpuPeriod is the CFS period used to express CPU limits as a quota
+const cpuPeriod = 100000
+
+// ImageExists reports whether an image is present locally
+func (r *PodmanRuntime) ImageExists(ctx context.Context, image string) (bool, error) {
+	resp, err := r.do(ctx, http.MethodGet, "/images/"+url.PathEscape(image)+"/exists", nil, nil)
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNoContent, http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	}
+	return false, fmt.Errorf("unexpected status %d", resp.StatusCode)
+}
+
+// PullImage pulls an image and waits for the pull to complete
+func (r *PodmanRuntime) PullImage(ctx context.Context, image string) error {
+	query := url.Values{"reference": {image}, "quiet": {"true"}}
+	resp, err := r.do(ctx, http.MethodPost, "/images/pull", query, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := checkPodmanResponse(resp); err != nil {
+		return err
+	}
+
+	// The pull reports progress and errors as a stream of JSON objects
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		var report struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(scanner.Bytes(), &report) == nil && report.Error != "" {
+			return fmt.Errorf("%s", report.Error)
+		}
+	}
+	return scanner.Err()
+}
+
+// CreateContainer creates a container and returns its ID
+func (r *PodmanRuntime) CreateContainer(ctx context.Context, spec *ContainerSpec) (string, error) {
+	ps := podmanSpec{
+		Name:          spec.Name,
+		Image:         spec.Image,
+		Command:       spec.Cmd,
+		Labels:        spec.Labels,
+		RestartPolicy: spec.RestartPolicy,
+	}
+
+	if len(spec.Env) > 0 {
+		ps.Env = make(map[string]string, len(spec.Env))
+		for _, kv := range spec.Env {
+			k, v, _ := strings.Cut(kv, "=")
+			ps.Env[k] = v
+		}
+	}
+
+	for _, bind := range spec.Binds {
+		parts := strings.Split(bind, ":")
+		if len(parts) < 2 {
+			return "", fmt.Errorf("invalid bind: %s", bind)
+		}
+		volume := podmanNamedVolume{Name: parts[0], Dest: parts[1]}
+		if len(parts) > 2 {
+			volume.Options = strings.Split(parts[2], ",")
+		}
+		ps.Volumes = append(ps.Volumes, volume)
+	}
+
+	for _, p := range spec.PublishedPorts {
+		port, proto, _ := strings.Cut(p, "/")
+		n, err := strconv.ParseUint(port, 10, 16)
+		if err != nil {
+			return "", fmt.Errorf("invalid port: %s", p)
+		}
+		if proto == "" {
+			proto = "tcp"
+		}
+		ps.PortMappings = append(ps.PortMappings, podmanPortMapping{ContainerPort: uint16(n), Protocol: proto})
+	}
+
+	if spec.Network != "" {
+		ps.Networks = map[string]struct{}{spec.Network: {}}
+	}
+
+	if spec.NanoCPUs > 0 || spec.MemoryBytes > 0 {
+		ps.ResourceLimits = &podmanResourceLimits{}
+		if spec.NanoCPUs > 0 {
+			ps.ResourceLimits.CPU = &podmanCPU{
+				Quota:  spec.NanoCPUs * cpuPeriod / 1e9,
+				Period: cpuPeriod,
+			}
+		}
+		if spec.MemoryBytes > 0 {
+			ps.ResourceLimits.Memory = &podmanMemory{Limit: spec.MemoryBytes}
+		}
+	}
+
+	var created struct {
+		ID string `json:"Id"`
+	}
+	if err := r.call(ctx, http.MethodPost, "/containers/create", nil, ps, &created); err != nil {
+		return "", err
+	}
+	return created.ID, nil
+}
+
+// StartContainer starts a container
+func (r *PodmanRuntime) StartContainer(ctx context.Context, id string) error {
+	return r.call(ctx, http.MethodPost, "/containers/"+url.PathEscape(id)+"/start", nil, nil, nil)
+}
+
+// StopContainer stops a container, killing it after timeout seconds
+func (r *PodmanRuntime) StopContainer(ctx context.Context, id string, timeout int) error {
+	query := url.Values{"timeout": {strconv.Itoa(timeout)}}
+	return r.call(ctx, http.MethodPost, "/containers/"+url.PathEscape(id)+"/stop", query, nil, nil)
+}
+
+// RestartContainer restarts a container, killing it after timeout seconds
+func (r *PodmanRuntime) RestartContainer(ctx context.Context, id string, timeout int) error {
+	query := url.Values{"t": {strconv.Itoa(timeout)}}
+	return r.call(ctx, http.MethodPost, "/containers/"+url.PathEscape(id)+"/restart", query, nil, nil)
+}
+
+// RemoveContainer removes a container, stopping it first if force is set
+func (r *PodmanRuntime) RemoveContainer(ctx context.Context, id string, force bool) error {
+	query := url.Values{"force": {strconv.FormatBool(force)}}
+	return r.call(ctx, http.MethodDelete, "/containers/"+url.PathEscape(id), query, nil, nil)
+}
+
+// RenameContainer gives a container a new name
+func (r *PodmanRuntime) RenameContainer(ctx context.Context, id string, name string) error {
+	query := url.Values{"name": {name}}
+	return r.call(ctx, http.MethodPost, "/containers/"+url.PathEscape(id)+"/rename", query, nil, nil)
+}
+
+// WaitContainer waits until a container is not running and returns its
+// exit code
+func (r *PodmanRuntime) WaitContainer(ctx context.Context, id string) (int64, error) {
+	query := url.Values{"condition": {"stopped", "exited"}}
+	var exitCode int64
+	if err := r.call(ctx, http.MethodPost, "/containers/"+url.PathEscape(id)+"/wait", query, nil, &exitCode); err != nil {
+		return 0, err
+	}
+	return exitCode, nil
+}
+
+// InspectContainer returns information about a container
+func (r *PodmanRuntime) InspectContainer(ctx context.Context, id string) (*ContainerInfo, error) {
+	var info struct {
+		ID      string    `json:"Id"`
+		Name    string    `json:"Name"`
+		Created time.Time `json:"Created"`
+		State   struct {
+			Status string `json:"Status"`
+		} `json:"State"`
+		Image     string `json:"Image"`
+		ImageName string `json:"ImageName"`
+		Config    struct {
+			Labels map[string]string `json:"Labels"`
+		} `json:"Config"`
+		NetworkSettings struct {
+			IPAddress string `json:"IPAddress"`
+			Ports     map[string][]struct {
+				HostPort string `json:"HostPort"`
+			} `json:"Ports"`
+			Networks map[string]struct {
+				IPAddress string `json:"IPAddress"`
+			} `json:"Networks"`
+		} `json:"NetworkSettings"`
+	}
+	if err := r.call(ctx, http.MethodGet, "/containers/"+url.PathEscape(id)+"/json", nil, nil, &info); err != nil {
+		return nil, err
+	}
+
+	ports := make(map[string]int)
+	for port, bindings := range info.NetworkSettings.Ports {
+		if len(bindings) > 0 {
+			ports[port], _ = strconv.Atoi(bindings[0].HostPort)
+		}
+	}
+
+	// Containers on a user network only report their address there
+	ipAddress := info.NetworkSettings.IPAddress
+	for _, n := range info.NetworkSettings.Networks {
+		if ipAddress == "" {
+			ipAddress = n.IPAddress
+		}
+	}
+
+	return &ContainerInfo{
+		ID:        info.ID,
+		Name:      "/" + strings.TrimPrefix(info.Name, "/"),
+		Status:    info.State.Status,
+		Ports:     ports,
+		IPAddress: ipAddress,
+		Created:   info.Created.UTC().Format(time.RFC3339),
+		Image:     info.ImageName,
+		ImageID:   "sha256:" + strings.TrimPrefix(info.Image, "sha256:"),
+		Labels:    info.Config.Labels,
+	}, nil
+}
+
+// ContainerLogs returns a container's stdout and stderr as plain text
+func (r *PodmanRuntime) ContainerLogs(ctx context.Context, id string, opts LogOptions) (io.ReadCloser, error) {
+	query := url.Values{
+		"stdout":     {"true"},
+		"stderr":     {"true"},
+		"timestamps": {strconv.FormatBool(opts.Timestamps)},
+		"follow":     {strconv.FormatBool(opts.Follow)},
+		"tail":       {opts.tail()},
+	}
+	if since := opts.since(); since != "" {
+		query.Set("since", since)
+	}
+
+	resp, err := r.do(ctx, http.MethodGet, "/containers/"+url.PathEscape(id)+"/logs", query, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkPodmanResponse(resp); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	return demuxLogs(resp.Body), nil
+}
+
+// ContainerStats samples the resource usage of a running container
+func (r *PodmanRuntime) ContainerStats(ctx context.Context, id string) (*ContainerStats, error) {
+	query := url.Values{
+		"containers": {id},
+		"stream":     {"false"},
+	}
+	var report struct {
+		Error *struct {
+			Message string `json:"message"`
+		} `json:"Error"`
+		Stats []struct {
+			CPU         float64 `json:"CPU"`
+			MemUsage    int64   `json:"MemUsage"`
+			MemLimit    int64   `json:"MemLimit"`
+			NetInput    int64   `json:"NetInput"`
+			NetOutput   int64   `json:"NetOutput"`
+			BlockInput  int64   `json:"BlockInput"`
+			BlockOutput int64   `json:"BlockOutput"`
+		} `json:"Stats"`
+	}
+	if err := r.call(ctx, http.MethodGet, "/containers/stats", query, nil, &report); err != nil {
+		return nil, err
+	}
+	if report.Error != nil {
+		return nil, fmt.Errorf("podman: %s", report.Error.Message)
+	}
+	if len(report.Stats) == 0 {
+		return nil, fmt.Errorf("%w: stats of container %s", ErrNotFound, id)
+	}
+
+	s := report.Stats[0]
+	return &ContainerStats{
+		CPUPercent:       s.CPU,
+		MemoryUsageBytes: s.MemUsage,
+		MemoryLimitBytes: s.MemLimit,
+		NetworkRxBytes:   s.NetInput,
+		NetworkTxBytes:   s.NetOutput,
+		BlockReadBytes:   s.BlockInput,
+		BlockWriteBytes:  s.BlockOutput,
+	}, nil
+}
+
+// ListContainers lists the containers with a label, including stopped ones
+// if all is set
+func (r *PodmanRuntime) ListContainers(ctx context.Context, label string, all bool) ([]*ContainerInfo, error) {
+	filters, err := json.Marshal(map[string][]string{"label": {label}})
+	if err != nil {
+		return nil, err
+	}
+	query := url.Values{
+		"all":     {strconv.FormatBool(all)},
+		"filters": {string(filters)},
+	}
+
+	var containers []struct {
+		ID      string            `json:"Id"`
+		Names   []string          `json:"Names"`
+		State   string            `json:"State"`
+		Image   string            `json:"Image"`
+		Labels  map[string]string `json:"Labels"`
+		Created time.Time         `json:"Created"`
+	}
+	if err := r.call(ctx, http.MethodGet, "/containers/json", query, nil, &containers); err != nil {
+		return nil, err
+	}
+
+	result := make([]*ContainerInfo, 0, len(containers))
+	for _, c := range containers {
+		name := ""
+		if len(c.Names) > 0 {
+			// Docker reports names with a leading slash, Podman without
+			name = "/" + strings.TrimPrefix(c.Names[0], "/")
+		}
+		result = append(result, &ContainerInfo{
+			ID:      c.ID,
+			Name:    name,
+			Status:  c.State,
+			Created: c.Created.UTC().Format(time.RFC3339),
+			Image:   c.Image,
+			Labels:  c.Labels,
+		})
+	}
+
+	return result, nil
+}
+
+// CreateVolume creates a volume. Creating an existing volume does nothing,
+// as with Docker.
+func (r *PodmanRuntime) CreateVolume(ctx context.Context, name string) error {
+	exists, err := r.volumeExists(ctx, name)
+	if err != nil || exists {
+		return err
+	}
+
+	body := map[string]string{"Name": name}
+	return r.call(ctx, http.MethodPost, "/volumes/create", nil, body, nil)
+}
+
+// RemoveVolume removes a volume
+func (r *PodmanRuntime) RemoveVolume(ctx context.Context, name string, force bool) error {
+	query := url.Values{"force": {strconv.FormatBool(force)}}
+	return r.call(ctx, http.MethodDelete, "/volumes/"+url.PathEscape(name), query, nil, nil)
+}
+
+// ListVolumes lists the volumes whose name contains nameFilter
+func (r *PodmanRuntime) ListVolumes(ctx context.Context, nameFilter string) ([]*VolumeInfo, error) {
+	var volumes []struct {
+		Name      string    `json:"Name"`
+		CreatedAt time.Time `json:"CreatedAt"`
+	}
+	if err := r.call(ctx, http.MethodGet, "/volumes/json", nil, nil, &volumes); err != nil {
+		return nil, err
+	}
+
+	// Podman's name filter matches whole names, so substrings are matched
+	// here
+	result := make([]*VolumeInfo, 0, len(volumes))
+	for _, v := range volumes {
+		if !strings.Contains(v.Name, nameFilter) {
+			continue
+		}
+		result = append(result, &VolumeInfo{
+			Name:      v.Name,
+			CreatedAt: v.CreatedAt.UTC().Format(time.RFC3339),
+		})
+	}
+
+	return result, nil
+}
+
+// Info returns the Podman version and the resources of its machine
+func (r *PodmanRuntime) Info(ctx context.Context) (*RuntimeInfo, error) {
+	var info struct {
+		Host struct {
+			CPUs     int   `json:"cpus"`
+			MemTotal int64 `json:"memTotal"`
+		} `json:"host"`
+		Version struct {
+			Version string `json:"Version"`
+		} `json:"version"`
+	}
+	if err := r.call(ctx, http.MethodGet, "/info", nil, nil, &info); err != nil {
+		return nil, err
+	}
+
+	return &RuntimeInfo{
+		Name:        "podman",
+		Version:     info.Version.Version,
+		CPUs:        info.Host.CPUs,
+		MemoryBytes: info.Host.MemTotal,
+	}, nil
+}
+
+// Close closes idle connections to the socket
+func (r *PodmanRuntime) Close() error {
+	r.client.CloseIdleConnections()
+	return nil
+}
+
+// volumeExists reports whether a volume exists
+func (r *PodmanRuntime) volumeExists(ctx context.Context, name string) (bool, error) {
+	resp, err := r.do(ctx, http.MethodGet, "/volumes/"+url.PathEscape(name)+"/exists", nil, nil)
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNoContent, http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	}
+	return false, fmt.Errorf("unexpected status %d", resp.StatusCode)
+}
+
+// call sends a request to the libpod API and decodes the JSON response into
+// out, if set
+func (r *PodmanRuntime) call(ctx context.Context, method, path string, query url.Values, body, out interface{}) error {
+	resp, err := r.do(ctx, method, path, query, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := checkPodmanResponse(resp); err != nil {
+		return err
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode Podman response: %w", err)
+	}
+	return nil
+}
+
+// do sends a request to the libpod API
+func (r *PodmanRuntime) do(ctx context.Context, method, path string, query url.Values, body interface{}) (*http.Response, error) {
+	target := podmanAPIBase + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach Podman at %s: %w", r.socket, err)
+	}
+	return resp, nil
+}
+
+// checkPodmanResponse turns an error response into an error. Starting a
+// running container or stopping a stopped one (304) is not an error.
+func checkPodmanResponse(resp *http.Response) error {
+	if resp.StatusCode < 300 || resp.StatusCode == http.StatusNotModified {
+		return nil
+	}
+
+	var apiErr struct {
+		Cause   string `json:"cause"`
+		Message string `json:"message"`
+	}
+	data, _ := io.ReadAll(resp.Body)
+	message := strings.TrimSpace(string(data))
+	if json.Unmarshal(data, &apiErr) == nil && apiErr.Message != "" {
+		message = apiErr.Message
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%w: %s", ErrNotFound, message)
+	}
+	return fmt.Errorf("podman: %s (status %d)", message, resp.StatusCode)
+}
@@ -0,0 +1,161 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+// ErrNotFound is returned by a runtime for a container or volume that does
+// not exist
+var ErrNotFound = errors.New("not found")
+
+// ContainerRuntime is the container engine that node containers, their
+// volumes and helper containers run on. ContainerManager and VolumeManager
+// only talk to the engine through it.
+type ContainerRuntime interface {
+	// ImageExists reports whether an image is present locally
+	ImageExists(ctx context.Context, image string) (bool, error)
+	// PullImage pulls an image and waits for the pull to complete
+	PullImage(ctx context.Context, image string) error
+
+	// CreateContainer creates a container and returns its ID
+	CreateContainer(ctx context.Context, spec *ContainerSpec) (string, error)
+	StartContainer(ctx context.Context, id string) error
+	// StopContainer stops a container, killing it after timeout seconds
+	StopContainer(ctx context.Context, id string, timeout int) error
+	RestartContainer(ctx context.Context, id string, timeout int) error
+	// RemoveContainer removes a container, stopping it first if force is set
+	RemoveContainer(ctx context.Context, id string, force bool) error
+	RenameContainer(ctx context.Context, id string, name string) error
+	// WaitContainer waits until a container is not running and returns its
+	// exit code
+	WaitContainer(ctx context.Context, id string) (int64, error)
+	InspectContainer(ctx context.Context, id string) (*ContainerInfo, error)
+	// ContainerLogs returns a container's stdout and stderr as plain text,
+	// interleaved in the order they were written
+	ContainerLogs(ctx context.Context, id string, opts LogOptions) (io.ReadCloser, error)
+	// ContainerStats samples the resource usage of a running container
+	ContainerStats(ctx context.Context, id string) (*ContainerStats, error)
+	// ListContainers lists the containers with a label ("key=value"),
+	// including stopped ones if all is set
+	ListContainers(ctx context.Context, label string, all bool) ([]*ContainerInfo, error)
+
+	CreateVolume(ctx context.Context, name string) error
+	RemoveVolume(ctx context.Context, name string, force bool) error
+	// ListVolumes lists the volumes whose name contains nameFilter
+	ListVolumes(ctx context.Context, nameFilter string) ([]*VolumeInfo, error)
+
+	// Info returns the engine's version and the resources of its machine
+	Info(ctx context.Context) (*RuntimeInfo, error)
+	Close() error
+}
+
+// NewRuntime connects to a container runtime by name: "docker" (the default)
+// or "podman", which uses the libpod API on podmanSocket
+func NewRuntime(name, podmanSocket string) (ContainerRuntime, error) {
+	switch name {
+	case "", "docker":
+		return NewDockerRuntime()
+	case "podman":
+		return NewPodmanRuntime(podmanSocket)
+	}
+	return nil, fmt.Errorf("unknown container runtime %q, must be docker or podman", name)
+}
+
+// ContainerSpec describes a container to create
+type ContainerSpec struct {
+	Name   string
+	Image  string
+	Cmd    []string
+	Env    []string
+	Labels map[string]string
+	Binds  []string // "volume:/path[:ro]"
+	// PublishedPorts are container ports ("50051/tcp") published on a random
+	// host port
+	PublishedPorts []string
+	Network        string
+	RestartPolicy  string // e.g. "unless-stopped"
+	NanoCPUs       int64
+	MemoryBytes    int64
+}
+
+// LogOptions selects the log lines of a container to read
+type LogOptions struct {
+	Tail       int       // last lines only, all if negative
+	Since      time.Time // lines written after this time only, all if zero
+	Follow     bool      // keep streaming new lines until ctx is done or the container stops
+	Timestamps bool      // prefix each line with its RFC 3339 timestamp
+}
+
+// tail returns the tail option as the Docker and libpod APIs take it
+func (o LogOptions) tail() string {
+	if o.Tail < 0 {
+		return "all"
+	}
+	return strconv.Itoa(o.Tail)
+}
+
+// since returns the since option as the Docker and libpod APIs take it, a
+// Unix timestamp
+func (o LogOptions) since() string {
+	if o.Since.IsZero() {
+		return ""
+	}
+	return strconv.FormatInt(o.Since.Unix(), 10)
+}
+
+// demuxLogs turns a multiplexed log stream, with stdout and stderr frames as
+// Docker and Podman send them for containers without a TTY, into plain text
+func demuxLogs(stream io.ReadCloser) io.ReadCloser {
+	reader, writer := io.Pipe()
+	go func() {
+		_, err := stdcopy.StdCopy(writer, writer, stream)
+		writer.CloseWithError(err)
+	}()
+	return &logReader{PipeReader: reader, stream: stream}
+}
+
+// logReader reads demultiplexed logs. Closing it closes the stream too,
+// which ends the copy.
+type logReader struct {
+	*io.PipeReader
+	stream io.Closer
+}
+
+func (r *logReader) Close() error {
+	r.PipeReader.Close()
+	return r.stream.Close()
+}
+
+// ContainerStats is a sample of a container's resource usage. Network and
+// block I/O are totals since the container started.
+type ContainerStats struct {
+	CPUPercent       float64 // percent of one CPU, 200 for two busy CPUs
+	MemoryUsageBytes int64   // excluding the page cache
+	MemoryLimitBytes int64   // the container's limit, or the machine's memory
+	NetworkRxBytes   int64
+	NetworkTxBytes   int64
+	BlockReadBytes   int64
+	BlockWriteBytes  int64
+}
+
+// RuntimeInfo describes a container engine and the machine it runs on
+type RuntimeInfo struct {
+	Name        string // "docker" or "podman"
+	Version     string
+	CPUs        int
+	MemoryBytes int64
+}
+
+// VolumeInfo holds information about a volume
+type VolumeInfo struct {
+	Name      string
+	CreatedAt string
+	Size      int64 // bytes, zero if the runtime does not report usage
+}
@@ -2,19 +2,17 @@ package docker
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 
-	"github.com/docker/docker/api/types/filters"
-	"github.com/docker/docker/api/types/volume"
-	"github.com/docker/docker/client"
 	"go.uber.org/zap"
 )
 
-// VolumeManager manages Docker volumes for game server nodes
+// VolumeManager manages the volumes of game server nodes
 type VolumeManager struct {
-	client *client.Client
-	logger *zap.Logger
+	runtime ContainerRuntime
+	logger  *zap.Logger
 }
 
 // VolumeConfig holds configuration for volume naming
@@ -23,16 +21,24 @@ type VolumeConfig struct {
 }
 
 // NewVolumeManager creates a new volume manager
-func NewVolumeManager(logger *zap.Logger) (*VolumeManager, error) {
-	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
-	if err != nil {
-		return nil, fmt.Errorf("failed to create Docker client: %w", err)
+func NewVolumeManager(runtime ContainerRuntime, logger *zap.Logger) *VolumeManager {
+	return &VolumeManager{
+		runtime: runtime,
+		logger:  logger,
 	}
+}
 
-	return &VolumeManager{
-		client: cli,
-		logger: logger,
-	}, nil
+// nodeVolumePrefix starts the name of every node volume
+const nodeVolumePrefix = "game-server-node-"
+
+// nodeVolumeSuffixes end the names of the volumes of a node
+var nodeVolumeSuffixes = []string{"-servers", "-backups", "-logs"}
+
+// NodeVolume is a node volume found in Docker
+type NodeVolume struct {
+	Name      string
+	NodeID    string // parsed from the name
+	CreatedAt string
 }
 
 // GetNodeVolumeNames returns the expected volume names for a node
@@ -68,19 +74,14 @@ func (vm *VolumeManager) DeleteNodeVolumes(ctx context.Context, nodeID string) e
 // deleteVolume deletes a single volume by name
 func (vm *VolumeManager) deleteVolume(ctx context.Context, volumeName string) error {
 	// Check if volume exists
-	volumes, err := vm.client.VolumeList(ctx, volume.ListOptions{
-		Filters: filters.NewArgs(filters.KeyValuePair{
-			Key:   "name",
-			Value: volumeName,
-		}),
-	})
+	volumes, err := vm.runtime.ListVolumes(ctx, volumeName)
 	if err != nil {
 		return fmt.Errorf("failed to list volumes: %w", err)
 	}
 
 	// Find the exact volume
 	var found bool
-	for _, v := range volumes.Volumes {
+	for _, v := range volumes {
 		if v.Name == volumeName {
 			found = true
 			break
@@ -93,7 +94,10 @@ func (vm *VolumeManager) deleteVolume(ctx context.Context, volumeName string) er
 	}
 
 	// Remove the volume
-	if err := vm.client.VolumeRemove(ctx, volumeName, true); err != nil {
+	if err := vm.runtime.RemoveVolume(ctx, volumeName, true); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil
+		}
 		return fmt.Errorf("failed to remove volume %s: %w", volumeName, err)
 	}
 
@@ -102,16 +106,16 @@ func (vm *VolumeManager) deleteVolume(ctx context.Context, volumeName string) er
 }
 
 // ListNodeVolumes lists all volumes for a node
-func (vm *VolumeManager) ListNodeVolumes(ctx context.Context, nodeID string) ([]*volume.Volume, error) {
+func (vm *VolumeManager) ListNodeVolumes(ctx context.Context, nodeID string) ([]*VolumeInfo, error) {
 	volumeNames := vm.GetNodeVolumeNames(nodeID)
 	
-	volumes, err := vm.client.VolumeList(ctx, volume.ListOptions{})
+	volumes, err := vm.runtime.ListVolumes(ctx, "")
 	if err != nil {
 		return nil, fmt.Errorf("failed to list volumes: %w", err)
 	}
 
-	var nodeVolumes []*volume.Volume
-	for _, v := range volumes.Volumes {
+	var nodeVolumes []*VolumeInfo
+	for _, v := range volumes {
 		for _, name := range volumeNames {
 			if v.Name == name {
 				nodeVolumes = append(nodeVolumes, v)
@@ -123,6 +127,42 @@ func (vm *VolumeManager) ListNodeVolumes(ctx context.Context, nodeID string) ([]
 	return nodeVolumes, nil
 }
 
+// ListAllNodeVolumes lists every volume named like a node volume, whether or
+// not its node still exists
+func (vm *VolumeManager) ListAllNodeVolumes(ctx context.Context) ([]*NodeVolume, error) {
+	volumes, err := vm.runtime.ListVolumes(ctx, nodeVolumePrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list volumes: %w", err)
+	}
+
+	var result []*NodeVolume
+	for _, v := range volumes {
+		// The name filter matches substrings
+		if !strings.HasPrefix(v.Name, nodeVolumePrefix) {
+			continue
+		}
+		nodeID := strings.TrimPrefix(v.Name, nodeVolumePrefix)
+		for _, suffix := range nodeVolumeSuffixes {
+			if strings.HasSuffix(nodeID, suffix) {
+				nodeID = strings.TrimSuffix(nodeID, suffix)
+				break
+			}
+		}
+		result = append(result, &NodeVolume{
+			Name:      v.Name,
+			NodeID:    nodeID,
+			CreatedAt: v.CreatedAt,
+		})
+	}
+
+	return result, nil
+}
+
+// RemoveVolume deletes a volume by name
+func (vm *VolumeManager) RemoveVolume(ctx context.Context, volumeName string) error {
+	return vm.deleteVolume(ctx, volumeName)
+}
+
 // GetVolumeUsage returns the total size of volumes for a node
 func (vm *VolumeManager) GetVolumeUsage(ctx context.Context, nodeID string) (int64, error) {
 	volumes, err := vm.ListNodeVolumes(ctx, nodeID)
@@ -132,15 +172,8 @@ func (vm *VolumeManager) GetVolumeUsage(ctx context.Context, nodeID string) (int
 
 	var totalSize int64
 	for _, v := range volumes {
-		if v.UsageData != nil {
-			totalSize += v.UsageData.Size
-		}
+		totalSize += v.Size
 	}
 
 	return totalSize, nil
 }
-
-// Close closes the Docker client
-func (vm *VolumeManager) Close() error {
-	return vm.client.Close()
-}
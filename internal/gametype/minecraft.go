@@ -0,0 +1,160 @@
+package gametype
+
+// Minecraft returns the built-in Minecraft Java Edition definition.
+// max-players, level-name and online-mode are not part of the settings
+// schema because they are first-class server fields.
+func Minecraft() *Definition {
+	settings := NewObjectSchema()
+	settings.Properties = map[string]*Property{
+		"difficulty": {
+			Type:    PropertyTypeString,
+			Title:   "Difficulty",
+			Default: "easy",
+			Enum:    []string{"peaceful", "easy", "normal", "hard"},
+		},
+		"gamemode": {
+			Type:    PropertyTypeString,
+			Title:   "Game mode",
+			Default: "survival",
+			Enum:    []string{"survival", "creative", "adventure", "spectator"},
+		},
+		"hardcore": {
+			Type:    PropertyTypeBoolean,
+			Title:   "Hardcore",
+			Default: false,
+		},
+		"pvp": {
+			Type:    PropertyTypeBoolean,
+			Title:   "PvP",
+			Default: true,
+		},
+		"motd": {
+			Type:      PropertyTypeString,
+			Title:     "Message of the day",
+			Default:   "A Minecraft Server",
+			MaxLength: intPtr(59),
+		},
+		"level-seed": {
+			Type:  PropertyTypeString,
+			Title: "World seed",
+		},
+		"level-type": {
+			Type:    PropertyTypeString,
+			Title:   "World type",
+			Default: "minecraft:normal",
+			Enum:    []string{"minecraft:normal", "minecraft:flat", "minecraft:large_biomes", "minecraft:amplified", "minecraft:single_biome_surface"},
+		},
+		"view-distance": {
+			Type:    PropertyTypeInteger,
+			Title:   "View distance",
+			Default: 10,
+			Minimum: floatPtr(3),
+			Maximum: floatPtr(32),
+		},
+		"simulation-distance": {
+			Type:    PropertyTypeInteger,
+			Title:   "Simulation distance",
+			Default: 10,
+			Minimum: floatPtr(3),
+			Maximum: floatPtr(32),
+		},
+		"spawn-protection": {
+			Type:    PropertyTypeInteger,
+			Title:   "Spawn protection radius",
+			Default: 16,
+			Minimum: floatPtr(0),
+		},
+		"max-world-size": {
+			Type:    PropertyTypeInteger,
+			Title:   "Max world size",
+			Default: 29999984,
+			Minimum: floatPtr(1),
+			Maximum: floatPtr(29999984),
+		},
+		"allow-flight": {
+			Type:    PropertyTypeBoolean,
+			Title:   "Allow flight",
+			Default: false,
+		},
+		"allow-nether": {
+			Type:    PropertyTypeBoolean,
+			Title:   "Allow Nether",
+			Default: true,
+		},
+		"spawn-monsters": {
+			Type:    PropertyTypeBoolean,
+			Title:   "Spawn monsters",
+			Default: true,
+		},
+		"spawn-animals": {
+			Type:    PropertyTypeBoolean,
+			Title:   "Spawn animals",
+			Default: true,
+		},
+		"spawn-npcs": {
+			Type:    PropertyTypeBoolean,
+			Title:   "Spawn villagers",
+			Default: true,
+		},
+		"white-list": {
+			Type:    PropertyTypeBoolean,
+			Title:   "Whitelist",
+			Default: false,
+		},
+		"enforce-whitelist": {
+			Type:    PropertyTypeBoolean,
+			Title:   "Enforce whitelist",
+			Default: false,
+		},
+		"enable-command-block": {
+			Type:    PropertyTypeBoolean,
+			Title:   "Enable command blocks",
+			Default: false,
+		},
+		"max-tick-time": {
+			Type:    PropertyTypeInteger,
+			Title:   "Max tick time (ms)",
+			Default: 60000,
+			Minimum: floatPtr(-1),
+		},
+	}
+
+	envVars := NewObjectSchema()
+	envVars.Properties = map[string]*Property{
+		"TYPE": {
+			Type:        PropertyTypeString,
+			Title:       "Server type",
+			Description: "Server software to install",
+			Default:     "VANILLA",
+			Enum:        []string{"VANILLA", "PAPER", "SPIGOT", "FORGE", "FABRIC"},
+		},
+		"MEMORY": {
+			Type:        PropertyTypeString,
+			Title:       "JVM heap size",
+			Description: "Heap size passed to -Xmx, e.g. 2G or 1024M",
+			Default:     "1G",
+			Pattern:     `^[0-9]+[MG]$`,
+		},
+		"JVM_OPTS": {
+			Type:  PropertyTypeString,
+			Title: "Extra JVM options",
+		},
+		"TZ": {
+			Type:  PropertyTypeString,
+			Title: "Time zone",
+		},
+	}
+
+	return &Definition{
+		ID:               "minecraft",
+		Name:             "Minecraft",
+		Description:      "Minecraft Java Edition server",
+		Source:           SourceBuiltin,
+		DefaultPort:      25565,
+		StopCommand:      "stop",
+		SaveCommand:      "save-all flush",
+		BroadcastCommand: "say {message}",
+		Settings:         settings,
+		EnvVars:          envVars,
+	}
+}
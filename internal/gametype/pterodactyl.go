@@ -0,0 +1,328 @@
+package gametype
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Egg represents a Pterodactyl egg export (PTDL_v1 or PTDL_v2)
+type Egg struct {
+	Meta struct {
+		Version string `json:"version"`
+	} `json:"meta"`
+	Name         string            `json:"name"`
+	Author       string            `json:"author"`
+	Description  string            `json:"description"`
+	DockerImages map[string]string `json:"docker_images"`
+	Images       []string          `json:"images"`
+	Image        string            `json:"image"`
+	Startup      string            `json:"startup"`
+	Config       struct {
+		Stop string `json:"stop"`
+	} `json:"config"`
+	Scripts struct {
+		Installation struct {
+			Script     string `json:"script"`
+			Container  string `json:"container"`
+			Entrypoint string `json:"entrypoint"`
+		} `json:"installation"`
+	} `json:"scripts"`
+	Variables []EggVariable `json:"variables"`
+}
+
+// EggVariable represents a single egg variable
+type EggVariable struct {
+	Name         string `json:"name"`
+	Description  string `json:"description"`
+	EnvVariable  string `json:"env_variable"`
+	DefaultValue string `json:"default_value"`
+	UserViewable bool   `json:"user_viewable"`
+	UserEditable bool   `json:"user_editable"`
+	Rules        string `json:"rules"`
+}
+
+// ParseEgg parses Pterodactyl egg JSON
+func ParseEgg(data []byte) (*Egg, error) {
+	var egg Egg
+	if err := json.Unmarshal(data, &egg); err != nil {
+		return nil, fmt.Errorf("failed to parse egg: %w", err)
+	}
+
+	if egg.Name == "" {
+		return nil, fmt.Errorf("egg has no name")
+	}
+	if egg.Startup == "" {
+		return nil, fmt.Errorf("egg has no startup command")
+	}
+
+	return &egg, nil
+}
+
+// ImportEgg converts Pterodactyl egg JSON into a game type definition.
+// If id is empty it is derived from the egg name.
+func ImportEgg(data []byte, id string) (*Definition, error) {
+	egg, err := ParseEgg(data)
+	if err != nil {
+		return nil, err
+	}
+	return egg.ToDefinition(id)
+}
+
+// ToDefinition converts the egg into a game type definition
+func (e *Egg) ToDefinition(id string) (*Definition, error) {
+	if id == "" {
+		id = Slugify(e.Name)
+	}
+	if id == "" {
+		return nil, fmt.Errorf("cannot derive game type id from egg name %q", e.Name)
+	}
+
+	// PTDL_v2 exports a name->image map, PTDL_v1 a list or a single image
+	images := make(map[string]string)
+	for name, image := range e.DockerImages {
+		images[name] = image
+	}
+	for _, image := range e.Images {
+		images[image] = image
+	}
+	if e.Image != "" {
+		images[e.Image] = e.Image
+	}
+
+	envVars := NewObjectSchema()
+	for _, v := range e.Variables {
+		if v.EnvVariable == "" {
+			continue
+		}
+
+		prop, required, err := propertyFromRules(v.Rules)
+		if err != nil {
+			return nil, fmt.Errorf("variable %s: %w", v.EnvVariable, err)
+		}
+		prop.Title = v.Name
+		prop.Description = v.Description
+		prop.ReadOnly = !v.UserEditable
+		if v.DefaultValue != "" || prop.Nullable {
+			prop.Default = v.DefaultValue
+		}
+
+		envVars.Properties[v.EnvVariable] = prop
+		if required {
+			envVars.Required = append(envVars.Required, v.EnvVariable)
+		}
+	}
+
+	def := &Definition{
+		ID:             id,
+		Name:           e.Name,
+		Description:    e.Description,
+		Source:         SourcePterodactyl,
+		DockerImages:   images,
+		StartupCommand: e.Startup,
+		StopCommand:    eggStopCommand(e.Config.Stop),
+		Settings:       NewObjectSchema(),
+		EnvVars:        envVars,
+	}
+
+	if e.Scripts.Installation.Script != "" {
+		def.InstallScript = &InstallScript{
+			Container:  e.Scripts.Installation.Container,
+			Entrypoint: e.Scripts.Installation.Entrypoint,
+			Script:     strings.ReplaceAll(e.Scripts.Installation.Script, "\r\n", "\n"),
+		}
+	}
+
+	return def, nil
+}
+
+// propertyFromRules converts Laravel validation rules such as
+// "required|string|max:20" into a schema property
+func propertyFromRules(rules string) (*Property, bool, error) {
+	prop := &Property{Type: PropertyTypeString}
+	required := false
+
+	var minValue, maxValue *float64
+	for _, rule := range splitRules(rules) {
+		name, arg, _ := strings.Cut(rule, ":")
+		switch strings.TrimSpace(name) {
+		case "required":
+			required = true
+		case "nullable", "sometimes":
+			prop.Nullable = true
+		case "string":
+			prop.Type = PropertyTypeString
+		case "integer", "int":
+			prop.Type = PropertyTypeInteger
+		case "numeric":
+			prop.Type = PropertyTypeNumber
+		case "boolean", "bool":
+			// Laravel accepts 0/1 as well as true/false
+			prop.Type = PropertyTypeString
+			prop.Enum = []string{"0", "1", "true", "false"}
+		case "in":
+			prop.Enum = strings.Split(arg, ",")
+		case "min":
+			n, err := strconv.ParseFloat(arg, 64)
+			if err != nil {
+				return nil, false, fmt.Errorf("invalid min rule %q", rule)
+			}
+			minValue = &n
+		case "max":
+			n, err := strconv.ParseFloat(arg, 64)
+			if err != nil {
+				return nil, false, fmt.Errorf("invalid max rule %q", rule)
+			}
+			maxValue = &n
+		case "between":
+			lo, hi, ok := strings.Cut(arg, ",")
+			loN, errLo := strconv.ParseFloat(lo, 64)
+			hiN, errHi := strconv.ParseFloat(hi, 64)
+			if !ok || errLo != nil || errHi != nil {
+				return nil, false, fmt.Errorf("invalid between rule %q", rule)
+			}
+			minValue, maxValue = &loN, &hiN
+		case "size":
+			n, err := strconv.ParseFloat(arg, 64)
+			if err != nil {
+				return nil, false, fmt.Errorf("invalid size rule %q", rule)
+			}
+			minValue, maxValue = &n, &n
+		case "regex":
+			pattern, err := convertRegex(arg)
+			if err != nil {
+				return nil, false, err
+			}
+			prop.Pattern = pattern
+		case "alpha":
+			prop.Pattern = `^[a-zA-Z]+$`
+		case "alpha_num":
+			prop.Pattern = `^[a-zA-Z0-9]+$`
+		case "alpha_dash":
+			prop.Pattern = `^[a-zA-Z0-9_-]+$`
+		case "url":
+			prop.Pattern = `^https?://`
+		}
+	}
+
+	// min/max mean length for strings and value for numbers
+	switch prop.Type {
+	case PropertyTypeInteger, PropertyTypeNumber:
+		prop.Minimum = minValue
+		prop.Maximum = maxValue
+	default:
+		if minValue != nil {
+			prop.MinLength = intPtr(int(*minValue))
+		}
+		if maxValue != nil {
+			prop.MaxLength = intPtr(int(*maxValue))
+		}
+	}
+
+	return prop, required, nil
+}
+
+// splitRules splits a rule string on "|" without breaking regex rules that
+// contain "|" themselves
+func splitRules(rules string) []string {
+	var result []string
+	for rules != "" {
+		if strings.HasPrefix(rules, "regex:") {
+			if end := regexRuleEnd(rules[len("regex:"):]); end >= 0 {
+				end += len("regex:")
+				result = append(result, rules[:end])
+				if end == len(rules) {
+					break
+				}
+				rules = rules[end+1:]
+				continue
+			}
+			result = append(result, rules)
+			break
+		}
+
+		rule, rest, found := strings.Cut(rules, "|")
+		if rule != "" {
+			result = append(result, rule)
+		}
+		if !found {
+			break
+		}
+		rules = rest
+	}
+	return result
+}
+
+// regexRuleEnd returns the length of the /pattern/flags literal at the start
+// of body, or -1 if it is not closed. The literal is delimited by its first
+// character; the closing delimiter is the first unescaped one followed by
+// optional flags and then "|" or the end of the rules.
+func regexRuleEnd(body string) int {
+	if len(body) < 2 {
+		return -1
+	}
+
+	delim := body[0]
+	for i := 1; i < len(body); i++ {
+		switch body[i] {
+		case '\\':
+			i++
+		case delim:
+			end := i + 1
+			for end < len(body) && isRegexFlag(body[end]) {
+				end++
+			}
+			if end == len(body) || body[end] == '|' {
+				return end
+			}
+		}
+	}
+	return -1
+}
+
+// isRegexFlag reports whether c is a PCRE pattern modifier
+func isRegexFlag(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// convertRegex converts a PCRE-style /pattern/flags literal into a Go pattern
+func convertRegex(literal string) (string, error) {
+	if len(literal) < 2 {
+		return "", fmt.Errorf("invalid regex rule %q", literal)
+	}
+
+	delim := literal[0]
+	end := strings.LastIndexByte(literal, delim)
+	if end <= 0 {
+		return "", fmt.Errorf("invalid regex rule %q", literal)
+	}
+
+	pattern := literal[1:end]
+	flags := literal[end+1:]
+	if strings.Contains(flags, "i") {
+		pattern = "(?i)" + pattern
+	}
+
+	if _, err := regexp.Compile(pattern); err != nil {
+		return "", fmt.Errorf("regex %q is not supported: %w", literal, err)
+	}
+	return pattern, nil
+}
+
+// eggStopCommand converts an egg stop command. Eggs prefix signals with "^",
+// e.g. "^C", which the node handles by signalling the process.
+func eggStopCommand(stop string) string {
+	if strings.HasPrefix(stop, "^") {
+		return ""
+	}
+	return stop
+}
+
+var slugPattern = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Slugify converts a name into a game type ID
+func Slugify(name string) string {
+	return strings.Trim(slugPattern.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
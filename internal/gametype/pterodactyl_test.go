@@ -0,0 +1,108 @@
+package gametype_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/game-server/controller/internal/gametype"
+)
+
+// importVariable imports an egg with one variable, VALUE, using rules
+func importVariable(t *testing.T, rules string) (*gametype.Definition, error) {
+	t.Helper()
+
+	egg := map[string]interface{}{
+		"name":    "Test Egg",
+		"startup": "./start.sh",
+		"variables": []map[string]interface{}{{
+			"name":          "Value",
+			"env_variable":  "VALUE",
+			"user_editable": true,
+			"rules":         rules,
+		}},
+	}
+	data, err := json.Marshal(egg)
+	if err != nil {
+		t.Fatalf("marshal egg: %v", err)
+	}
+
+	return gametype.ImportEgg(data, "")
+}
+
+func TestImportEggRegexRules(t *testing.T) {
+	tests := []struct {
+		name      string
+		rules     string
+		pattern   string
+		maxLength int
+		required  bool
+	}{
+		{
+			name:    "single regex",
+			rules:   "regex:/^[a-z]+$/",
+			pattern: "^[a-z]+$",
+		},
+		{
+			name:      "regex containing a pipe",
+			rules:     "required|regex:/^(a|b)$/|max:20",
+			pattern:   "^(a|b)$",
+			maxLength: 20,
+			required:  true,
+		},
+		{
+			name:    "two regex rules",
+			rules:   "regex:/a/|regex:/b/",
+			pattern: "b",
+		},
+		{
+			name:      "regex followed by a rule with a slash",
+			rules:     "regex:/^[0-9]+$/|max:5|in:a/b,c/d",
+			pattern:   "^[0-9]+$",
+			maxLength: 5,
+		},
+		{
+			name:    "escaped delimiter",
+			rules:   `regex:/^a\/b$/|string`,
+			pattern: `^a\/b$`,
+		},
+		{
+			name:      "flags",
+			rules:     "regex:/^[a-z]+$/i|max:8",
+			pattern:   "(?i)^[a-z]+$",
+			maxLength: 8,
+		},
+		{
+			name:     "other delimiter",
+			rules:    "regex:#^[a-z/|]+$#|required",
+			pattern:  "^[a-z/|]+$",
+			required: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			def, err := importVariable(t, tt.rules)
+			if err != nil {
+				t.Fatalf("ImportEgg: %v", err)
+			}
+			prop := def.EnvVars.Properties["VALUE"]
+
+			if prop.Pattern != tt.pattern {
+				t.Errorf("pattern = %q, want %q", prop.Pattern, tt.pattern)
+			}
+			if tt.maxLength > 0 && (prop.MaxLength == nil || *prop.MaxLength != tt.maxLength) {
+				t.Errorf("maxLength = %v, want %d", prop.MaxLength, tt.maxLength)
+			}
+			required := len(def.EnvVars.Required) == 1 && def.EnvVars.Required[0] == "VALUE"
+			if required != tt.required {
+				t.Errorf("required = %v, want %v", required, tt.required)
+			}
+		})
+	}
+}
+
+func TestImportEggUnclosedRegex(t *testing.T) {
+	if _, err := importVariable(t, "regex:/^[a-z]+"); err == nil {
+		t.Fatal("ImportEgg accepted an unclosed regex rule")
+	}
+}
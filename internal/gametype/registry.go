@@ -0,0 +1,135 @@
+package gametype
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Source identifies where a game type definition came from
+type Source string
+
+const (
+	SourceBuiltin     Source = "builtin"
+	SourcePterodactyl Source = "pterodactyl"
+)
+
+// Definition describes a game type the controller can provision
+type Definition struct {
+	ID               string            `json:"id"`
+	Name             string            `json:"name"`
+	Description      string            `json:"description"`
+	Source           Source            `json:"source"`
+	DefaultPort      int               `json:"default_port"`
+	DockerImages     map[string]string `json:"docker_images,omitempty"`
+	StartupCommand   string            `json:"startup_command,omitempty"`
+	StopCommand      string            `json:"stop_command,omitempty"`
+	SaveCommand      string            `json:"save_command,omitempty"`      // flushes the world before a graceful stop
+	BroadcastCommand string            `json:"broadcast_command,omitempty"` // {message} is replaced with the text
+	InstallScript    *InstallScript    `json:"install_script,omitempty"`
+	Settings         *Schema           `json:"settings_schema"`
+	EnvVars          *Schema           `json:"env_vars_schema"`
+}
+
+// InstallScript describes how a game server is installed on a node
+type InstallScript struct {
+	Container  string `json:"container"`
+	Entrypoint string `json:"entrypoint"`
+	Script     string `json:"script"`
+}
+
+// Validate validates settings and env vars against the definition's schemas
+// and returns copies with defaults applied
+func (d *Definition) Validate(settings, envVars map[string]string) (map[string]string, map[string]string, error) {
+	validSettings, errs := d.Settings.Validate("settings", settings)
+	validEnvVars, envErrs := d.EnvVars.Validate("env_vars", envVars)
+	errs = append(errs, envErrs...)
+
+	if len(errs) > 0 {
+		return nil, nil, &ValidationError{GameType: d.ID, Errors: errs}
+	}
+
+	return validSettings, validEnvVars, nil
+}
+
+// Registry holds the game type definitions known to the controller
+type Registry struct {
+	definitions map[string]*Definition
+	mu          sync.RWMutex
+}
+
+// NewRegistry creates a registry populated with the built-in game types
+func NewRegistry() *Registry {
+	r := &Registry{
+		definitions: make(map[string]*Definition),
+	}
+	r.Register(Minecraft())
+	return r
+}
+
+// Register adds or replaces a game type definition
+func (r *Registry) Register(def *Definition) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.definitions[def.ID] = def
+}
+
+// Unregister removes a game type definition
+func (r *Registry) Unregister(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.definitions, id)
+}
+
+// IsBuiltin reports whether id refers to a built-in game type
+func (r *Registry) IsBuiltin(id string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	def, exists := r.definitions[id]
+	return exists && def.Source == SourceBuiltin
+}
+
+// Get retrieves a game type definition by ID
+func (r *Registry) Get(id string) (*Definition, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	def, exists := r.definitions[id]
+	if !exists {
+		return nil, fmt.Errorf("game type not found: %s", id)
+	}
+	return def, nil
+}
+
+// List returns all game type definitions sorted by ID
+func (r *Registry) List() []*Definition {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]*Definition, 0, len(r.definitions))
+	for _, def := range r.definitions {
+		result = append(result, def)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID < result[j].ID
+	})
+	return result
+}
+
+// Validate validates server settings and env vars for a game type
+func (r *Registry) Validate(gameType string, settings, envVars map[string]string) (map[string]string, map[string]string, error) {
+	def, err := r.Get(gameType)
+	if err != nil {
+		return nil, nil, &ValidationError{
+			GameType: gameType,
+			Errors: []FieldError{{
+				Field:   "game_type",
+				Message: "unsupported game type",
+			}},
+		}
+	}
+	return def.Validate(settings, envVars)
+}
@@ -0,0 +1,266 @@
+package gametype
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PropertyType represents the value type of a schema property
+type PropertyType string
+
+const (
+	PropertyTypeString  PropertyType = "string"
+	PropertyTypeInteger PropertyType = "integer"
+	PropertyTypeNumber  PropertyType = "number"
+	PropertyTypeBoolean PropertyType = "boolean"
+)
+
+// Schema is a JSON-Schema-style definition of the keys allowed in a
+// free-form string map such as Server.Settings or Server.EnvVars
+type Schema struct {
+	Type                 string               `json:"type"`
+	Properties           map[string]*Property `json:"properties"`
+	Required             []string             `json:"required,omitempty"`
+	AdditionalProperties bool                 `json:"additionalProperties"`
+}
+
+// Property describes a single key in a schema
+type Property struct {
+	Type        PropertyType `json:"type"`
+	Title       string       `json:"title,omitempty"`
+	Description string       `json:"description,omitempty"`
+	Default     interface{}  `json:"default,omitempty"`
+	Enum        []string     `json:"enum,omitempty"`
+	Minimum     *float64     `json:"minimum,omitempty"`
+	Maximum     *float64     `json:"maximum,omitempty"`
+	MinLength   *int         `json:"minLength,omitempty"`
+	MaxLength   *int         `json:"maxLength,omitempty"`
+	Pattern     string       `json:"pattern,omitempty"`
+	Nullable    bool         `json:"nullable,omitempty"`
+	ReadOnly    bool         `json:"readOnly,omitempty"`
+}
+
+// FieldError describes a validation failure for a single field
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError is returned when settings or env vars do not match the schema
+type ValidationError struct {
+	GameType string       `json:"game_type"`
+	Errors   []FieldError `json:"errors"`
+}
+
+// Error implements the error interface
+func (e *ValidationError) Error() string {
+	parts := make([]string, 0, len(e.Errors))
+	for _, fe := range e.Errors {
+		parts = append(parts, fmt.Sprintf("%s: %s", fe.Field, fe.Message))
+	}
+	return fmt.Sprintf("invalid configuration for game type %s: %s", e.GameType, strings.Join(parts, "; "))
+}
+
+// NewObjectSchema creates an empty object schema
+func NewObjectSchema() *Schema {
+	return &Schema{
+		Type:       "object",
+		Properties: make(map[string]*Property),
+	}
+}
+
+// Validate checks values against the schema and returns a copy with defaults
+// applied. Field names in the returned errors are prefixed with prefix.
+func (s *Schema) Validate(prefix string, values map[string]string) (map[string]string, []FieldError) {
+	result := make(map[string]string, len(values))
+	for k, v := range values {
+		result[k] = v
+	}
+
+	if s == nil {
+		return result, nil
+	}
+
+	var errs []FieldError
+
+	// Reject unknown keys so typos never reach the node
+	if !s.AdditionalProperties {
+		keys := make([]string, 0, len(values))
+		for key := range values {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			if _, ok := s.Properties[key]; !ok {
+				errs = append(errs, FieldError{
+					Field:   fieldName(prefix, key),
+					Message: "unknown key" + s.suggest(key),
+				})
+			}
+		}
+	}
+
+	for _, key := range s.Required {
+		if v, ok := values[key]; !ok || v == "" {
+			if prop, ok := s.Properties[key]; ok && prop.Default != nil {
+				continue
+			}
+			errs = append(errs, FieldError{
+				Field:   fieldName(prefix, key),
+				Message: "is required",
+			})
+		}
+	}
+
+	names := make([]string, 0, len(s.Properties))
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		prop := s.Properties[name]
+		value, ok := values[name]
+		if !ok {
+			if prop.Default != nil {
+				result[name] = fmt.Sprint(prop.Default)
+			}
+			continue
+		}
+		if prop.ReadOnly && prop.Default != nil && value != fmt.Sprint(prop.Default) {
+			errs = append(errs, FieldError{
+				Field:   fieldName(prefix, name),
+				Message: "is read-only",
+			})
+			continue
+		}
+		if msg := prop.check(value); msg != "" {
+			errs = append(errs, FieldError{
+				Field:   fieldName(prefix, name),
+				Message: msg,
+			})
+		}
+	}
+
+	return result, errs
+}
+
+// check validates a single value and returns an error message or ""
+func (p *Property) check(value string) string {
+	if value == "" && p.Nullable {
+		return ""
+	}
+
+	switch p.Type {
+	case PropertyTypeInteger:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return "must be an integer"
+		}
+		if msg := p.checkRange(float64(n)); msg != "" {
+			return msg
+		}
+	case PropertyTypeNumber:
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return "must be a number"
+		}
+		if msg := p.checkRange(n); msg != "" {
+			return msg
+		}
+	case PropertyTypeBoolean:
+		if value != "true" && value != "false" {
+			return "must be true or false"
+		}
+	case PropertyTypeString, "":
+		if p.MinLength != nil && len(value) < *p.MinLength {
+			return fmt.Sprintf("must be at least %d characters", *p.MinLength)
+		}
+		if p.MaxLength != nil && len(value) > *p.MaxLength {
+			return fmt.Sprintf("must be at most %d characters", *p.MaxLength)
+		}
+	}
+
+	if len(p.Enum) > 0 {
+		found := false
+		for _, allowed := range p.Enum {
+			if value == allowed {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Sprintf("must be one of: %s", strings.Join(p.Enum, ", "))
+		}
+	}
+
+	if p.Pattern != "" {
+		re, err := regexp.Compile(p.Pattern)
+		if err != nil {
+			return fmt.Sprintf("schema pattern is invalid: %v", err)
+		}
+		if !re.MatchString(value) {
+			return fmt.Sprintf("must match pattern %s", p.Pattern)
+		}
+	}
+
+	return ""
+}
+
+// checkRange validates numeric bounds
+func (p *Property) checkRange(n float64) string {
+	if p.Minimum != nil && n < *p.Minimum {
+		return fmt.Sprintf("must be >= %s", formatNumber(*p.Minimum))
+	}
+	if p.Maximum != nil && n > *p.Maximum {
+		return fmt.Sprintf("must be <= %s", formatNumber(*p.Maximum))
+	}
+	return ""
+}
+
+// suggest returns a hint for the closest known key, if any
+func (s *Schema) suggest(key string) string {
+	normalized := normalizeKey(key)
+	names := make([]string, 0, len(s.Properties))
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if normalizeKey(name) == normalized || strings.HasPrefix(normalizeKey(name), normalized) {
+			return fmt.Sprintf(" (did you mean %q?)", name)
+		}
+	}
+	return ""
+}
+
+// Helper functions
+
+func fieldName(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+func normalizeKey(key string) string {
+	replacer := strings.NewReplacer("-", "", "_", "", ".", "")
+	return strings.ToLower(replacer.Replace(key))
+}
+
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
+
+func floatPtr(f float64) *float64 {
+	return &f
+}
+
+func intPtr(i int) *int {
+	return &i
+}
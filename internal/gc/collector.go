@@ -0,0 +1,252 @@
+package gc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/game-server/controller/internal/core/models"
+	"github.com/game-server/controller/internal/core/repository"
+	"github.com/game-server/controller/internal/docker"
+	"github.com/game-server/controller/pkg/config"
+	"go.uber.org/zap"
+)
+
+// Collector finds node containers and volumes whose node is no longer in the
+// database, for example because removing them failed when the node was
+// deleted, and removes them once they have been orphaned for the grace
+// period. Every connected host is scanned; a node's resources on a host other
+// than its own are orphans too.
+type Collector struct {
+	nodeRepo NodeLister
+	hosts    *docker.Hosts
+	cfg      *config.Config
+	logger   *zap.Logger
+
+	// When each orphan was first seen, keyed by kind, host and container ID
+	// or volume name. Orphans that go away or get their node back are
+	// forgotten.
+	firstSeen map[string]time.Time
+	mu        sync.Mutex
+}
+
+// NodeLister lists the nodes that own containers and volumes.
+// *repository.NodeRepository implements it.
+type NodeLister interface {
+	List(ctx context.Context, status *models.NodeStatus) ([]*models.Node, error)
+}
+
+var _ NodeLister = (*repository.NodeRepository)(nil)
+
+// NewCollector creates a new orphan collector
+func NewCollector(
+	nodeRepo NodeLister,
+	hosts *docker.Hosts,
+	cfg *config.Config,
+	logger *zap.Logger,
+) *Collector {
+	return &Collector{
+		nodeRepo:  nodeRepo,
+		hosts:     hosts,
+		cfg:       cfg,
+		logger:    logger,
+		firstSeen: make(map[string]time.Time),
+	}
+}
+
+// Run collects orphans every GC interval until ctx is cancelled. It returns
+// right away unless GC is enabled.
+func (c *Collector) Run(ctx context.Context) {
+	if !c.cfg.GCEnabled {
+		return
+	}
+
+	ticker := time.NewTicker(c.cfg.GetGCInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := c.Collect(ctx, nil); err != nil {
+				c.logger.Error("Failed to collect orphans", zap.Error(err))
+			}
+		}
+	}
+}
+
+// Scan reports the current orphans without removing any
+func (c *Collector) Scan(ctx context.Context) (*models.GCReport, error) {
+	dryRun := true
+	return c.Collect(ctx, &dryRun)
+}
+
+// Collect looks for orphans and removes those seen for longer than the grace
+// period. Nothing is removed on a dry run; a nil dryRun uses gc_dry_run.
+func (c *Collector) Collect(ctx context.Context, dryRun *bool) (*models.GCReport, error) {
+	report := &models.GCReport{
+		DryRun:    c.cfg.GCDryRun,
+		Orphans:   []models.Orphan{},
+		ScannedAt: time.Now(),
+	}
+	if dryRun != nil {
+		report.DryRun = *dryRun
+	}
+
+	nodes, err := c.nodeRepo.List(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	nodeHosts := make(map[string]string, len(nodes))
+	for _, node := range nodes {
+		nodeHosts[node.ID] = node.HostID
+	}
+
+	hosts := c.hosts.List()
+	if len(hosts) == 0 {
+		return nil, fmt.Errorf("no container hosts connected")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	seen := make(map[string]bool)
+	// Orphans of a host that cannot be scanned are kept until it is back
+	unscanned := make(map[string]bool)
+	track := func(key string, orphan models.Orphan) {
+		first, exists := c.firstSeen[key]
+		if !exists {
+			first = report.ScannedAt
+			c.firstSeen[key] = first
+		}
+		seen[key] = true
+		orphan.FirstSeen = first
+		orphan.RemovableAt = first.Add(c.cfg.GetGCGracePeriod())
+		report.Orphans = append(report.Orphans, orphan)
+	}
+
+	for _, host := range hosts {
+		containers, err := host.Containers.ListAllNodeContainers(ctx)
+		if err == nil {
+			var volumes []*docker.NodeVolume
+			volumes, err = host.Volumes.ListAllNodeVolumes(ctx)
+			if err == nil {
+				c.trackHost(host.ID, containers, volumes, nodeHosts, track)
+			}
+		}
+		if err != nil {
+			unscanned[host.ID] = true
+			report.Errors = append(report.Errors, fmt.Sprintf("host %s: %v", host.ID, err))
+			c.logger.Warn("Failed to scan host for orphans",
+				zap.Error(err),
+				zap.String("host_id", host.ID))
+		}
+	}
+
+	for key := range c.firstSeen {
+		if !seen[key] && !unscanned[hostOfKey(key)] {
+			delete(c.firstSeen, key)
+		}
+	}
+
+	if report.DryRun {
+		if len(report.Orphans) > 0 {
+			c.logger.Info("Found orphaned node resources",
+				zap.Int("orphans", len(report.Orphans)),
+				zap.Bool("dry_run", true))
+		}
+		return report, nil
+	}
+
+	for i := range report.Orphans {
+		orphan := &report.Orphans[i]
+		if report.ScannedAt.Before(orphan.RemovableAt) {
+			continue
+		}
+
+		host, err := c.hosts.Get(orphan.HostID)
+		var key string
+		switch {
+		case err != nil:
+		case orphan.Kind == models.OrphanKindContainer:
+			key = orphanKey(orphan.Kind, orphan.HostID, orphan.ContainerID)
+			err = host.Containers.RemoveContainer(ctx, orphan.ContainerID)
+		default:
+			key = orphanKey(orphan.Kind, orphan.HostID, orphan.Name)
+			err = host.Volumes.RemoveVolume(ctx, orphan.Name)
+		}
+		if err != nil {
+			orphan.Error = err.Error()
+			c.logger.Error("Failed to remove orphan",
+				zap.Error(err),
+				zap.String("kind", string(orphan.Kind)),
+				zap.String("host_id", orphan.HostID),
+				zap.String("name", orphan.Name))
+			continue
+		}
+
+		orphan.Removed = true
+		report.Removed++
+		delete(c.firstSeen, key)
+
+		c.logger.Info("Removed orphan",
+			zap.String("kind", string(orphan.Kind)),
+			zap.String("host_id", orphan.HostID),
+			zap.String("name", orphan.Name),
+			zap.String("node_id", orphan.NodeID))
+	}
+
+	return report, nil
+}
+
+// trackHost tracks the containers and volumes of a host whose node is gone
+// or lives on another host. Containers come first so their volumes are no
+// longer in use when they are removed.
+func (c *Collector) trackHost(
+	hostID string,
+	containers []*docker.ContainerInfo,
+	volumes []*docker.NodeVolume,
+	nodeHosts map[string]string,
+	track func(key string, orphan models.Orphan),
+) {
+	for _, container := range containers {
+		if container.NodeID != "" && nodeHosts[container.NodeID] == hostID {
+			continue
+		}
+		track(orphanKey(models.OrphanKindContainer, hostID, container.ID), models.Orphan{
+			Kind:        models.OrphanKindContainer,
+			HostID:      hostID,
+			Name:        container.Name,
+			ContainerID: container.ID,
+			NodeID:      container.NodeID,
+		})
+	}
+	for _, volume := range volumes {
+		if nodeHosts[volume.NodeID] == hostID {
+			continue
+		}
+		track(orphanKey(models.OrphanKindVolume, hostID, volume.Name), models.Orphan{
+			Kind:   models.OrphanKindVolume,
+			HostID: hostID,
+			Name:   volume.Name,
+			NodeID: volume.NodeID,
+		})
+	}
+}
+
+// orphanKey is the key of an orphan in firstSeen
+func orphanKey(kind models.OrphanKind, hostID, name string) string {
+	return string(kind) + ":" + hostID + ":" + name
+}
+
+// hostOfKey returns the host ID in an orphan key
+func hostOfKey(key string) string {
+	parts := strings.SplitN(key, ":", 3)
+	if len(parts) < 3 {
+		return ""
+	}
+	return parts[1]
+}
@@ -0,0 +1,100 @@
+package gc_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/game-server/controller/internal/core/models"
+	"github.com/game-server/controller/internal/core/repository/repositorytest"
+	"github.com/game-server/controller/internal/docker"
+	"github.com/game-server/controller/internal/docker/dockertest"
+	"github.com/game-server/controller/internal/gc"
+	"github.com/game-server/controller/pkg/config"
+	"go.uber.org/zap"
+)
+
+// newHost connects a fake runtime as the local host, with containers and
+// volumes for the given nodes
+func newHost(t *testing.T, nodeIDs ...string) (*docker.Hosts, *dockertest.Runtime) {
+	t.Helper()
+
+	runtime := dockertest.NewRuntime()
+	hosts := docker.NewHosts(zap.NewNop())
+	hosts.Add(&docker.Host{ID: models.LocalHostID, Runtime: runtime})
+
+	host, _ := hosts.Get(models.LocalHostID)
+	for _, nodeID := range nodeIDs {
+		cfg := &docker.NodeContainerConfig{NodeID: nodeID, NodeName: nodeID, Image: "node:test"}
+		if _, err := host.Containers.CreateNodeContainer(context.Background(), cfg); err != nil {
+			t.Fatalf("CreateNodeContainer(%s): %v", nodeID, err)
+		}
+	}
+	return hosts, runtime
+}
+
+func TestCollectRemovesOrphansAfterGracePeriod(t *testing.T) {
+	ctx := context.Background()
+	hosts, runtime := newHost(t, "kept", "gone")
+	nodes := repositorytest.NewNodes(&models.Node{ID: "kept", HostID: models.LocalHostID})
+
+	collector := gc.NewCollector(nodes, hosts, &config.Config{GCGracePeriod: 0}, zap.NewNop())
+
+	report, err := collector.Scan(ctx)
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	if len(report.Orphans) != 4 {
+		t.Fatalf("Scan found %d orphans, want the container and 3 volumes of the deleted node: %+v", len(report.Orphans), report.Orphans)
+	}
+	for _, orphan := range report.Orphans {
+		if orphan.NodeID != "gone" {
+			t.Errorf("orphan %s belongs to node %q, want gone", orphan.Name, orphan.NodeID)
+		}
+	}
+	if report.Removed != 0 || len(runtime.Containers()) != 2 {
+		t.Fatalf("Scan removed orphans")
+	}
+
+	dryRun := false
+	report, err = collector.Collect(ctx, &dryRun)
+	if err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	if report.Removed != 4 {
+		t.Fatalf("Collect removed %d orphans, want 4: %+v", report.Removed, report.Orphans)
+	}
+
+	containers := runtime.Containers()
+	if len(containers) != 1 || containers[0].Labels["game-server.node-id"] != "kept" {
+		t.Errorf("containers after Collect = %+v, want only the kept node's", containers)
+	}
+	want := []string{
+		"game-server-node-kept-backups",
+		"game-server-node-kept-logs",
+		"game-server-node-kept-servers",
+	}
+	if volumes := runtime.Volumes(); !reflect.DeepEqual(volumes, want) {
+		t.Errorf("volumes after Collect = %v, want %v", volumes, want)
+	}
+}
+
+func TestCollectKeepsOrphansWithinGracePeriod(t *testing.T) {
+	ctx := context.Background()
+	hosts, runtime := newHost(t, "gone")
+	nodes := repositorytest.NewNodes()
+
+	collector := gc.NewCollector(nodes, hosts, &config.Config{GCGracePeriod: 3600}, zap.NewNop())
+
+	dryRun := false
+	report, err := collector.Collect(ctx, &dryRun)
+	if err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	if len(report.Orphans) != 4 || report.Removed != 0 {
+		t.Fatalf("Collect found %d orphans and removed %d, want 4 and 0", len(report.Orphans), report.Removed)
+	}
+	if len(runtime.Containers()) != 1 || len(runtime.Volumes()) != 3 {
+		t.Errorf("orphans were removed within the grace period")
+	}
+}
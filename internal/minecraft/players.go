@@ -0,0 +1,207 @@
+package minecraft
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PlayerList identifies one of the server's player list files
+type PlayerList string
+
+const (
+	PlayerListWhitelist PlayerList = "whitelist"
+	PlayerListOps       PlayerList = "ops"
+	PlayerListBans      PlayerList = "bans"
+)
+
+// banTimeFormat is the date format used in banned-players.json
+const banTimeFormat = "2006-01-02 15:04:05 -0700"
+
+// mojangProfileURL is the Mojang API endpoint resolving names to profiles
+var mojangProfileURL = "https://api.mojang.com/users/profiles/minecraft/"
+
+// playerNamePattern matches valid Minecraft player names
+var playerNamePattern = regexp.MustCompile(`^[A-Za-z0-9_]{1,16}$`)
+
+// ParsePlayerList validates a player list name
+func ParsePlayerList(name string) (PlayerList, error) {
+	switch list := PlayerList(name); list {
+	case PlayerListWhitelist, PlayerListOps, PlayerListBans:
+		return list, nil
+	}
+	return "", fmt.Errorf("unknown player list %q, must be one of whitelist, ops or bans", name)
+}
+
+// File returns the name of the file backing the list
+func (l PlayerList) File() string {
+	switch l {
+	case PlayerListOps:
+		return "ops.json"
+	case PlayerListBans:
+		return "banned-players.json"
+	default:
+		return "whitelist.json"
+	}
+}
+
+// AddCommand returns the console command adding a player to the list
+func (l PlayerList) AddCommand(name, reason string) string {
+	switch l {
+	case PlayerListOps:
+		return "op " + name
+	case PlayerListBans:
+		if reason != "" {
+			return "ban " + name + " " + reason
+		}
+		return "ban " + name
+	default:
+		return "whitelist add " + name
+	}
+}
+
+// RemoveCommand returns the console command removing a player from the list
+func (l PlayerList) RemoveCommand(name string) string {
+	switch l {
+	case PlayerListOps:
+		return "deop " + name
+	case PlayerListBans:
+		return "pardon " + name
+	default:
+		return "whitelist remove " + name
+	}
+}
+
+// PlayerEntry is an entry of whitelist.json, ops.json or banned-players.json.
+// Fields that do not apply to a list are left empty.
+type PlayerEntry struct {
+	UUID                string `json:"uuid"`
+	Name                string `json:"name"`
+	Level               int    `json:"level,omitempty"`
+	BypassesPlayerLimit bool   `json:"bypassesPlayerLimit,omitempty"`
+	Created             string `json:"created,omitempty"`
+	Source              string `json:"source,omitempty"`
+	Expires             string `json:"expires,omitempty"`
+	Reason              string `json:"reason,omitempty"`
+}
+
+// NewPlayerEntry creates an entry for a list with the fields Minecraft writes
+func NewPlayerEntry(list PlayerList, id, name string, level int, reason string) PlayerEntry {
+	entry := PlayerEntry{UUID: id, Name: name}
+
+	switch list {
+	case PlayerListOps:
+		if level < 1 || level > 4 {
+			level = 4
+		}
+		entry.Level = level
+	case PlayerListBans:
+		if reason == "" {
+			reason = "Banned by an operator."
+		}
+		entry.Created = time.Now().Format(banTimeFormat)
+		entry.Source = "Server"
+		entry.Expires = "forever"
+		entry.Reason = reason
+	}
+
+	return entry
+}
+
+// ParsePlayerEntries parses a player list file. An empty file is an empty list.
+func ParsePlayerEntries(data []byte) ([]PlayerEntry, error) {
+	entries := []PlayerEntry{}
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return entries, nil
+	}
+
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse player list: %w", err)
+	}
+	return entries, nil
+}
+
+// FormatPlayerEntries formats a player list file like Minecraft does
+func FormatPlayerEntries(entries []PlayerEntry) ([]byte, error) {
+	if entries == nil {
+		entries = []PlayerEntry{}
+	}
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to format player list: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// ValidatePlayerName checks that name is a valid Minecraft player name
+func ValidatePlayerName(name string) error {
+	if !playerNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid player name %q", name)
+	}
+	return nil
+}
+
+// OfflineUUID returns the UUID an offline-mode server assigns to a player,
+// a version 3 UUID of "OfflinePlayer:<name>"
+func OfflineUUID(name string) string {
+	hash := md5.Sum([]byte("OfflinePlayer:" + name))
+	hash[6] = hash[6]&0x0f | 0x30
+	hash[8] = hash[8]&0x3f | 0x80
+	return uuid.UUID(hash).String()
+}
+
+// ResolvePlayer returns the UUID and canonical name of a player. Online-mode
+// servers use the player's Mojang account; offline-mode servers derive the
+// UUID from the name.
+func ResolvePlayer(ctx context.Context, name string, onlineMode bool) (string, string, error) {
+	if err := ValidatePlayerName(name); err != nil {
+		return "", "", err
+	}
+	if !onlineMode {
+		return OfflineUUID(name), name, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, mojangProfileURL+name, nil)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create profile request: %w", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to look up player %s: %w", name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNoContent || resp.StatusCode == http.StatusNotFound {
+		return "", "", fmt.Errorf("no Minecraft account named %s", name)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", "", fmt.Errorf("failed to look up player %s: Mojang API returned %s", name, resp.Status)
+	}
+
+	var profile struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&profile); err != nil {
+		return "", "", fmt.Errorf("failed to decode profile: %w", err)
+	}
+
+	id, err := uuid.Parse(profile.ID)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid profile UUID %q: %w", profile.ID, err)
+	}
+
+	return id.String(), profile.Name, nil
+}
@@ -0,0 +1,302 @@
+package minecraft
+
+import (
+	"bufio"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/game-server/controller/internal/core/models"
+)
+
+// GameType is the game type ID of Minecraft Java Edition servers
+const GameType = "minecraft"
+
+// PropertiesFile is the name of the file rendered by RenderProperties
+const PropertiesFile = "server.properties"
+
+// RCONPasswordKey is the server.properties key holding the RCON password
+const RCONPasswordKey = "rcon.password"
+
+// RenderProperties builds the canonical server.properties values for a server
+// from its settings and first-class fields. First-class fields always win
+// over settings with the same key.
+func RenderProperties(server *models.Server) map[string]string {
+	props := make(map[string]string, len(server.Settings)+8)
+	for k, v := range server.Settings {
+		props[k] = v
+	}
+
+	worldName := server.WorldName
+	if worldName == "" {
+		worldName = "world"
+	}
+
+	props["level-name"] = worldName
+	props["max-players"] = strconv.Itoa(server.MaxPlayers)
+	props["online-mode"] = strconv.FormatBool(server.OnlineMode)
+
+	// RCON is always enabled so the controller can run console commands
+	props["enable-rcon"] = "true"
+
+	// Ports are assigned by the node, so they are only known after creation
+	if server.Port > 0 {
+		props["server-port"] = strconv.Itoa(server.Port)
+	}
+	if server.QueryPort > 0 {
+		props["enable-query"] = "true"
+		props["query.port"] = strconv.Itoa(server.QueryPort)
+	}
+	if server.RCONPort > 0 {
+		props["rcon.port"] = strconv.Itoa(server.RCONPort)
+	}
+
+	return props
+}
+
+// FormatProperties serializes properties in server.properties format with
+// keys sorted so the output is stable
+func FormatProperties(props map[string]string) string {
+	keys := make([]string, 0, len(props))
+	for k := range props {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString("# Minecraft server properties\n")
+	b.WriteString("# Managed by the game server controller, manual edits will be reported as drift\n")
+	for _, k := range keys {
+		b.WriteString(escapeProperty(k, true))
+		b.WriteByte('=')
+		b.WriteString(escapeProperty(props[k], false))
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// ParseProperties parses a Java properties file such as server.properties
+func ParseProperties(data string) (map[string]string, error) {
+	props := make(map[string]string)
+
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	var logical strings.Builder
+	for scanner.Scan() {
+		line := strings.TrimLeft(scanner.Text(), " \t\f")
+
+		if logical.Len() == 0 && (line == "" || line[0] == '#' || line[0] == '!') {
+			continue
+		}
+
+		// A line ending in an odd number of backslashes continues on the next line
+		if trailingBackslashes(line)%2 == 1 {
+			logical.WriteString(line[:len(line)-1])
+			continue
+		}
+		logical.WriteString(line)
+
+		key, value, err := splitProperty(logical.String())
+		if err != nil {
+			return nil, err
+		}
+		props[key] = value
+		logical.Reset()
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read properties: %w", err)
+	}
+
+	if logical.Len() > 0 {
+		key, value, err := splitProperty(logical.String())
+		if err != nil {
+			return nil, err
+		}
+		props[key] = value
+	}
+
+	return props, nil
+}
+
+// PropertyDrift describes a single property whose reported value differs
+type PropertyDrift struct {
+	Key      string `json:"key"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+}
+
+// DriftReport compares the rendered properties with those reported by the node
+type DriftReport struct {
+	InSync  bool            `json:"in_sync"`
+	Changed []PropertyDrift `json:"changed"`
+	Missing []string        `json:"missing"`
+}
+
+// RedactProperties returns a copy of props with secrets masked
+func RedactProperties(props map[string]string) map[string]string {
+	result := make(map[string]string, len(props))
+	for k, v := range props {
+		result[k] = v
+	}
+	if _, ok := result[RCONPasswordKey]; ok {
+		result[RCONPasswordKey] = "********"
+	}
+	return result
+}
+
+// DetectDrift compares expected properties with the properties reported by
+// the agent. Keys that the controller does not manage are ignored because
+// Minecraft writes every default into server.properties.
+func DetectDrift(expected, actual map[string]string) *DriftReport {
+	report := &DriftReport{
+		Changed: []PropertyDrift{},
+		Missing: []string{},
+	}
+
+	keys := make([]string, 0, len(expected))
+	for k := range expected {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		value, ok := actual[k]
+		if !ok {
+			report.Missing = append(report.Missing, k)
+			continue
+		}
+		if value != expected[k] {
+			report.Changed = append(report.Changed, PropertyDrift{
+				Key:      k,
+				Expected: expected[k],
+				Actual:   value,
+			})
+		}
+	}
+
+	report.InSync = len(report.Changed) == 0 && len(report.Missing) == 0
+	return report
+}
+
+// Helper functions
+
+func trailingBackslashes(s string) int {
+	n := 0
+	for i := len(s) - 1; i >= 0 && s[i] == '\\'; i-- {
+		n++
+	}
+	return n
+}
+
+// splitProperty splits a logical line into an unescaped key and value
+func splitProperty(line string) (string, string, error) {
+	var key strings.Builder
+	i := 0
+	for i < len(line) {
+		c := line[i]
+		if c == '\\' && i+1 < len(line) {
+			key.WriteByte(line[i+1])
+			i += 2
+			continue
+		}
+		if c == '=' || c == ':' || c == ' ' || c == '\t' || c == '\f' {
+			break
+		}
+		key.WriteByte(c)
+		i++
+	}
+
+	// Skip whitespace, then at most one separator, then whitespace again
+	rest := strings.TrimLeft(line[i:], " \t\f")
+	if rest != "" && (rest[0] == '=' || rest[0] == ':') {
+		rest = strings.TrimLeft(rest[1:], " \t\f")
+	}
+
+	value, err := unescapeProperty(rest)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid value for %s: %w", key.String(), err)
+	}
+	return key.String(), value, nil
+}
+
+func unescapeProperty(s string) (string, error) {
+	if !strings.Contains(s, "\\") {
+		return s, nil
+	}
+
+	var b strings.Builder
+	var highSurrogate rune
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '\\' || i+1 >= len(s) {
+			b.WriteByte(c)
+			continue
+		}
+
+		i++
+		switch s[i] {
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 'f':
+			b.WriteByte('\f')
+		case 'u':
+			if i+4 >= len(s) {
+				return "", fmt.Errorf("truncated unicode escape")
+			}
+			r, err := strconv.ParseUint(s[i+1:i+5], 16, 32)
+			if err != nil {
+				return "", fmt.Errorf("invalid unicode escape: %w", err)
+			}
+			i += 4
+			switch {
+			case utf16.IsSurrogate(rune(r)) && highSurrogate == 0:
+				highSurrogate = rune(r)
+			case highSurrogate != 0:
+				b.WriteRune(utf16.DecodeRune(highSurrogate, rune(r)))
+				highSurrogate = 0
+			default:
+				b.WriteRune(rune(r))
+			}
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String(), nil
+}
+
+func escapeProperty(s string, isKey bool) string {
+	var b strings.Builder
+	for i, r := range s {
+		switch {
+		case r == '\\':
+			b.WriteString(`\\`)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r == '=' || r == ':' || r == '#' || r == '!':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == ' ' && (isKey || i == 0):
+			b.WriteString(`\ `)
+		case r > 0x7e:
+			// server.properties is read as ISO-8859-1, so escape as UTF-16
+			if r > 0xffff {
+				r1, r2 := utf16.EncodeRune(r)
+				fmt.Fprintf(&b, `\u%04x\u%04x`, r1, r2)
+			} else {
+				fmt.Fprintf(&b, `\u%04x`, r)
+			}
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
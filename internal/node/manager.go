@@ -2,7 +2,9 @@ package node
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"sync"
 	"time"
 
@@ -13,12 +15,39 @@ import (
 	"go.uber.org/zap"
 )
 
+// ErrCrossHost is returned when copying data between nodes on different
+// container hosts
+var ErrCrossHost = errors.New("nodes are on different hosts")
+
+// NodeStore stores nodes. *repository.NodeRepository keeps them in the
+// database; repositorytest.Nodes keeps them in memory for tests.
+type NodeStore interface {
+	Create(ctx context.Context, node *models.Node) error
+	// GetByID returns nil if the node does not exist
+	GetByID(ctx context.Context, id string) (*models.Node, error)
+	List(ctx context.Context, status *models.NodeStatus) ([]*models.Node, error)
+	Update(ctx context.Context, node *models.Node) error
+	UpdateResources(ctx context.Context, id string, resources models.NodeResources) error
+	Delete(ctx context.Context, id string) error
+}
+
+// ServerStore is the part of the server repository the manager uses
+type ServerStore interface {
+	// DeleteByNodeID deletes the servers of a node and returns how many
+	// there were
+	DeleteByNodeID(ctx context.Context, nodeID string) (int, error)
+}
+
+var (
+	_ NodeStore   = (*repository.NodeRepository)(nil)
+	_ ServerStore = (*repository.ServerRepository)(nil)
+)
+
 // Manager handles node lifecycle and communication
 type Manager struct {
-	nodeRepo      *repository.NodeRepository
-	serverRepo    *repository.ServerRepository
-	volumeMgr     *docker.VolumeManager
-	containerMgr  *docker.ContainerManager
+	nodeRepo      NodeStore
+	serverRepo    ServerStore
+	hosts         *docker.Hosts // container hosts that node containers run on
 	cfg           *config.Config
 	logger        *zap.Logger
 	
@@ -36,6 +65,18 @@ type NodeState struct {
 	LastHeartbeat time.Time
 	CommandQueue  chan *Command
 	Metrics       *models.NodeMetrics
+	RegisteredAt  time.Time // when the agent last registered
+	// ContainerMetrics is the latest stats sample of the node's container
+	ContainerMetrics *models.NodeMetrics
+	// ContainerCPUs is the number of CPUs the container's CPU usage is
+	// relative to: its limit, or its host's CPUs without one. Zero if unknown.
+	ContainerCPUs int
+	// ContainerUnhealthy is set while the node is unhealthy because its
+	// container is overloaded and its agent silent
+	ContainerUnhealthy bool
+	// HeartbeatTimedOut is set while the node's agent has sent nothing for
+	// longer than the node timeout
+	HeartbeatTimedOut bool
 }
 
 // Command represents a command to be sent to a node
@@ -56,6 +97,8 @@ const (
 	CommandTypeStartServer   CommandType = "start_server"
 	CommandTypeStopServer    CommandType = "stop_server"
 	CommandTypeRestartServer CommandType = "restart_server"
+	CommandTypeReadFile      CommandType = "read_file"
+	CommandTypeWriteFile     CommandType = "write_file"
 )
 
 // CommandResult represents the result of a command
@@ -63,6 +106,8 @@ type CommandResult struct {
 	Success bool
 	Message string
 	Error   error
+	Data    []byte         // file contents for read_file
+	Ports   map[string]int // ports allocated by create_server: port, query_port and rcon_port
 }
 
 // StreamEvent represents an event from a node stream
@@ -75,18 +120,16 @@ type StreamEvent struct {
 
 // NewManager creates a new node manager
 func NewManager(
-	nodeRepo *repository.NodeRepository,
-	serverRepo *repository.ServerRepository,
-	volumeMgr *docker.VolumeManager,
-	containerMgr *docker.ContainerManager,
+	nodeRepo NodeStore,
+	serverRepo ServerStore,
+	hosts *docker.Hosts,
 	cfg *config.Config,
 	logger *zap.Logger,
 ) *Manager {
 	return &Manager{
 		nodeRepo:     nodeRepo,
 		serverRepo:   serverRepo,
-		volumeMgr:    volumeMgr,
-		containerMgr: containerMgr,
+		hosts:        hosts,
 		cfg:          cfg,
 		logger:       logger,
 		nodes:        make(map[string]*NodeState),
@@ -102,23 +145,46 @@ func (m *Manager) RegisterNode(ctx context.Context, node *models.Node) error {
 	existing, exists := m.nodes[node.ID]
 	if exists {
 		// Update existing node state
+		if isOperatorStatus(existing.Node.Status) {
+			node.Status = existing.Node.Status
+		} else {
+			node.Status = models.NodeStatusOnline
+		}
+		// Limits and the host are set by the controller, not reported by the
+		// agent
+		node.NodeResources = existing.Node.NodeResources
+		node.HostID = existing.Node.HostID
 		existing.Node = node
 		existing.Connected = true
 		existing.LastHeartbeat = time.Now()
+		existing.RegisteredAt = time.Now()
+		existing.ContainerUnhealthy = false
 		m.mu.Unlock()
 
+		// Nodes recovered at startup are still reconnecting in the database
+		if err := m.nodeRepo.Update(ctx, node); err != nil {
+			m.logger.Error("Failed to update node status", zap.Error(err))
+		}
+
 		m.logger.Info("Node reconnected",
 			zap.String("node_id", node.ID),
 			zap.String("name", node.Name))
 		return nil
 	}
 
+	// Agents do not know their host, nodes the controller did not create
+	// are taken to be local
+	if node.HostID == "" {
+		node.HostID = models.LocalHostID
+	}
+
 	// Create new node state in memory
 	state := &NodeState{
 		Node:          node,
 		Connected:     true,
 		LastHeartbeat: time.Now(),
 		CommandQueue:  make(chan *Command, 100),
+		RegisteredAt:  time.Now(),
 	}
 
 	m.nodes[node.ID] = state
@@ -139,8 +205,18 @@ func (m *Manager) RegisterNode(ctx context.Context, node *models.Node) error {
 			return fmt.Errorf("failed to create node in database: %w", err)
 		}
 	} else {
-		// Update status to online in database
-		node.Status = models.NodeStatusOnline
+		m.mu.Lock()
+		node.NodeResources = existingNode.NodeResources
+		node.HostID = existingNode.HostID
+		m.mu.Unlock()
+
+		// Update status to online in database, unless an operator took the
+		// node out of service
+		if isOperatorStatus(existingNode.Status) {
+			m.UpdateNodeStatus(node.ID, existingNode.Status)
+		} else {
+			node.Status = models.NodeStatusOnline
+		}
 		if err := m.nodeRepo.Update(ctx, node); err != nil {
 			m.logger.Error("Failed to update node status", zap.Error(err))
 		}
@@ -149,6 +225,131 @@ func (m *Manager) RegisterNode(ctx context.Context, node *models.Node) error {
 	return nil
 }
 
+// Recover rebuilds the in-memory state of the nodes in the database after a
+// controller restart, so they can be listed and queued commands before their
+// agents register again. Nodes whose agent container is running are
+// reconnecting until RunReconnectGrace gives up on them; nodes without one
+// are offline. Operator statuses are kept.
+func (m *Manager) Recover(ctx context.Context) error {
+	nodes, err := m.nodeRepo.List(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	// The containers on hosts that cannot be reached are unknown, so their
+	// nodes get the grace period
+	running := make(map[string]bool)
+	listed := make(map[string]bool)
+	for _, host := range m.hosts.List() {
+		containers, err := host.Containers.ListNodeContainers(ctx)
+		if err != nil {
+			m.logger.Warn("Failed to list node containers",
+				zap.Error(err),
+				zap.String("host_id", host.ID))
+			continue
+		}
+		listed[host.ID] = true
+		for _, c := range containers {
+			if c.NodeID != "" {
+				running[c.NodeID] = true
+			}
+		}
+	}
+
+	reconnecting, offline := 0, 0
+	for _, node := range nodes {
+		status := node.Status
+		switch {
+		case isOperatorStatus(node.Status):
+		case running[node.ID] || !listed[node.HostID]:
+			status = models.NodeStatusReconnecting
+			reconnecting++
+		default:
+			status = models.NodeStatusOffline
+			offline++
+		}
+
+		m.mu.Lock()
+		_, registered := m.nodes[node.ID]
+		if !registered {
+			node.Status = status
+			m.nodes[node.ID] = &NodeState{
+				Node:          node,
+				LastHeartbeat: node.LastHeartbeat,
+				CommandQueue:  make(chan *Command, 100),
+			}
+		}
+		m.mu.Unlock()
+
+		// The agent registered while recovering, it owns the status now
+		if registered {
+			continue
+		}
+		if err := m.nodeRepo.Update(ctx, node); err != nil {
+			m.logger.Error("Failed to update node status",
+				zap.Error(err),
+				zap.String("node_id", node.ID))
+		}
+	}
+
+	m.logger.Info("Recovered nodes",
+		zap.Int("nodes", len(nodes)),
+		zap.Int("reconnecting", reconnecting),
+		zap.Int("offline", offline))
+
+	return nil
+}
+
+// RunReconnectGrace waits for the reconnect grace period after Recover and
+// marks the nodes whose agent has not registered again offline
+func (m *Manager) RunReconnectGrace(ctx context.Context) {
+	timer := time.NewTimer(m.cfg.GetNodeReconnectGracePeriod())
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return
+	case <-timer.C:
+	}
+
+	var expired []*models.Node
+	m.mu.Lock()
+	for _, state := range m.nodes {
+		if !state.Connected && state.Node.Status == models.NodeStatusReconnecting {
+			state.Node.Status = models.NodeStatusOffline
+			node := *state.Node
+			expired = append(expired, &node)
+		}
+	}
+	m.mu.Unlock()
+
+	for _, node := range expired {
+		m.logger.Warn("Node did not reconnect",
+			zap.String("node_id", node.ID),
+			zap.String("name", node.Name))
+		if err := m.nodeRepo.Update(ctx, node); err != nil {
+			m.logger.Error("Failed to update node status",
+				zap.Error(err),
+				zap.String("node_id", node.ID))
+		}
+	}
+}
+
+// IsConnected returns whether a node's agent is connected
+func (m *Manager) IsConnected(nodeID string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	state, exists := m.nodes[nodeID]
+	return exists && state.Connected
+}
+
+// isOperatorStatus returns whether a status was set by an operator and must
+// survive the agent reconnecting
+func isOperatorStatus(status models.NodeStatus) bool {
+	return status == models.NodeStatusDraining || status == models.NodeStatusMaintenance
+}
+
 // CreateNode creates a new node in the database only (called via REST API)
 func (m *Manager) CreateNode(ctx context.Context, node *models.Node) error {
 	// Only create in database - in-memory state is for connected agents only
@@ -193,6 +394,9 @@ func (m *Manager) UnregisterNode(ctx context.Context, nodeID string) error {
 
 // DeleteNode permanently deletes a node and all its servers
 func (m *Manager) DeleteNode(ctx context.Context, nodeID string) error {
+	// Look the host up while the node still exists
+	host, hostErr := m.nodeHost(nodeID)
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -231,19 +435,21 @@ func (m *Manager) DeleteNode(ctx context.Context, nodeID string) error {
 		return fmt.Errorf("failed to delete node from database: %w", err)
 	}
 
-	// Remove Docker container for this node
-	if m.containerMgr != nil {
-		if err := m.containerMgr.RemoveNodeContainer(ctx, nodeID); err != nil {
+	if hostErr != nil {
+		m.logger.Warn("Failed to find node host, its container and volumes are left to the orphan collector",
+			zap.Error(hostErr),
+			zap.String("node_id", nodeID))
+	} else {
+		// Remove Docker container for this node
+		if err := host.Containers.RemoveNodeContainer(ctx, nodeID); err != nil {
 			m.logger.Warn("Failed to remove node container",
 				zap.Error(err),
 				zap.String("node_id", nodeID))
 			// Don't fail the deletion, just log the warning
 		}
-	}
 
-	// Delete Docker volumes for this node
-	if m.volumeMgr != nil {
-		if err := m.volumeMgr.DeleteNodeVolumes(ctx, nodeID); err != nil {
+		// Delete Docker volumes for this node
+		if err := host.Volumes.DeleteNodeVolumes(ctx, nodeID); err != nil {
 			m.logger.Warn("Failed to delete node volumes",
 				zap.Error(err),
 				zap.String("node_id", nodeID))
@@ -259,29 +465,386 @@ func (m *Manager) DeleteNode(ctx context.Context, nodeID string) error {
 
 // CreateNodeContainer creates a new node container
 func (m *Manager) CreateNodeContainer(ctx context.Context, cfg *docker.NodeContainerConfig) (string, error) {
-	if m.containerMgr == nil {
-		return "", fmt.Errorf("container manager not initialized")
+	host, err := m.nodeHost(cfg.NodeID)
+	if err != nil {
+		return "", err
 	}
 
-	containerID, err := m.containerMgr.CreateNodeContainer(ctx, cfg)
+	containerID, err := host.Containers.CreateNodeContainer(ctx, cfg)
 	if err != nil {
 		return "", fmt.Errorf("failed to create node container: %w", err)
 	}
 
 	m.logger.Info("Node container created",
 		zap.String("container_id", containerID),
-		zap.String("node_id", cfg.NodeID))
+		zap.String("node_id", cfg.NodeID),
+		zap.String("host_id", host.ID))
+
+	return containerID, nil
+}
+
+// NodeContainerConfig returns the container configuration of a node. The
+// controller address and network depend on the node's host.
+func (m *Manager) NodeContainerConfig(node *models.Node) *docker.NodeContainerConfig {
+	cfg := &docker.NodeContainerConfig{
+		NodeID:         node.ID,
+		NodeName:       node.Name,
+		Image:          m.cfg.NodeAgentImage,
+		ControllerAddr: m.cfg.GetGRPCAddress(),
+		MaxServers:     node.MaxServers,
+		TotalCPUCores:  node.CPUCores,
+		TotalMemoryMB:  node.MemoryMB,
+		TotalStorageMB: node.StorageMB,
+		GameTypes:      []string{node.GameType},
+		NetworkName:    m.cfg.NodeNetworkName,
+	}
+
+	if host, err := m.hosts.Get(node.HostID); err == nil {
+		if host.ControllerAddress != "" {
+			cfg.ControllerAddr = host.ControllerAddress
+		}
+		cfg.NetworkName = host.NetworkName
+	}
+
+	return cfg
+}
+
+// Hosts returns the connected container hosts
+func (m *Manager) Hosts() *docker.Hosts {
+	return m.hosts
+}
+
+// nodeHost returns the container host of a node
+func (m *Manager) nodeHost(nodeID string) (*docker.Host, error) {
+	node, err := m.GetNode(nodeID)
+	if err != nil {
+		return nil, err
+	}
+	return m.hosts.Get(node.HostID)
+}
+
+// sameHost returns the container host of two nodes, which must share it
+// since data is copied between their volumes by a helper container
+func (m *Manager) sameHost(fromNodeID, toNodeID string) (*docker.Host, error) {
+	from, err := m.GetNode(fromNodeID)
+	if err != nil {
+		return nil, err
+	}
+	to, err := m.GetNode(toNodeID)
+	if err != nil {
+		return nil, err
+	}
+	if from.HostID != to.HostID {
+		return nil, fmt.Errorf("%w: node %s is on %s, node %s is on %s",
+			ErrCrossHost, fromNodeID, from.HostID, toNodeID, to.HostID)
+	}
+	return m.hosts.Get(from.HostID)
+}
+
+// ResizeNode changes the container limits of a node by recreating its
+// container. Servers running in the container are stopped with it. The old
+// container is kept if the new one cannot be created, and the limits are
+// only saved once it has started.
+func (m *Manager) ResizeNode(ctx context.Context, nodeID string, resources models.NodeResources) error {
+	node, err := m.GetNode(nodeID)
+	if err != nil {
+		return err
+	}
+	host, err := m.hosts.Get(node.HostID)
+	if err != nil {
+		return err
+	}
+
+	resized := *node
+	resized.NodeResources = resources
+	cfg := m.NodeContainerConfig(&resized)
+	cfg.Image = m.ContainerImage(ctx, nodeID)
+	if _, err := host.Containers.RecreateNodeContainer(ctx, cfg); err != nil {
+		return fmt.Errorf("failed to recreate node container: %w", err)
+	}
+	m.markDisconnected(nodeID)
+
+	if err := m.nodeRepo.UpdateResources(ctx, nodeID, resources); err != nil {
+		// Put the container back to the limits that are still stored
+		cfg = m.NodeContainerConfig(node)
+		cfg.Image = m.ContainerImage(ctx, nodeID)
+		if _, rollbackErr := host.Containers.RecreateNodeContainer(ctx, cfg); rollbackErr != nil {
+			m.logger.Error("Failed to restore node container limits",
+				zap.Error(rollbackErr),
+				zap.String("node_id", nodeID))
+		}
+		return err
+	}
+
+	m.mu.Lock()
+	if state, exists := m.nodes[nodeID]; exists {
+		state.Node.NodeResources = resources
+	}
+	m.mu.Unlock()
+
+	m.logger.Info("Node resized",
+		zap.String("node_id", nodeID),
+		zap.Int("max_servers", resources.MaxServers),
+		zap.Int("cpu_cores", resources.CPUCores),
+		zap.Int64("memory_mb", resources.MemoryMB),
+		zap.Int64("storage_mb", resources.StorageMB))
+
+	return nil
+}
+
+// StartNodeContainer starts a node's stopped container
+func (m *Manager) StartNodeContainer(ctx context.Context, nodeID string) error {
+	host, err := m.nodeHost(nodeID)
+	if err != nil {
+		return err
+	}
+
+	return host.Containers.StartNodeContainer(ctx, nodeID)
+}
+
+// StopNodeContainer stops a node's container. The node is offline until its
+// agent registers again.
+func (m *Manager) StopNodeContainer(ctx context.Context, nodeID string) error {
+	host, err := m.nodeHost(nodeID)
+	if err != nil {
+		return err
+	}
+
+	if err := host.Containers.StopNodeContainer(ctx, nodeID); err != nil {
+		return err
+	}
+	m.markDisconnected(nodeID)
+
+	return nil
+}
+
+// RestartNodeContainer restarts a node's container
+func (m *Manager) RestartNodeContainer(ctx context.Context, nodeID string) error {
+	host, err := m.nodeHost(nodeID)
+	if err != nil {
+		return err
+	}
+
+	if err := host.Containers.RestartNodeContainer(ctx, nodeID); err != nil {
+		return err
+	}
+	m.markDisconnected(nodeID)
+
+	return nil
+}
+
+// RecreateNodeContainer replaces a node's container, keeping its volumes. An
+// empty image keeps the image the container runs now.
+func (m *Manager) RecreateNodeContainer(ctx context.Context, nodeID, image string) (string, error) {
+	node, err := m.GetNode(nodeID)
+	if err != nil {
+		return "", err
+	}
+	host, err := m.hosts.Get(node.HostID)
+	if err != nil {
+		return "", err
+	}
+
+	cfg := m.NodeContainerConfig(node)
+	cfg.Image = image
+	if cfg.Image == "" {
+		cfg.Image = m.ContainerImage(ctx, nodeID)
+	}
+
+	containerID, err := host.Containers.RecreateNodeContainer(ctx, cfg)
+	if err != nil {
+		return "", fmt.Errorf("failed to recreate node container: %w", err)
+	}
+	m.markDisconnected(nodeID)
 
 	return containerID, nil
 }
 
+// AgentImage returns the configured node agent image
+func (m *Manager) AgentImage() string {
+	return m.cfg.NodeAgentImage
+}
+
+// WaitForRegistration waits until a node's agent registers after since, and
+// returns the node as it registered
+func (m *Manager) WaitForRegistration(ctx context.Context, nodeID string, since time.Time) (*models.Node, error) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		m.mu.RLock()
+		state, exists := m.nodes[nodeID]
+		var node *models.Node
+		if exists && state.Connected && state.RegisteredAt.After(since) {
+			registered := *state.Node
+			node = &registered
+		}
+		m.mu.RUnlock()
+
+		if node != nil {
+			return node, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("node %s did not register: %w", nodeID, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// ContainerImage returns the image of a node's container, or the configured
+// agent image if the container does not exist
+func (m *Manager) ContainerImage(ctx context.Context, nodeID string) string {
+	host, err := m.nodeHost(nodeID)
+	if err != nil {
+		return m.cfg.NodeAgentImage
+	}
+
+	info, err := host.Containers.GetNodeContainerInfo(ctx, nodeID)
+	if err != nil || info == nil || info.Image == "" {
+		return m.cfg.NodeAgentImage
+	}
+	return info.Image
+}
+
+// ContainerImageID returns the ID of the image a node's container was
+// created from, or "" if it is not known. Unlike the image's tag, the ID
+// still names the same image after a newer one is pulled under the tag.
+func (m *Manager) ContainerImageID(ctx context.Context, nodeID string) string {
+	host, err := m.nodeHost(nodeID)
+	if err != nil {
+		return ""
+	}
+
+	info, err := host.Containers.GetNodeContainerInfo(ctx, nodeID)
+	if err != nil || info == nil {
+		return ""
+	}
+	return info.ImageID
+}
+
+// markDisconnected marks a node whose container went away as disconnected
+// and offline, keeping an operator status
+func (m *Manager) markDisconnected(nodeID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	state, exists := m.nodes[nodeID]
+	if !exists {
+		return
+	}
+	state.Connected = false
+	if !isOperatorStatus(state.Node.Status) {
+		state.Node.Status = models.NodeStatusOffline
+	}
+}
+
 // GetNodeContainerInfo returns information about a node container
 func (m *Manager) GetNodeContainerInfo(ctx context.Context, nodeID string) (*docker.ContainerInfo, error) {
-	if m.containerMgr == nil {
-		return nil, fmt.Errorf("container manager not initialized")
+	host, err := m.nodeHost(nodeID)
+	if err != nil {
+		return nil, err
+	}
+
+	return host.Containers.GetNodeContainerInfo(ctx, nodeID)
+}
+
+// NodeContainerLogs returns the logs of a node's container
+func (m *Manager) NodeContainerLogs(ctx context.Context, nodeID string, opts docker.LogOptions) (io.ReadCloser, error) {
+	host, err := m.nodeHost(nodeID)
+	if err != nil {
+		return nil, err
+	}
+
+	return host.Containers.NodeContainerLogs(ctx, nodeID, opts)
+}
+
+// NodeAddress returns the address of a node's container on its host's
+// network, which game servers on the node listen on
+func (m *Manager) NodeAddress(ctx context.Context, nodeID string) (string, error) {
+	info, err := m.GetNodeContainerInfo(ctx, nodeID)
+	if err != nil {
+		return "", err
+	}
+	if info == nil {
+		return "", fmt.Errorf("container not found for node: %s", nodeID)
+	}
+	if info.IPAddress == "" {
+		return "", fmt.Errorf("container of node %s has no network address", nodeID)
+	}
+	return info.IPAddress, nil
+}
+
+// CopyServerData copies a server's data from one node's volume to another's.
+// Both nodes must be on the same host.
+func (m *Manager) CopyServerData(ctx context.Context, serverID, fromNodeID, toNodeID string) error {
+	host, err := m.sameHost(fromNodeID, toNodeID)
+	if err != nil {
+		return err
+	}
+
+	return host.Containers.CopyServerData(ctx, m.cfg.HelperImage, serverID, fromNodeID, toNodeID)
+}
+
+// RemoveServerData deletes a server's data from a node's volume
+func (m *Manager) RemoveServerData(ctx context.Context, nodeID, serverID string) error {
+	host, err := m.nodeHost(nodeID)
+	if err != nil {
+		return err
+	}
+
+	return host.Containers.RemoveServerData(ctx, m.cfg.HelperImage, nodeID, serverID)
+}
+
+// CopyServerWorld copies a server's world into another server's data
+// directory. Both nodes must be on the same host.
+func (m *Manager) CopyServerWorld(ctx context.Context, world, fromNodeID, fromServerID, toNodeID, toServerID string) error {
+	host, err := m.sameHost(fromNodeID, toNodeID)
+	if err != nil {
+		return err
+	}
+
+	return host.Containers.CopyServerWorld(ctx, m.cfg.HelperImage, world, fromNodeID, fromServerID, toNodeID, toServerID)
+}
+
+// SaveTemplateWorld archives a server's world for a template, on the host of
+// the server's node
+func (m *Manager) SaveTemplateWorld(ctx context.Context, world, nodeID, serverID, templateID string) error {
+	host, err := m.nodeHost(nodeID)
+	if err != nil {
+		return err
 	}
 
-	return m.containerMgr.GetNodeContainerInfo(ctx, nodeID)
+	return host.Containers.SaveTemplateWorld(ctx, m.cfg.HelperImage, world, nodeID, serverID, templateID)
+}
+
+// RestoreTemplateWorld extracts a template's world into a server's data
+// directory. The archive must be on the node's host.
+func (m *Manager) RestoreTemplateWorld(ctx context.Context, templateID, nodeID, serverID string) error {
+	host, err := m.nodeHost(nodeID)
+	if err != nil {
+		return err
+	}
+
+	return host.Containers.RestoreTemplateWorld(ctx, m.cfg.HelperImage, templateID, nodeID, serverID)
+}
+
+// RemoveTemplateWorld deletes a template's world archive from every host,
+// since it is not recorded which host it was saved on
+func (m *Manager) RemoveTemplateWorld(ctx context.Context, templateID string) error {
+	hosts := m.hosts.List()
+	if len(hosts) == 0 {
+		return fmt.Errorf("no container hosts connected")
+	}
+
+	var errs []error
+	for _, host := range hosts {
+		if err := host.Containers.RemoveTemplateWorld(ctx, m.cfg.HelperImage, templateID); err != nil {
+			errs = append(errs, fmt.Errorf("host %s: %w", host.ID, err))
+		}
+	}
+	return errors.Join(errs...)
 }
 
 // GetNode retrieves a node by ID
@@ -373,6 +936,10 @@ func (m *Manager) UpdateNodeMetrics(nodeID string, metrics *models.NodeMetrics)
 		return fmt.Errorf("node not found: %s", nodeID)
 	}
 
+	metrics.Source = models.MetricsSourceAgent
+	if metrics.Timestamp.IsZero() {
+		metrics.Timestamp = time.Now()
+	}
 	state.Metrics = metrics
 	state.LastHeartbeat = time.Now()
 
@@ -397,6 +964,24 @@ func (m *Manager) SendCommand(nodeID string, cmd *Command) error {
 	}
 }
 
+// NodeSupports reports whether a connected node's agent supports a capability
+func (m *Manager) NodeSupports(nodeID string, capability string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	state, exists := m.nodes[nodeID]
+	if !exists || !state.Connected {
+		return false
+	}
+
+	for _, c := range state.Node.Capabilities {
+		if c == capability {
+			return true
+		}
+	}
+	return false
+}
+
 // HandleNodeEvent handles an event from a node
 func (m *Manager) HandleNodeEvent(event *StreamEvent) {
 	m.mu.RLock()
@@ -454,7 +1039,8 @@ func (m *Manager) UnsubscribeFromEvents(ch <-chan *StreamEvent) {
 	}
 }
 
-// GetNodeMetrics retrieves the latest metrics for a node
+// GetNodeMetrics retrieves the latest metrics for a node, from its agent or,
+// when those are stale, from its container's stats
 func (m *Manager) GetNodeMetrics(nodeID string) (*models.NodeMetrics, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
@@ -464,7 +1050,7 @@ func (m *Manager) GetNodeMetrics(nodeID string) (*models.NodeMetrics, error) {
 		return nil, fmt.Errorf("node not found: %s", nodeID)
 	}
 
-	return state.Metrics, nil
+	return m.currentMetrics(state, time.Now()), nil
 }
 
 // GetClusterMetrics retrieves aggregated metrics for all nodes
@@ -479,9 +1065,12 @@ func (m *Manager) GetClusterMetrics() (*ClusterMetrics, error) {
 	}
 
 	for _, state := range m.nodes {
-		if state.Node.Status == models.NodeStatusOnline {
+		switch state.Node.Status {
+		case models.NodeStatusOnline:
 			metrics.OnlineNodes++
-		} else {
+		case models.NodeStatusReconnecting:
+			metrics.ReconnectingNodes++
+		default:
 			metrics.OfflineNodes++
 		}
 	}
@@ -491,9 +1080,10 @@ func (m *Manager) GetClusterMetrics() (*ClusterMetrics, error) {
 
 // ClusterMetrics represents aggregated cluster metrics
 type ClusterMetrics struct {
-	TotalNodes   int
-	OnlineNodes  int
-	OfflineNodes int
+	TotalNodes        int
+	OnlineNodes       int
+	OfflineNodes      int
+	ReconnectingNodes int // recovered at startup, waiting for their agent
 }
 
 // StartHealthCheck starts periodic health checks for all nodes
@@ -511,10 +1101,13 @@ func (m *Manager) StartHealthCheck(ctx context.Context) {
 	}
 }
 
-// checkNodeHealth checks the health of all nodes
+// checkNodeHealth checks the health of all nodes. A node whose agent
+// reports no metrics is judged by its container's stats instead. Draining
+// and maintenance are kept, and an unhealthy node goes back online once it
+// recovers.
 func (m *Manager) checkNodeHealth() {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+	m.mu.Lock()
+	defer m.mu.Unlock()
 
 	timeout := m.cfg.GetNodeTimeout()
 	now := time.Now()
@@ -525,10 +1118,48 @@ func (m *Manager) checkNodeHealth() {
 		}
 
 		if now.Sub(state.LastHeartbeat) > timeout {
+			if !state.HeartbeatTimedOut {
+				state.HeartbeatTimedOut = true
+				m.logger.Warn("Node heartbeat timeout",
+					zap.String("node_id", state.Node.ID),
+					zap.String("name", state.Node.Name),
+					zap.String("status", string(state.Node.Status)))
+			}
+			if !isOperatorStatus(state.Node.Status) {
+				state.Node.Status = models.NodeStatusUnhealthy
+			}
+			continue
+		}
+
+		if state.HeartbeatTimedOut {
+			state.HeartbeatTimedOut = false
+			if state.Node.Status == models.NodeStatusUnhealthy && !state.ContainerUnhealthy {
+				state.Node.Status = models.NodeStatusOnline
+				m.logger.Info("Node heartbeat recovered",
+					zap.String("node_id", state.Node.ID),
+					zap.String("name", state.Node.Name))
+			}
+		}
+
+		overloaded := m.agentMetricsStale(state, now) && m.containerOverloaded(state, now)
+		switch {
+		case overloaded && state.Node.Status == models.NodeStatusOnline:
 			state.Node.Status = models.NodeStatusUnhealthy
-			m.logger.Warn("Node heartbeat timeout",
+			state.ContainerUnhealthy = true
+			m.logger.Warn("Node container overloaded with stale agent metrics",
 				zap.String("node_id", state.Node.ID),
-				zap.String("name", state.Node.Name))
+				zap.String("name", state.Node.Name),
+				zap.Float64("cpu_usage_percent", state.ContainerMetrics.CPUUsagePercent),
+				zap.Float64("memory_usage_percent", state.ContainerMetrics.MemoryUsagePercent))
+
+		case !overloaded && state.ContainerUnhealthy:
+			state.ContainerUnhealthy = false
+			if state.Node.Status == models.NodeStatusUnhealthy {
+				state.Node.Status = models.NodeStatusOnline
+				m.logger.Info("Node container recovered",
+					zap.String("node_id", state.Node.ID),
+					zap.String("name", state.Node.Name))
+			}
 		}
 	}
 }
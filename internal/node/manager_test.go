@@ -0,0 +1,81 @@
+package node_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/game-server/controller/internal/core/models"
+	"github.com/game-server/controller/internal/core/repository/repositorytest"
+	"github.com/game-server/controller/internal/docker"
+	"github.com/game-server/controller/internal/docker/dockertest"
+	"github.com/game-server/controller/internal/node"
+	"github.com/game-server/controller/pkg/config"
+	"go.uber.org/zap"
+)
+
+func TestDeleteNodeRemovesContainerVolumesAndServers(t *testing.T) {
+	ctx := context.Background()
+
+	runtime := dockertest.NewRuntime()
+	hosts := docker.NewHosts(zap.NewNop())
+	hosts.Add(&docker.Host{ID: models.LocalHostID, Runtime: runtime})
+
+	nodes := repositorytest.NewNodes(
+		&models.Node{ID: "node-1", Name: "node-1", HostID: models.LocalHostID},
+		&models.Node{ID: "node-2", Name: "node-2", HostID: models.LocalHostID},
+	)
+	servers := repositorytest.NewServers(
+		&models.Server{ID: "server-1", NodeID: "node-1"},
+		&models.Server{ID: "server-2", NodeID: "node-1"},
+		&models.Server{ID: "server-3", NodeID: "node-2"},
+	)
+	mgr := node.NewManager(nodes, servers, hosts, &config.Config{NodeAgentImage: "node:test"}, zap.NewNop())
+
+	for _, nodeID := range []string{"node-1", "node-2"} {
+		n, err := mgr.GetNode(nodeID)
+		if err != nil {
+			t.Fatalf("GetNode(%s): %v", nodeID, err)
+		}
+		if _, err := mgr.CreateNodeContainer(ctx, mgr.NodeContainerConfig(n)); err != nil {
+			t.Fatalf("CreateNodeContainer(%s): %v", nodeID, err)
+		}
+	}
+
+	if err := mgr.DeleteNode(ctx, "node-1"); err != nil {
+		t.Fatalf("DeleteNode: %v", err)
+	}
+
+	if n, _ := nodes.GetByID(ctx, "node-1"); n != nil {
+		t.Errorf("node-1 is still stored")
+	}
+	if ids := servers.ByNodeID("node-1"); len(ids) != 0 {
+		t.Errorf("servers of node-1 after DeleteNode = %v, want none", ids)
+	}
+	if ids := servers.ByNodeID("node-2"); len(ids) != 1 {
+		t.Errorf("servers of node-2 after DeleteNode = %v, want server-3", ids)
+	}
+
+	containers := runtime.Containers()
+	if len(containers) != 1 || containers[0].Labels["game-server.node-id"] != "node-2" {
+		t.Errorf("containers after DeleteNode = %+v, want only node-2's", containers)
+	}
+	want := []string{
+		"game-server-node-node-2-backups",
+		"game-server-node-node-2-logs",
+		"game-server-node-node-2-servers",
+	}
+	if volumes := runtime.Volumes(); !reflect.DeepEqual(volumes, want) {
+		t.Errorf("volumes after DeleteNode = %v, want %v", volumes, want)
+	}
+}
+
+func TestDeleteNodeUnknown(t *testing.T) {
+	hosts := docker.NewHosts(zap.NewNop())
+	hosts.Add(&docker.Host{ID: models.LocalHostID, Runtime: dockertest.NewRuntime()})
+	mgr := node.NewManager(repositorytest.NewNodes(), repositorytest.NewServers(), hosts, &config.Config{}, zap.NewNop())
+
+	if err := mgr.DeleteNode(context.Background(), "missing"); err == nil {
+		t.Fatal("DeleteNode of an unknown node succeeded")
+	}
+}
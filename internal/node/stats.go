@@ -0,0 +1,191 @@
+package node
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/game-server/controller/internal/core/models"
+	"github.com/game-server/controller/internal/docker"
+	"go.uber.org/zap"
+)
+
+// containerOverloadPercent is the CPU or memory usage of a node container,
+// relative to its limits, at which a node without fresh agent metrics is
+// unhealthy
+const containerOverloadPercent = 95
+
+// containerStatsTimeout bounds a single stats sample. Docker takes about a
+// second to measure CPU usage.
+const containerStatsTimeout = 10 * time.Second
+
+// RunContainerStats samples the stats of every running node container on
+// every host each container stats interval until ctx is cancelled. The
+// samples stand in for agent metrics when those are stale.
+func (m *Manager) RunContainerStats(ctx context.Context) {
+	interval := m.cfg.GetContainerStatsInterval()
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.sampleContainerStats(ctx)
+		}
+	}
+}
+
+// sampleContainerStats samples the running node containers of all hosts
+// concurrently
+func (m *Manager) sampleContainerStats(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, host := range m.hosts.List() {
+		containers, err := host.Containers.ListNodeContainers(ctx)
+		if err != nil {
+			m.logger.Warn("Failed to list node containers for stats",
+				zap.Error(err),
+				zap.String("host_id", host.ID))
+			continue
+		}
+
+		hostCPUs := m.hostCPUs(ctx, host)
+		for _, c := range containers {
+			if c.NodeID == "" {
+				continue
+			}
+			wg.Add(1)
+			go func(host *docker.Host, c *docker.ContainerInfo) {
+				defer wg.Done()
+				m.sampleContainer(ctx, host, c, hostCPUs)
+			}(host, c)
+		}
+	}
+	wg.Wait()
+}
+
+// hostCPUs returns the number of CPUs of a host, or 0 if its runtime cannot
+// tell
+func (m *Manager) hostCPUs(ctx context.Context, host *docker.Host) int {
+	ctx, cancel := context.WithTimeout(ctx, containerStatsTimeout)
+	defer cancel()
+
+	info, err := host.Runtime.Info(ctx)
+	if err != nil {
+		m.logger.Debug("Failed to get host info for container stats",
+			zap.Error(err),
+			zap.String("host_id", host.ID))
+		return 0
+	}
+	return info.CPUs
+}
+
+// sampleContainer samples one node container and records the result as the
+// node's container metrics. A container without a CPU limit can use all of
+// its host's CPUs.
+func (m *Manager) sampleContainer(ctx context.Context, host *docker.Host, c *docker.ContainerInfo, hostCPUs int) {
+	ctx, cancel := context.WithTimeout(ctx, containerStatsTimeout)
+	defer cancel()
+
+	stats, err := host.Containers.ContainerStats(ctx, c.ID)
+	if err != nil {
+		m.logger.Debug("Failed to sample node container stats",
+			zap.Error(err),
+			zap.String("node_id", c.NodeID),
+			zap.String("container_id", c.ID))
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	state, exists := m.nodes[c.NodeID]
+	if !exists || state.Node.HostID != host.ID {
+		return
+	}
+	cpus := state.Node.CPUCores
+	if cpus <= 0 {
+		cpus = hostCPUs
+	}
+	state.ContainerMetrics = containerMetrics(state.Node, stats, cpus)
+	state.ContainerCPUs = cpus
+}
+
+// containerMetrics turns a stats sample of a node's container into node
+// metrics. CPU usage is relative to the container's cpus, like the agent
+// reports it, and stays a percentage of one CPU if cpus is 0.
+func containerMetrics(node *models.Node, stats *docker.ContainerStats, cpus int) *models.NodeMetrics {
+	metrics := &models.NodeMetrics{
+		NodeID:           node.ID,
+		CPUUsagePercent:  stats.CPUPercent,
+		NetworkInBytes:   stats.NetworkRxBytes,
+		NetworkOutBytes:  stats.NetworkTxBytes,
+		MemoryUsageBytes: stats.MemoryUsageBytes,
+		MemoryLimitBytes: stats.MemoryLimitBytes,
+		BlockReadBytes:   stats.BlockReadBytes,
+		BlockWriteBytes:  stats.BlockWriteBytes,
+		Source:           models.MetricsSourceContainer,
+		Timestamp:        time.Now(),
+	}
+	if cpus > 0 {
+		metrics.CPUUsagePercent /= float64(cpus)
+	}
+	if stats.MemoryLimitBytes > 0 {
+		metrics.MemoryUsagePercent = float64(stats.MemoryUsageBytes) / float64(stats.MemoryLimitBytes) * 100
+	}
+	return metrics
+}
+
+// currentMetrics returns a node's metrics: the agent's while they are fresh,
+// with the container's memory and block I/O added, and the latest container
+// sample otherwise. m.mu must be held.
+func (m *Manager) currentMetrics(state *NodeState, now time.Time) *models.NodeMetrics {
+	agent, container := state.Metrics, state.ContainerMetrics
+
+	if agent != nil && !m.agentMetricsStale(state, now) {
+		merged := *agent
+		if container != nil {
+			merged.MemoryUsageBytes = container.MemoryUsageBytes
+			merged.MemoryLimitBytes = container.MemoryLimitBytes
+			merged.BlockReadBytes = container.BlockReadBytes
+			merged.BlockWriteBytes = container.BlockWriteBytes
+		}
+		return &merged
+	}
+
+	latest := container
+	if latest == nil || (agent != nil && agent.Timestamp.After(latest.Timestamp)) {
+		latest = agent
+	}
+	if latest == nil {
+		return nil
+	}
+	result := *latest
+	return &result
+}
+
+// agentMetricsStale reports whether a node's agent has not reported metrics
+// within the node timeout. m.mu must be held.
+func (m *Manager) agentMetricsStale(state *NodeState, now time.Time) bool {
+	return state.Metrics == nil || now.Sub(state.Metrics.Timestamp) > m.cfg.GetNodeTimeout()
+}
+
+// containerOverloaded reports whether a node's latest container sample is
+// recent and shows the container at its CPU or memory limit. CPU is only
+// checked when the number of CPUs it is relative to is known. m.mu must be
+// held.
+func (m *Manager) containerOverloaded(state *NodeState, now time.Time) bool {
+	metrics := state.ContainerMetrics
+	if metrics == nil || now.Sub(metrics.Timestamp) > 2*m.cfg.GetContainerStatsInterval() {
+		return false
+	}
+	if state.ContainerCPUs > 0 && metrics.CPUUsagePercent >= containerOverloadPercent {
+		return true
+	}
+	return metrics.MemoryUsagePercent >= containerOverloadPercent
+}
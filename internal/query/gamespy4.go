@@ -0,0 +1,145 @@
+package query
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+	"net"
+	"strconv"
+	"time"
+)
+
+// GameSpy4 packet types
+const (
+	gameSpy4TypeHandshake byte = 0x09
+	gameSpy4TypeStat      byte = 0x00
+)
+
+var gameSpy4Magic = []byte{0xFE, 0xFD}
+
+// QueryGameSpy4 queries a server with the GameSpy4 (UT3) query protocol, as
+// implemented by Minecraft when enable-query is set
+func QueryGameSpy4(host string, port int, timeout time.Duration) (*Result, error) {
+	address := net.JoinHostPort(host, strconv.Itoa(port))
+	conn, err := net.DialTimeout("udp", address, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("gamespy4: failed to connect to %s: %w", address, err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	sessionID := rand.Int31() & 0x0F0F0F0F
+	start := time.Now()
+
+	// Handshake to obtain a challenge token
+	if _, err := conn.Write(gameSpy4Request(gameSpy4TypeHandshake, sessionID, nil)); err != nil {
+		return nil, fmt.Errorf("gamespy4: failed to send handshake: %w", err)
+	}
+
+	buf := make([]byte, 65535)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return nil, fmt.Errorf("gamespy4: failed to read handshake: %w", err)
+	}
+	latency := time.Since(start)
+
+	body, err := gameSpy4Body(buf[:n], gameSpy4TypeHandshake, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	challenge, err := strconv.ParseInt(string(bytes.TrimRight(body, "\x00")), 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("gamespy4: invalid challenge token: %w", err)
+	}
+
+	// Full stat request: challenge token followed by four bytes of padding
+	payload := make([]byte, 8)
+	binary.BigEndian.PutUint32(payload, uint32(int32(challenge)))
+	if _, err := conn.Write(gameSpy4Request(gameSpy4TypeStat, sessionID, payload)); err != nil {
+		return nil, fmt.Errorf("gamespy4: failed to send stat request: %w", err)
+	}
+
+	n, err = conn.Read(buf)
+	if err != nil {
+		return nil, fmt.Errorf("gamespy4: failed to read stat response: %w", err)
+	}
+
+	body, err = gameSpy4Body(buf[:n], gameSpy4TypeStat, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := parseFullStat(body)
+	if err != nil {
+		return nil, err
+	}
+	result.Latency = latency
+
+	return result, nil
+}
+
+func gameSpy4Request(packetType byte, sessionID int32, payload []byte) []byte {
+	var buf bytes.Buffer
+	buf.Write(gameSpy4Magic)
+	buf.WriteByte(packetType)
+	binary.Write(&buf, binary.BigEndian, sessionID)
+	buf.Write(payload)
+	return buf.Bytes()
+}
+
+// gameSpy4Body validates the response header and returns the body
+func gameSpy4Body(data []byte, packetType byte, sessionID int32) ([]byte, error) {
+	if len(data) < 5 {
+		return nil, fmt.Errorf("gamespy4: response too short")
+	}
+	if data[0] != packetType {
+		return nil, fmt.Errorf("gamespy4: unexpected response type %d", data[0])
+	}
+	if int32(binary.BigEndian.Uint32(data[1:5])) != sessionID {
+		return nil, fmt.Errorf("gamespy4: session ID mismatch")
+	}
+	return data[5:], nil
+}
+
+// parseFullStat parses the key/value section and the player list of a full
+// stat response
+func parseFullStat(body []byte) (*Result, error) {
+	// Skip the constant "splitnum\x00\x80\x00" padding
+	const kvPadding = 11
+	if len(body) < kvPadding {
+		return nil, fmt.Errorf("gamespy4: stat response too short")
+	}
+	body = body[kvPadding:]
+
+	sections := bytes.SplitN(body, []byte("\x00\x00\x01player_\x00\x00"), 2)
+
+	values := make(map[string]string)
+	fields := bytes.Split(sections[0], []byte{0})
+	for i := 0; i+1 < len(fields); i += 2 {
+		if len(fields[i]) == 0 {
+			break
+		}
+		values[string(fields[i])] = string(fields[i+1])
+	}
+
+	players := []string{}
+	if len(sections) == 2 {
+		for _, name := range bytes.Split(sections[1], []byte{0}) {
+			if len(name) > 0 {
+				players = append(players, string(name))
+			}
+		}
+	}
+
+	result := &Result{
+		Protocol:     ProtocolGameSpy4,
+		MOTD:         values["hostname"],
+		Version:      values["version"],
+		PlayerSample: players,
+	}
+	result.OnlinePlayers, _ = strconv.Atoi(values["numplayers"])
+	result.MaxPlayers, _ = strconv.Atoi(values["maxplayers"])
+
+	return result, nil
+}
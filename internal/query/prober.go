@@ -0,0 +1,272 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/game-server/controller/internal/core/models"
+	"github.com/game-server/controller/internal/core/repository"
+	"github.com/game-server/controller/internal/minecraft"
+	"github.com/game-server/controller/internal/node"
+	"github.com/game-server/controller/pkg/config"
+	"go.uber.org/zap"
+)
+
+// Protocol identifies the query protocol used to probe a server
+type Protocol string
+
+const (
+	ProtocolNone      Protocol = "none"
+	ProtocolMinecraft Protocol = "minecraft"
+	ProtocolGameSpy4  Protocol = "gamespy4"
+)
+
+// Result holds the information returned by a game query
+type Result struct {
+	Protocol      Protocol      `json:"protocol"`
+	MOTD          string        `json:"motd"`
+	Version       string        `json:"version"`
+	OnlinePlayers int           `json:"online_players"`
+	MaxPlayers    int           `json:"max_players"`
+	PlayerSample  []string      `json:"player_sample"`
+	Latency       time.Duration `json:"-"`
+}
+
+// ProbeResult is the latest probe of a server
+type ProbeResult struct {
+	ServerID            string              `json:"server_id"`
+	Protocol            Protocol            `json:"protocol"`
+	Responsive          bool                `json:"responsive"`
+	Error               string              `json:"error,omitempty"`
+	MOTD                string              `json:"motd"`
+	Version             string              `json:"version"`
+	PlayerCount         int                 `json:"player_count"`
+	MaxPlayers          int                 `json:"max_players"`
+	PlayerSample        []string            `json:"player_sample"`
+	LatencyMS           float64             `json:"latency_ms"`
+	AgentStatus         models.ServerStatus `json:"agent_status"`
+	ConsecutiveFailures int                 `json:"consecutive_failures"`
+	// Unresponsive is set when the agent reports the server as running but
+	// it has failed to answer several probes in a row
+	Unresponsive bool      `json:"unresponsive"`
+	ProbedAt     time.Time `json:"probed_at"`
+}
+
+// IsValidQueryType reports whether queryType can be stored on a server. An
+// empty query type selects the default protocol for the game type.
+func IsValidQueryType(queryType string) bool {
+	switch Protocol(queryType) {
+	case "", ProtocolNone, ProtocolMinecraft, ProtocolGameSpy4:
+		return true
+	}
+	return false
+}
+
+// ProtocolFor returns the query protocol for a server, based on its
+// configured query type and game type
+func ProtocolFor(server *models.Server) Protocol {
+	switch Protocol(server.QueryType) {
+	case ProtocolNone, ProtocolMinecraft, ProtocolGameSpy4:
+		return Protocol(server.QueryType)
+	}
+	if server.GameType == minecraft.GameType {
+		return ProtocolMinecraft
+	}
+	return ProtocolNone
+}
+
+// Probe queries a server once using its query protocol, at host
+func Probe(server *models.Server, host string, timeout time.Duration) (*Result, error) {
+	switch ProtocolFor(server) {
+	case ProtocolMinecraft:
+		if server.Port == 0 {
+			return nil, fmt.Errorf("server has no port assigned")
+		}
+		return PingMinecraft(host, server.Port, timeout)
+	case ProtocolGameSpy4:
+		port := server.QueryPort
+		if port == 0 {
+			port = server.Port
+		}
+		if port == 0 {
+			return nil, fmt.Errorf("server has no query port assigned")
+		}
+		return QueryGameSpy4(host, port, timeout)
+	default:
+		return nil, fmt.Errorf("server has no query protocol")
+	}
+}
+
+// Prober periodically probes running servers independently of agent reports
+type Prober struct {
+	serverRepo *repository.ServerRepository
+	nodeMgr    *node.Manager // resolves the address servers listen on
+	cfg        *config.Config
+	logger     *zap.Logger
+
+	results map[string]*ProbeResult
+	mu      sync.RWMutex
+}
+
+// NewProber creates a new prober
+func NewProber(serverRepo *repository.ServerRepository, nodeMgr *node.Manager, cfg *config.Config, logger *zap.Logger) *Prober {
+	return &Prober{
+		serverRepo: serverRepo,
+		nodeMgr:    nodeMgr,
+		cfg:        cfg,
+		logger:     logger,
+		results:    make(map[string]*ProbeResult),
+	}
+}
+
+// Run probes all servers every probe interval until ctx is cancelled
+func (p *Prober) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.cfg.GetQueryProbeInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.probeAll(ctx)
+		}
+	}
+}
+
+// probeAll probes every running server concurrently
+func (p *Prober) probeAll(ctx context.Context) {
+	servers, err := p.serverRepo.List(ctx, &models.ServerFilters{Status: models.ServerStatusRunning})
+	if err != nil {
+		p.logger.Error("Failed to list servers for probing", zap.Error(err))
+		return
+	}
+
+	// Servers on the same node share its address, resolved once per round
+	type nodeAddress struct {
+		host string
+		err  error
+	}
+	addresses := make(map[string]nodeAddress)
+
+	running := make(map[string]bool, len(servers))
+	var wg sync.WaitGroup
+	for _, server := range servers {
+		if ProtocolFor(server) == ProtocolNone {
+			continue
+		}
+		running[server.ID] = true
+
+		address, resolved := addresses[server.NodeID]
+		if !resolved {
+			address.host, address.err = p.nodeMgr.NodeAddress(ctx, server.NodeID)
+			addresses[server.NodeID] = address
+		}
+
+		wg.Add(1)
+		go func(server *models.Server, address nodeAddress) {
+			defer wg.Done()
+			p.probe(server, address.host, address.err)
+		}(server, address)
+	}
+	wg.Wait()
+
+	// Forget servers that are no longer running
+	p.mu.Lock()
+	for id := range p.results {
+		if !running[id] {
+			delete(p.results, id)
+		}
+	}
+	p.mu.Unlock()
+}
+
+// ProbeServer probes a single server and records the result
+func (p *Prober) ProbeServer(ctx context.Context, server *models.Server) *ProbeResult {
+	host, err := p.nodeMgr.NodeAddress(ctx, server.NodeID)
+	return p.probe(server, host, err)
+}
+
+// probe probes a server at host and records the result. A failure to resolve
+// the host counts as a failed probe.
+func (p *Prober) probe(server *models.Server, host string, resolveErr error) *ProbeResult {
+	var result *Result
+	err := resolveErr
+	if err != nil {
+		err = fmt.Errorf("failed to resolve node address: %w", err)
+	} else {
+		result, err = Probe(server, host, p.cfg.GetQueryProbeTimeout())
+	}
+
+	probe := &ProbeResult{
+		ServerID:     server.ID,
+		Protocol:     ProtocolFor(server),
+		Responsive:   err == nil,
+		AgentStatus:  server.Status,
+		PlayerSample: []string{},
+		ProbedAt:     time.Now(),
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	previous := p.results[server.ID]
+	if err != nil {
+		probe.Error = err.Error()
+		if previous != nil {
+			probe.ConsecutiveFailures = previous.ConsecutiveFailures
+		}
+		probe.ConsecutiveFailures++
+	} else {
+		probe.MOTD = result.MOTD
+		probe.Version = result.Version
+		probe.PlayerCount = result.OnlinePlayers
+		probe.MaxPlayers = result.MaxPlayers
+		probe.PlayerSample = result.PlayerSample
+		probe.LatencyMS = float64(result.Latency.Microseconds()) / 1000
+	}
+
+	probe.Unresponsive = server.Status == models.ServerStatusRunning &&
+		probe.ConsecutiveFailures >= p.cfg.QueryFailureThreshold
+
+	if probe.Unresponsive && (previous == nil || !previous.Unresponsive) {
+		p.logger.Warn("Server reported running but does not answer queries",
+			zap.String("server_id", server.ID),
+			zap.String("protocol", string(probe.Protocol)),
+			zap.Int("consecutive_failures", probe.ConsecutiveFailures),
+			zap.String("error", probe.Error))
+	}
+
+	p.results[server.ID] = probe
+	return probe
+}
+
+// Get returns the latest probe result for a server
+func (p *Prober) Get(serverID string) (*ProbeResult, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	result, exists := p.results[serverID]
+	return result, exists
+}
+
+// List returns the latest probe results, optionally only unresponsive servers
+func (p *Prober) List(unresponsiveOnly bool) []*ProbeResult {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	results := make([]*ProbeResult, 0, len(p.results))
+	for _, result := range p.results {
+		if unresponsiveOnly && !result.Unresponsive {
+			continue
+		}
+		results = append(results, result)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].ServerID < results[j].ServerID
+	})
+	return results
+}
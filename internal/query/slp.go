@@ -0,0 +1,231 @@
+package query
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxSLPPacketSize bounds the size of a status response
+const maxSLPPacketSize = 1 << 21
+
+// PingMinecraft queries a Minecraft Java Edition server with the Server List
+// Ping protocol
+func PingMinecraft(host string, port int, timeout time.Duration) (*Result, error) {
+	address := net.JoinHostPort(host, strconv.Itoa(port))
+	conn, err := net.DialTimeout("tcp", address, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("slp: failed to connect to %s: %w", address, err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	// Handshake with next state 1 (status), followed by a status request
+	var handshake bytes.Buffer
+	writeVarInt(&handshake, 0x00)
+	writeVarInt(&handshake, -1) // protocol version, -1 when only querying status
+	writeString(&handshake, host)
+	binary.Write(&handshake, binary.BigEndian, uint16(port))
+	writeVarInt(&handshake, 1)
+
+	if err := writePacket(conn, handshake.Bytes()); err != nil {
+		return nil, fmt.Errorf("slp: failed to send handshake: %w", err)
+	}
+	if err := writePacket(conn, []byte{0x00}); err != nil {
+		return nil, fmt.Errorf("slp: failed to send status request: %w", err)
+	}
+
+	reader := bufio.NewReader(conn)
+	packet, err := readPacket(reader)
+	if err != nil {
+		return nil, fmt.Errorf("slp: failed to read status response: %w", err)
+	}
+
+	payload := bytes.NewReader(packet)
+	if id, err := readVarInt(payload); err != nil || id != 0x00 {
+		return nil, fmt.Errorf("slp: unexpected status response packet")
+	}
+	statusJSON, err := readString(payload)
+	if err != nil {
+		return nil, fmt.Errorf("slp: failed to read status: %w", err)
+	}
+
+	result, err := parseStatus(statusJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	// Measure latency with a ping/pong round trip
+	var ping bytes.Buffer
+	writeVarInt(&ping, 0x01)
+	sent := time.Now()
+	binary.Write(&ping, binary.BigEndian, sent.UnixNano())
+	if err := writePacket(conn, ping.Bytes()); err != nil {
+		return nil, fmt.Errorf("slp: failed to send ping: %w", err)
+	}
+	if _, err := readPacket(reader); err != nil {
+		return nil, fmt.Errorf("slp: failed to read pong: %w", err)
+	}
+	result.Latency = time.Since(sent)
+
+	return result, nil
+}
+
+// statusResponse is the JSON document returned by Server List Ping
+type statusResponse struct {
+	Version struct {
+		Name     string `json:"name"`
+		Protocol int    `json:"protocol"`
+	} `json:"version"`
+	Players struct {
+		Max    int `json:"max"`
+		Online int `json:"online"`
+		Sample []struct {
+			Name string `json:"name"`
+			ID   string `json:"id"`
+		} `json:"sample"`
+	} `json:"players"`
+	Description json.RawMessage `json:"description"`
+}
+
+func parseStatus(data string) (*Result, error) {
+	var status statusResponse
+	if err := json.Unmarshal([]byte(data), &status); err != nil {
+		return nil, fmt.Errorf("slp: invalid status JSON: %w", err)
+	}
+
+	result := &Result{
+		Protocol:      ProtocolMinecraft,
+		MOTD:          parseDescription(status.Description),
+		Version:       status.Version.Name,
+		OnlinePlayers: status.Players.Online,
+		MaxPlayers:    status.Players.Max,
+		PlayerSample:  make([]string, 0, len(status.Players.Sample)),
+	}
+	for _, p := range status.Players.Sample {
+		result.PlayerSample = append(result.PlayerSample, p.Name)
+	}
+
+	return result, nil
+}
+
+// chatComponent is a Minecraft text component as used in the MOTD
+type chatComponent struct {
+	Text  string          `json:"text"`
+	Extra []chatComponent `json:"extra"`
+}
+
+func (c *chatComponent) flatten(b *strings.Builder) {
+	b.WriteString(c.Text)
+	for i := range c.Extra {
+		c.Extra[i].flatten(b)
+	}
+}
+
+// parseDescription flattens the description, which is either a plain string
+// or a chat component
+func parseDescription(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return text
+	}
+
+	var component chatComponent
+	if err := json.Unmarshal(raw, &component); err != nil {
+		return ""
+	}
+
+	var b strings.Builder
+	component.flatten(&b)
+	return b.String()
+}
+
+// Minecraft protocol helpers
+
+func writePacket(w io.Writer, data []byte) error {
+	var buf bytes.Buffer
+	writeVarInt(&buf, int32(len(data)))
+	buf.Write(data)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func readPacket(r io.ByteReader) ([]byte, error) {
+	length, err := readVarInt(r)
+	if err != nil {
+		return nil, err
+	}
+	if length < 0 || length > maxSLPPacketSize {
+		return nil, fmt.Errorf("invalid packet length %d", length)
+	}
+
+	data := make([]byte, length)
+	for i := range data {
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		data[i] = b
+	}
+	return data, nil
+}
+
+func writeVarInt(buf *bytes.Buffer, value int32) {
+	v := uint32(value)
+	for {
+		if v&^0x7F == 0 {
+			buf.WriteByte(byte(v))
+			return
+		}
+		buf.WriteByte(byte(v&0x7F | 0x80))
+		v >>= 7
+	}
+}
+
+func readVarInt(r io.ByteReader) (int32, error) {
+	var result uint32
+	for i := 0; i < 5; i++ {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		result |= uint32(b&0x7F) << (7 * i)
+		if b&0x80 == 0 {
+			return int32(result), nil
+		}
+	}
+	return 0, errors.New("varint too long")
+}
+
+func writeString(buf *bytes.Buffer, s string) {
+	writeVarInt(buf, int32(len(s)))
+	buf.WriteString(s)
+}
+
+func readString(r *bytes.Reader) (string, error) {
+	length, err := readVarInt(r)
+	if err != nil {
+		return "", err
+	}
+	if length < 0 || int(length) > r.Len() {
+		return "", fmt.Errorf("invalid string length %d", length)
+	}
+
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,186 @@
+package rcon
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Packet types of the Source RCON protocol
+const (
+	PacketTypeResponseValue int32 = 0
+	PacketTypeExecCommand   int32 = 2
+	PacketTypeAuthResponse  int32 = 2
+	PacketTypeAuth          int32 = 3
+)
+
+const (
+	// MaxCommandLength is the longest command Minecraft accepts
+	MaxCommandLength = 1446
+
+	// maxPacketSize bounds the size field of incoming packets
+	maxPacketSize = 4096 + 10
+
+	// headerSize is the size of the id and type fields
+	headerSize = 8
+)
+
+// ErrAuthFailed is returned when the server rejects the password
+var ErrAuthFailed = errors.New("rcon: authentication failed")
+
+// Packet is a single RCON packet
+type Packet struct {
+	ID   int32
+	Type int32
+	Body string
+}
+
+// Client is an authenticated RCON connection
+type Client struct {
+	conn    net.Conn
+	timeout time.Duration
+	nextID  int32
+	mu      sync.Mutex
+}
+
+// Dial connects to an RCON server and authenticates with password
+func Dial(address, password string, timeout time.Duration) (*Client, error) {
+	conn, err := net.DialTimeout("tcp", address, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("rcon: failed to connect to %s: %w", address, err)
+	}
+
+	c := &Client{
+		conn:    conn,
+		timeout: timeout,
+		nextID:  1,
+	}
+
+	if err := c.authenticate(password); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// authenticate sends the auth packet and waits for the auth response
+func (c *Client) authenticate(password string) error {
+	id := c.allocateID()
+	c.conn.SetDeadline(time.Now().Add(c.timeout))
+
+	if err := WritePacket(c.conn, &Packet{ID: id, Type: PacketTypeAuth, Body: password}); err != nil {
+		return fmt.Errorf("rcon: failed to send auth: %w", err)
+	}
+
+	// Source servers send an empty response value before the auth response
+	for {
+		p, err := ReadPacket(c.conn)
+		if err != nil {
+			return fmt.Errorf("rcon: failed to read auth response: %w", err)
+		}
+		if p.Type != PacketTypeAuthResponse {
+			continue
+		}
+		if p.ID == -1 || p.ID != id {
+			return ErrAuthFailed
+		}
+		return nil
+	}
+}
+
+// Execute runs a command and returns the response text. Responses split over
+// several packets are reassembled by sending a trailing marker packet and
+// reading until its response arrives.
+func (c *Client) Execute(command string) (string, error) {
+	if len(command) > MaxCommandLength {
+		return "", fmt.Errorf("rcon: command exceeds %d bytes", MaxCommandLength)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	id := c.allocateID()
+	markerID := c.allocateID()
+	c.conn.SetDeadline(time.Now().Add(c.timeout))
+
+	if err := WritePacket(c.conn, &Packet{ID: id, Type: PacketTypeExecCommand, Body: command}); err != nil {
+		return "", fmt.Errorf("rcon: failed to send command: %w", err)
+	}
+	if err := WritePacket(c.conn, &Packet{ID: markerID, Type: PacketTypeResponseValue}); err != nil {
+		return "", fmt.Errorf("rcon: failed to send command: %w", err)
+	}
+
+	var response strings.Builder
+	for {
+		p, err := ReadPacket(c.conn)
+		if err != nil {
+			return "", fmt.Errorf("rcon: failed to read response: %w", err)
+		}
+		switch p.ID {
+		case id:
+			response.WriteString(p.Body)
+		case markerID:
+			return response.String(), nil
+		case -1:
+			return "", ErrAuthFailed
+		}
+	}
+}
+
+// Close closes the connection
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+func (c *Client) allocateID() int32 {
+	id := c.nextID
+	c.nextID++
+	if c.nextID <= 0 {
+		c.nextID = 1
+	}
+	return id
+}
+
+// WritePacket encodes and writes a packet
+func WritePacket(w io.Writer, p *Packet) error {
+	size := int32(headerSize + len(p.Body) + 2)
+
+	buf := bytes.NewBuffer(make([]byte, 0, size+4))
+	binary.Write(buf, binary.LittleEndian, size)
+	binary.Write(buf, binary.LittleEndian, p.ID)
+	binary.Write(buf, binary.LittleEndian, p.Type)
+	buf.WriteString(p.Body)
+	buf.Write([]byte{0, 0})
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// ReadPacket reads and decodes a packet
+func ReadPacket(r io.Reader) (*Packet, error) {
+	var size int32
+	if err := binary.Read(r, binary.LittleEndian, &size); err != nil {
+		return nil, err
+	}
+	if size < headerSize+2 || size > maxPacketSize {
+		return nil, fmt.Errorf("invalid packet size %d", size)
+	}
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+
+	return &Packet{
+		ID:   int32(binary.LittleEndian.Uint32(data[0:4])),
+		Type: int32(binary.LittleEndian.Uint32(data[4:8])),
+		Body: string(bytes.TrimRight(data[headerSize:], "\x00")),
+	}, nil
+}
@@ -0,0 +1,147 @@
+package rcon_test
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/game-server/controller/internal/rcon"
+	"github.com/game-server/controller/internal/rcon/rcontest"
+)
+
+const testTimeout = 2 * time.Second
+
+func TestExecute(t *testing.T) {
+	server := rcontest.NewServer("secret", func(command string) string {
+		return "ran " + command
+	})
+	defer server.Close()
+
+	client, err := rcon.Dial(server.Addr(), "secret", testTimeout)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer client.Close()
+
+	for _, command := range []string{"list", "say hello"} {
+		response, err := client.Execute(command)
+		if err != nil {
+			t.Fatalf("Execute(%q): %v", command, err)
+		}
+		if response != "ran "+command {
+			t.Errorf("Execute(%q) = %q, want %q", command, response, "ran "+command)
+		}
+	}
+
+	if commands := server.Commands(); len(commands) != 2 || commands[0] != "list" || commands[1] != "say hello" {
+		t.Errorf("server received %v, want [list say hello]", commands)
+	}
+}
+
+func TestDialWrongPassword(t *testing.T) {
+	server := rcontest.NewServer("secret", nil)
+	defer server.Close()
+
+	_, err := rcon.Dial(server.Addr(), "wrong", testTimeout)
+	if !errors.Is(err, rcon.ErrAuthFailed) {
+		t.Fatalf("Dial with a wrong password = %v, want ErrAuthFailed", err)
+	}
+}
+
+func TestExecuteReassemblesSplitResponse(t *testing.T) {
+	// Three packets: the fake server splits bodies at 4096 bytes
+	long := strings.Repeat("0123456789", 1000)
+	server := rcontest.NewServer("secret", func(command string) string {
+		if command == "long" {
+			return long
+		}
+		return "short"
+	})
+	defer server.Close()
+
+	client, err := rcon.Dial(server.Addr(), "secret", testTimeout)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer client.Close()
+
+	response, err := client.Execute("long")
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if response != long {
+		t.Errorf("response has %d bytes, want %d", len(response), len(long))
+	}
+
+	// The marker packet ends the response, so the next command gets its own
+	response, err = client.Execute("next")
+	if err != nil || response != "short" {
+		t.Errorf("Execute after a split response = %q, %v, want short", response, err)
+	}
+}
+
+func TestExecuteTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := rcontest.NewServer("secret", func(command string) string {
+		<-release
+		return ""
+	})
+	defer server.Close()
+	defer close(release)
+
+	client, err := rcon.Dial(server.Addr(), "secret", 100*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer client.Close()
+
+	_, err = client.Execute("hang")
+	var netErr net.Error
+	if !errors.As(err, &netErr) || !netErr.Timeout() {
+		t.Fatalf("Execute on a silent server = %v, want a timeout", err)
+	}
+}
+
+func TestDialTimeoutWithoutAuthResponse(t *testing.T) {
+	// Accepts connections but never answers
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	_, err = rcon.Dial(listener.Addr().String(), "secret", 100*time.Millisecond)
+	var netErr net.Error
+	if !errors.As(err, &netErr) || !netErr.Timeout() {
+		t.Fatalf("Dial to a silent server = %v, want a timeout", err)
+	}
+}
+
+func TestExecuteRejectsLongCommand(t *testing.T) {
+	server := rcontest.NewServer("secret", nil)
+	defer server.Close()
+
+	client, err := rcon.Dial(server.Addr(), "secret", testTimeout)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer client.Close()
+
+	if _, err := client.Execute(strings.Repeat("a", rcon.MaxCommandLength+1)); err == nil {
+		t.Fatal("Execute accepted a command longer than MaxCommandLength")
+	}
+	if commands := server.Commands(); len(commands) != 0 {
+		t.Errorf("server received %d commands, want none", len(commands))
+	}
+}
@@ -0,0 +1,165 @@
+// Package rcontest provides an in-process RCON server for testing code that
+// talks to game servers over RCON.
+package rcontest
+
+import (
+	"net"
+	"sync"
+
+	"github.com/game-server/controller/internal/rcon"
+)
+
+// maxBodySize is the largest response body sent in one packet, matching Minecraft
+const maxBodySize = 4096
+
+// HandlerFunc returns the response text for a command
+type HandlerFunc func(command string) string
+
+// Server is a fake RCON server listening on a loopback address
+type Server struct {
+	Password string
+
+	listener net.Listener
+	handler  HandlerFunc
+	commands []string
+	conns    map[net.Conn]struct{}
+	mu       sync.Mutex
+	wg       sync.WaitGroup
+}
+
+// NewServer starts a fake RCON server. handler may be nil, in which case
+// every command gets an empty response.
+func NewServer(password string, handler HandlerFunc) *Server {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic("rcontest: failed to listen: " + err.Error())
+	}
+
+	if handler == nil {
+		handler = func(string) string { return "" }
+	}
+
+	s := &Server{
+		Password: password,
+		listener: listener,
+		handler:  handler,
+		conns:    make(map[net.Conn]struct{}),
+	}
+
+	s.wg.Add(1)
+	go s.serve()
+
+	return s
+}
+
+// Addr returns the address the server listens on
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Commands returns the commands received so far
+func (s *Server) Commands() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := make([]string, len(s.commands))
+	copy(result, s.commands)
+	return result
+}
+
+// Close stops the server and closes open connections
+func (s *Server) Close() {
+	s.listener.Close()
+
+	s.mu.Lock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+		go s.handleConn(conn)
+	}
+}
+
+func (s *Server) handleConn(conn net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+
+	authenticated := false
+	for {
+		p, err := rcon.ReadPacket(conn)
+		if err != nil {
+			return
+		}
+
+		switch {
+		case p.Type == rcon.PacketTypeAuth:
+			id := p.ID
+			if p.Body != s.Password {
+				id = -1
+			}
+			authenticated = id != -1
+			if err := rcon.WritePacket(conn, &rcon.Packet{ID: id, Type: rcon.PacketTypeAuthResponse}); err != nil {
+				return
+			}
+
+		case !authenticated:
+			rcon.WritePacket(conn, &rcon.Packet{ID: -1, Type: rcon.PacketTypeResponseValue})
+			return
+
+		case p.Type == rcon.PacketTypeExecCommand:
+			s.mu.Lock()
+			s.commands = append(s.commands, p.Body)
+			s.mu.Unlock()
+
+			if err := s.writeResponse(conn, p.ID, s.handler(p.Body)); err != nil {
+				return
+			}
+
+		default:
+			// Mirror other packets back, which clients use as an end marker
+			if err := rcon.WritePacket(conn, &rcon.Packet{ID: p.ID, Type: rcon.PacketTypeResponseValue}); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// writeResponse splits a response over several packets like Minecraft does
+func (s *Server) writeResponse(conn net.Conn, id int32, body string) error {
+	for {
+		chunk := body
+		if len(chunk) > maxBodySize {
+			chunk = chunk[:maxBodySize]
+		}
+		if err := rcon.WritePacket(conn, &rcon.Packet{ID: id, Type: rcon.PacketTypeResponseValue, Body: chunk}); err != nil {
+			return err
+		}
+		body = body[len(chunk):]
+		if body == "" {
+			return nil
+		}
+	}
+}
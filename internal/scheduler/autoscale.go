@@ -0,0 +1,208 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/game-server/controller/internal/core/models"
+	"go.uber.org/zap"
+)
+
+// autoscaleDownCooldown is how long after scaling up a fleet the autoscaler
+// waits before scaling it down, so short dips in load do not cause churn
+const autoscaleDownCooldown = 5 * time.Minute
+
+// ErrInvalidAutoscalePolicy is returned when an autoscale policy is inconsistent
+var ErrInvalidAutoscalePolicy = errors.New("invalid autoscale policy")
+
+// PreviewAutoscale returns what the autoscaler would decide for a fleet now,
+// without applying it
+func (s *Scheduler) PreviewAutoscale(ctx context.Context, fleetID string) (*models.ScaleDecision, error) {
+	fleet, err := s.getFleet(ctx, fleetID)
+	if err != nil {
+		return nil, err
+	}
+	if fleet.Autoscale == nil {
+		return nil, fmt.Errorf("%w: fleet has no autoscale policy", ErrInvalidAutoscalePolicy)
+	}
+
+	servers, err := s.serverRepo.List(ctx, &models.ServerFilters{FleetID: fleet.ID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list fleet servers: %w", err)
+	}
+
+	decision := computeScaleDecision(fleet, servers)
+	decision.DryRun = true
+
+	return decision, nil
+}
+
+// ListFleetEvents returns the most recent autoscaler decisions of a fleet
+func (s *Scheduler) ListFleetEvents(ctx context.Context, fleetID string, limit int) ([]*models.FleetEvent, error) {
+	fleet, err := s.getFleet(ctx, fleetID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.fleetRepo.ListEvents(ctx, fleet.ID, limit)
+}
+
+// autoscaleFleet applies the fleet's autoscale policy to its replicas and
+// records the decision. Dry-run policies only record it. Only used by
+// RunFleets.
+func (s *Scheduler) autoscaleFleet(ctx context.Context, fleet *models.Fleet) {
+	servers, err := s.serverRepo.List(ctx, &models.ServerFilters{FleetID: fleet.ID})
+	if err != nil {
+		s.logger.Error("Failed to list fleet servers for autoscaling",
+			zap.Error(err),
+			zap.String("fleet_id", fleet.ID))
+		return
+	}
+
+	decision := computeScaleDecision(fleet, servers)
+	decision.DryRun = fleet.Autoscale.DryRun
+
+	if decision.DesiredReplicas == fleet.Replicas {
+		delete(s.lastDryRun, fleet.ID)
+		return
+	}
+
+	eventType := models.FleetEventScaleUp
+	if decision.DesiredReplicas < fleet.Replicas {
+		eventType = models.FleetEventScaleDown
+		if time.Since(s.lastScaleUp[fleet.ID]) < autoscaleDownCooldown {
+			return
+		}
+	}
+
+	// A dry run does not change the fleet, so record each decision only once
+	if decision.DryRun {
+		if last, exists := s.lastDryRun[fleet.ID]; exists && last == decision.DesiredReplicas {
+			return
+		}
+		s.lastDryRun[fleet.ID] = decision.DesiredReplicas
+	} else {
+		if err := s.fleetRepo.UpdateReplicas(ctx, fleet.ID, decision.DesiredReplicas); err != nil {
+			s.logger.Error("Failed to scale fleet",
+				zap.Error(err),
+				zap.String("fleet_id", fleet.ID))
+			return
+		}
+		if eventType == models.FleetEventScaleUp {
+			s.lastScaleUp[fleet.ID] = time.Now()
+		}
+		delete(s.lastDryRun, fleet.ID)
+	}
+
+	event := &models.FleetEvent{
+		FleetID:      fleet.ID,
+		Type:         eventType,
+		FromReplicas: fleet.Replicas,
+		ToReplicas:   decision.DesiredReplicas,
+		Reason:       decision.Reason,
+		DryRun:       decision.DryRun,
+	}
+	if err := s.fleetRepo.CreateEvent(ctx, event); err != nil {
+		s.logger.Error("Failed to record fleet event",
+			zap.Error(err),
+			zap.String("fleet_id", fleet.ID))
+	}
+
+	s.logger.Info("Fleet autoscaled",
+		zap.String("fleet_id", fleet.ID),
+		zap.String("type", string(eventType)),
+		zap.Int("from", fleet.Replicas),
+		zap.Int("to", decision.DesiredReplicas),
+		zap.Bool("dry_run", decision.DryRun),
+		zap.String("reason", decision.Reason))
+
+	if !decision.DryRun {
+		fleet.Replicas = decision.DesiredReplicas
+	}
+}
+
+// computeScaleDecision returns the replicas a fleet's autoscale policy asks
+// for. Servers in error are ignored, they are replaced by the reconciler.
+func computeScaleDecision(fleet *models.Fleet, servers []*models.Server) *models.ScaleDecision {
+	policy := fleet.Autoscale
+	decision := &models.ScaleDecision{
+		FleetID:         fleet.ID,
+		CurrentReplicas: fleet.Replicas,
+	}
+
+	// Servers that must not be removed: allocated or with players on them
+	protected := 0
+	for _, server := range servers {
+		state := models.FleetServerStateOf(server)
+		switch state {
+		case models.FleetServerStateReady:
+			decision.Ready++
+		case models.FleetServerStateAllocated:
+			decision.Allocated++
+		}
+		if state == models.FleetServerStateReady || state == models.FleetServerStateAllocated {
+			decision.Players += server.PlayerCount
+			decision.Capacity += server.MaxPlayers
+		}
+		if state == models.FleetServerStateAllocated || server.PlayerCount > 0 {
+			protected++
+		}
+	}
+	if decision.Capacity > 0 {
+		decision.Load = float64(decision.Players) / float64(decision.Capacity)
+	}
+
+	desired := 0
+	var reasons []string
+
+	switch {
+	case policy.BufferSize > 0:
+		desired = decision.Allocated + policy.BufferSize
+		reasons = append(reasons, fmt.Sprintf("%d allocated plus a buffer of %d ready servers", decision.Allocated, policy.BufferSize))
+	case policy.BufferPercent > 0:
+		desired = int(math.Ceil(float64(decision.Allocated) * 100 / float64(100-policy.BufferPercent)))
+		reasons = append(reasons, fmt.Sprintf("%d allocated with %d%% of replicas ready", decision.Allocated, policy.BufferPercent))
+	}
+
+	if policy.TargetLoad > 0 && fleet.Config.MaxPlayers > 0 {
+		byLoad := int(math.Ceil(float64(decision.Players) / (policy.TargetLoad * float64(fleet.Config.MaxPlayers))))
+		reason := fmt.Sprintf("%d players at load %.2f for target load %.2f", decision.Players, decision.Load, policy.TargetLoad)
+		if byLoad > desired {
+			desired = byLoad
+			reasons = append([]string{reason}, reasons...)
+		} else {
+			reasons = append(reasons, reason)
+		}
+	}
+
+	if desired < protected {
+		desired = protected
+		reasons = append(reasons, fmt.Sprintf("%d servers are allocated or have players", protected))
+	}
+
+	if desired < policy.MinReplicas {
+		desired = policy.MinReplicas
+		reasons = append(reasons, fmt.Sprintf("raised to min_replicas %d", policy.MinReplicas))
+	}
+	if desired > policy.MaxReplicas {
+		desired = policy.MaxReplicas
+		reasons = append(reasons, fmt.Sprintf("capped at max_replicas %d", policy.MaxReplicas))
+	}
+
+	decision.DesiredReplicas = desired
+	decision.Reason = strings.Join(reasons, "; ")
+
+	return decision
+}
+
+// validateAutoscalePolicy wraps policy errors in ErrInvalidAutoscalePolicy
+func validateAutoscalePolicy(policy *models.AutoscalePolicy) error {
+	if err := policy.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidAutoscalePolicy, err)
+	}
+	return nil
+}
@@ -0,0 +1,369 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/game-server/controller/internal/core/models"
+	"go.uber.org/zap"
+)
+
+const (
+	// drainConcurrency is how many servers of a node are evacuated at once
+	drainConcurrency = 3
+	// drainReleasePollInterval is how often a drain checks whether an
+	// allocated fleet server was released
+	drainReleasePollInterval = 5 * time.Second
+)
+
+var (
+	// ErrNodeUnavailable is returned when starting a server on a node that
+	// is draining or in maintenance
+	ErrNodeUnavailable = errors.New("node is draining or in maintenance")
+	// ErrNotDraining is returned when undraining a node that is not draining
+	// or in maintenance
+	ErrNotDraining = errors.New("node is not draining or in maintenance")
+	// ErrNoDrain is returned when a node has no drain progress to report
+	ErrNoDrain = errors.New("node has not been drained")
+)
+
+// nodeDrain is a drain in progress or the last drain of a node
+type nodeDrain struct {
+	progress models.DrainProgress
+	cancel   context.CancelFunc
+}
+
+// DrainNode stops new placements on a node and evacuates its servers in the
+// background according to the policy. The node enters maintenance once no
+// server is active on it. Draining a node again retries failed servers.
+// Concurrent calls for one node start a single drain.
+func (s *Scheduler) DrainNode(ctx context.Context, nodeID string, opts models.DrainOptions) (*models.DrainProgress, error) {
+	node, err := s.nodeMgr.GetNode(nodeID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.drainsMu.Lock()
+	defer s.drainsMu.Unlock()
+
+	if drain, exists := s.drains[nodeID]; exists && drain.progress.State == models.DrainStateRunning {
+		return copyDrainProgress(&drain.progress), nil
+	}
+
+	node.Status = models.NodeStatusDraining
+	if err := s.nodeMgr.Update(node); err != nil {
+		return nil, fmt.Errorf("failed to set node draining: %w", err)
+	}
+
+	servers, err := s.serverRepo.List(ctx, &models.ServerFilters{NodeID: nodeID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list node servers: %w", err)
+	}
+
+	opts.Graceful = true
+	drainCtx, cancel := context.WithCancel(context.Background())
+	drain := &nodeDrain{
+		progress: models.DrainProgress{
+			NodeID:    nodeID,
+			Policy:    opts.Policy,
+			State:     models.DrainStateRunning,
+			Servers:   []models.DrainServerProgress{},
+			StartedAt: time.Now(),
+		},
+		cancel: cancel,
+	}
+	for _, server := range servers {
+		// Stopping leaves inactive servers alone, migrating moves every server
+		if opts.Policy != models.DrainPolicyMigrate && !isActiveServer(server) && server.FleetID == "" {
+			continue
+		}
+		drain.progress.Servers = append(drain.progress.Servers, models.DrainServerProgress{
+			ServerID: server.ID,
+			Name:     server.Name,
+			State:    models.DrainServerPending,
+		})
+	}
+	drain.progress.Total = len(drain.progress.Servers)
+	s.drains[nodeID] = drain
+
+	s.logger.Info("Draining node",
+		zap.String("node_id", nodeID),
+		zap.String("policy", string(opts.Policy)),
+		zap.Bool("force", opts.Force),
+		zap.Int("servers", drain.progress.Total))
+
+	go s.runDrain(drainCtx, drain, servers, opts)
+
+	return copyDrainProgress(&drain.progress), nil
+}
+
+// UndrainNode cancels a drain and puts a draining or maintenance node back
+// into service. Servers stopped by the drain are not restarted.
+func (s *Scheduler) UndrainNode(ctx context.Context, nodeID string) error {
+	node, err := s.nodeMgr.GetNode(nodeID)
+	if err != nil {
+		return err
+	}
+	if node.Status != models.NodeStatusDraining && node.Status != models.NodeStatusMaintenance {
+		return ErrNotDraining
+	}
+
+	s.drainsMu.Lock()
+	if drain, exists := s.drains[nodeID]; exists {
+		drain.cancel()
+		if drain.progress.State == models.DrainStateRunning {
+			s.finishDrain(drain, models.DrainStateCancelled)
+		}
+	}
+	s.drainsMu.Unlock()
+
+	node.Status = models.NodeStatusOffline
+	if s.nodeMgr.IsConnected(nodeID) {
+		node.Status = models.NodeStatusOnline
+	}
+	if err := s.nodeMgr.Update(node); err != nil {
+		return fmt.Errorf("failed to update node status: %w", err)
+	}
+
+	s.logger.Info("Node undrained",
+		zap.String("node_id", nodeID),
+		zap.String("status", string(node.Status)))
+
+	return nil
+}
+
+// GetDrainProgress returns the progress of the current or last drain of a node
+func (s *Scheduler) GetDrainProgress(nodeID string) (*models.DrainProgress, error) {
+	s.drainsMu.Lock()
+	defer s.drainsMu.Unlock()
+
+	drain, exists := s.drains[nodeID]
+	if !exists {
+		return nil, ErrNoDrain
+	}
+	return copyDrainProgress(&drain.progress), nil
+}
+
+// runDrain evacuates the servers of a drain and moves the node to
+// maintenance if it ends up empty
+func (s *Scheduler) runDrain(ctx context.Context, drain *nodeDrain, servers []*models.Server, opts models.DrainOptions) {
+	byID := make(map[string]*models.Server, len(servers))
+	for _, server := range servers {
+		byID[server.ID] = server
+	}
+
+	sem := make(chan struct{}, drainConcurrency)
+	var wg sync.WaitGroup
+
+	for i := range drain.progress.Servers {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			// Allocated fleet servers wait without taking a slot, so they
+			// do not hold up the other servers
+			server := byID[drain.progress.Servers[i].ServerID]
+			if server.FleetID != "" && !opts.Force {
+				if err := s.waitForRelease(ctx, drain, i, server); err != nil {
+					if ctx.Err() == nil {
+						s.setDrainServerState(drain, i, models.DrainServerFailed, err)
+					}
+					return
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case sem <- struct{}{}:
+			}
+			defer func() { <-sem }()
+
+			state, err := s.evacuateServer(ctx, drain, i, server, opts)
+			s.setDrainServerState(drain, i, state, err)
+		}(i)
+	}
+	wg.Wait()
+
+	s.drainsMu.Lock()
+	defer s.drainsMu.Unlock()
+
+	if drain.progress.State != models.DrainStateRunning {
+		return
+	}
+	if drain.progress.Failed > 0 {
+		s.finishDrain(drain, models.DrainStateFailed)
+		s.logger.Warn("Node drain failed",
+			zap.String("node_id", drain.progress.NodeID),
+			zap.Int("failed", drain.progress.Failed))
+		return
+	}
+
+	// Servers may have been started or placed while the drain ran
+	remaining, err := s.serverRepo.List(context.Background(), &models.ServerFilters{NodeID: drain.progress.NodeID})
+	if err != nil {
+		s.logger.Error("Failed to list node servers", zap.Error(err))
+		s.finishDrain(drain, models.DrainStateFailed)
+		return
+	}
+	for _, server := range remaining {
+		if isActiveServer(server) {
+			s.logger.Warn("Node not empty after drain",
+				zap.String("node_id", drain.progress.NodeID),
+				zap.String("server_id", server.ID))
+			s.finishDrain(drain, models.DrainStateFailed)
+			return
+		}
+	}
+
+	node, err := s.nodeMgr.GetNode(drain.progress.NodeID)
+	if err == nil {
+		node.Status = models.NodeStatusMaintenance
+		err = s.nodeMgr.Update(node)
+	}
+	if err != nil {
+		s.logger.Error("Failed to set node to maintenance",
+			zap.Error(err),
+			zap.String("node_id", drain.progress.NodeID))
+		s.finishDrain(drain, models.DrainStateFailed)
+		return
+	}
+
+	s.finishDrain(drain, models.DrainStateCompleted)
+	s.logger.Info("Node drained",
+		zap.String("node_id", drain.progress.NodeID),
+		zap.Int("servers", drain.progress.Total))
+}
+
+// waitForRelease waits until a fleet server on a draining node is no longer
+// allocated, because a match may be running on it. Servers on draining nodes
+// are not allocated again, so it is safe to delete afterwards.
+func (s *Scheduler) waitForRelease(ctx context.Context, drain *nodeDrain, i int, server *models.Server) error {
+	ticker := time.NewTicker(drainReleasePollInterval)
+	defer ticker.Stop()
+
+	for {
+		current, err := s.serverRepo.GetByID(ctx, server.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get server: %w", err)
+		}
+		if current == nil || !current.AllocatedAt.Valid {
+			return nil
+		}
+		s.setDrainServerState(drain, i, models.DrainServerAllocated, nil)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// evacuateServer moves one server off a draining node. Fleet servers are
+// deleted, their fleet replaces them on another node. Other servers are
+// migrated or stopped according to the policy.
+func (s *Scheduler) evacuateServer(ctx context.Context, drain *nodeDrain, i int, server *models.Server, opts models.DrainOptions) (models.DrainServerState, error) {
+	if server.FleetID != "" {
+		if isActiveServer(server) {
+			s.setDrainServerState(drain, i, models.DrainServerStopping, nil)
+			if err := s.StopServerGracefully(ctx, server.ID, opts.StopOptions); err != nil {
+				return models.DrainServerFailed, err
+			}
+		}
+		if err := s.DeleteServer(ctx, server.ID, false); err != nil {
+			return models.DrainServerFailed, err
+		}
+		return models.DrainServerDeleted, nil
+	}
+
+	if opts.Policy == models.DrainPolicyMigrate {
+		// The draining node is not online, so it is never picked as the
+		// target. Server data is only copied between nodes on one host.
+		source, err := s.nodeMgr.GetNode(server.NodeID)
+		if err != nil {
+			return models.DrainServerFailed, err
+		}
+		target, err := s.findNodeOnHost(server.GameType, source.HostID, opts.ExcludeTargets)
+		if err != nil {
+			return models.DrainServerFailed, err
+		}
+
+		s.drainsMu.Lock()
+		drain.progress.Servers[i].TargetNodeID = target.ID
+		s.drainsMu.Unlock()
+
+		s.setDrainServerState(drain, i, models.DrainServerMigrating, nil)
+		if err := s.MigrateServer(ctx, server.ID, target.ID, opts.StopOptions); err != nil {
+			return models.DrainServerFailed, err
+		}
+		return models.DrainServerMigrated, nil
+	}
+
+	s.setDrainServerState(drain, i, models.DrainServerStopping, nil)
+	if err := s.StopServerGracefully(ctx, server.ID, opts.StopOptions); err != nil {
+		return models.DrainServerFailed, err
+	}
+	return models.DrainServerStopped, nil
+}
+
+// setDrainServerState records the state of a server in a drain
+func (s *Scheduler) setDrainServerState(drain *nodeDrain, i int, state models.DrainServerState, err error) {
+	s.drainsMu.Lock()
+	defer s.drainsMu.Unlock()
+
+	progress := &drain.progress.Servers[i]
+	progress.State = state
+	if err != nil {
+		progress.Error = err.Error()
+		s.logger.Error("Failed to evacuate server",
+			zap.Error(err),
+			zap.String("node_id", drain.progress.NodeID),
+			zap.String("server_id", progress.ServerID))
+	}
+
+	switch state {
+	case models.DrainServerFailed:
+		drain.progress.Failed++
+	case models.DrainServerStopped, models.DrainServerMigrated, models.DrainServerDeleted:
+		drain.progress.Done++
+	}
+}
+
+// finishDrain ends a drain. drainsMu must be held.
+func (s *Scheduler) finishDrain(drain *nodeDrain, state models.DrainState) {
+	now := time.Now()
+	drain.progress.State = state
+	drain.progress.CompletedAt = &now
+}
+
+// checkNodeAvailable fails if a node is draining or in maintenance
+func (s *Scheduler) checkNodeAvailable(nodeID string) error {
+	node, err := s.nodeMgr.GetNode(nodeID)
+	if err != nil {
+		return err
+	}
+	if node.Status == models.NodeStatusDraining || node.Status == models.NodeStatusMaintenance {
+		return fmt.Errorf("%w: %s", ErrNodeUnavailable, nodeID)
+	}
+	return nil
+}
+
+// isActiveServer returns whether a server runs or is about to run. Stopped,
+// errored and hibernating servers are not active.
+func isActiveServer(server *models.Server) bool {
+	switch server.Status {
+	case models.ServerStatusStopped, models.ServerStatusError, models.ServerStatusHibernating:
+		return false
+	}
+	return true
+}
+
+func copyDrainProgress(progress *models.DrainProgress) *models.DrainProgress {
+	p := *progress
+	p.Servers = append([]models.DrainServerProgress(nil), progress.Servers...)
+	return &p
+}
@@ -0,0 +1,72 @@
+package scheduler
+
+import (
+	"context"
+
+	"github.com/game-server/controller/internal/core/models"
+	"github.com/game-server/controller/internal/minecraft"
+	"github.com/game-server/controller/internal/node"
+	"go.uber.org/zap"
+)
+
+// Run consumes node events until ctx is cancelled
+func (s *Scheduler) Run(ctx context.Context) {
+	events := s.nodeMgr.SubscribeToEvents("scheduler")
+	defer s.nodeMgr.UnsubscribeFromEvents(events)
+
+	s.logger.Info("Scheduler event loop started")
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			s.handleEvent(ctx, event)
+		}
+	}
+}
+
+// handleEvent dispatches a node event to the matching handler
+func (s *Scheduler) handleEvent(ctx context.Context, event *node.StreamEvent) {
+	switch event.Type {
+	case models.EventTypeServerProperties:
+		report, ok := event.Payload.(*models.ServerPropertiesReport)
+		if !ok {
+			s.logger.Warn("Unexpected server properties payload",
+				zap.String("node_id", event.NodeID))
+			return
+		}
+		s.RecordPropertiesReport(ctx, report)
+
+	case models.EventTypeServerStarted, models.EventTypeServerStopped, models.EventTypeServerError:
+		serverEvent, ok := event.Payload.(*models.ServerEvent)
+		if !ok {
+			s.logger.Warn("Unexpected server event payload",
+				zap.String("node_id", event.NodeID),
+				zap.String("event_type", string(event.Type)))
+			return
+		}
+		s.notifyServerEvent(event.Type, serverEvent)
+		if event.Type == models.EventTypeServerStarted {
+			go s.checkPropertiesDrift(ctx, serverEvent.ServerID)
+		}
+	}
+}
+
+// checkPropertiesDrift reads server.properties after a Minecraft server
+// starts, once the server has written its defaults into the file
+func (s *Scheduler) checkPropertiesDrift(ctx context.Context, serverID string) {
+	server, err := s.serverRepo.GetByID(ctx, serverID)
+	if err != nil || server == nil || server.GameType != minecraft.GameType {
+		return
+	}
+
+	if err := s.RefreshPropertiesReport(ctx, server); err != nil {
+		s.logger.Warn("Failed to read server.properties from node",
+			zap.Error(err),
+			zap.String("server_id", serverID))
+	}
+}
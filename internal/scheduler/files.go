@@ -0,0 +1,78 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/game-server/controller/internal/core/models"
+	"github.com/game-server/controller/internal/node"
+)
+
+// fileCommandTimeout bounds reading or writing a file on a node
+const fileCommandTimeout = 30 * time.Second
+
+// readServerFile reads a file relative to the server's data directory on its
+// node. A missing file is returned as empty content.
+func (s *Scheduler) readServerFile(ctx context.Context, server *models.Server, path string) ([]byte, error) {
+	cmd := &node.Command{
+		ID:   generateCommandID(),
+		Type: node.CommandTypeReadFile,
+		Payload: map[string]interface{}{
+			"server_id":  server.ID,
+			"path":       path,
+			"missing_ok": true,
+		},
+		Response: make(chan *node.CommandResult, 1),
+	}
+
+	result, err := s.sendCommandAndWait(ctx, server.NodeID, cmd, fileCommandTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	return result.Data, nil
+}
+
+// writeServerFile replaces a file relative to the server's data directory on
+// its node
+func (s *Scheduler) writeServerFile(ctx context.Context, server *models.Server, path string, data []byte) error {
+	cmd := &node.Command{
+		ID:   generateCommandID(),
+		Type: node.CommandTypeWriteFile,
+		Payload: map[string]interface{}{
+			"server_id": server.ID,
+			"path":      path,
+			"content":   data,
+		},
+		Response: make(chan *node.CommandResult, 1),
+	}
+
+	if _, err := s.sendCommandAndWait(ctx, server.NodeID, cmd, fileCommandTimeout); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// sendCommandAndWait sends a command to a node and waits for its result
+func (s *Scheduler) sendCommandAndWait(ctx context.Context, nodeID string, cmd *node.Command, timeout time.Duration) (*node.CommandResult, error) {
+	if err := s.nodeMgr.SendCommand(nodeID, cmd); err != nil {
+		return nil, fmt.Errorf("failed to send %s command: %w", cmd.Type, err)
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case result := <-cmd.Response:
+		if !result.Success {
+			return nil, fmt.Errorf("node returned an error: %s", result.Message)
+		}
+		return result, nil
+	case <-timer.C:
+		return nil, fmt.Errorf("timeout waiting for node")
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
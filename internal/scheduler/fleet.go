@@ -0,0 +1,590 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/game-server/controller/internal/core/models"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// fleetReconcileInterval is how often fleets are brought to their desired size
+const fleetReconcileInterval = 15 * time.Second
+
+var (
+	// ErrFleetNotFound is returned when a fleet does not exist
+	ErrFleetNotFound = errors.New("fleet not found")
+	// ErrNoReadyServer is returned when a fleet has no server to allocate
+	ErrNoReadyServer = errors.New("no ready server in fleet")
+	// ErrNotAllocated is returned when releasing a server that is not allocated
+	ErrNotAllocated = errors.New("server is not allocated")
+)
+
+// CreateFleet validates the server template and creates a fleet. Its servers
+// are created by the reconciler.
+func (s *Scheduler) CreateFleet(ctx context.Context, req *models.CreateFleetRequest) (*models.Fleet, error) {
+	if err := s.validateFleetConfig(req.GameType, &req.Config); err != nil {
+		return nil, err
+	}
+	if req.Autoscale != nil {
+		if err := validateAutoscalePolicy(req.Autoscale); err != nil {
+			return nil, err
+		}
+	}
+
+	fleet := &models.Fleet{
+		Name:                 req.Name,
+		GameType:             req.GameType,
+		Config:               req.Config,
+		Requirements:         req.Requirements,
+		Replicas:             req.Replicas,
+		AllocationTTLSeconds: req.AllocationTTLSeconds,
+		Autoscale:            req.Autoscale,
+	}
+
+	if err := s.fleetRepo.Create(ctx, fleet); err != nil {
+		return nil, err
+	}
+
+	s.reconcileFleet(ctx, fleet)
+
+	return fleet, nil
+}
+
+// GetFleet returns a fleet with the state of its servers
+func (s *Scheduler) GetFleet(ctx context.Context, fleetID string) (*models.FleetStatus, error) {
+	fleet, err := s.getFleet(ctx, fleetID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.fleetStatus(ctx, fleet)
+}
+
+// ListFleets returns all fleets with the state of their servers
+func (s *Scheduler) ListFleets(ctx context.Context) ([]*models.FleetStatus, error) {
+	fleets, err := s.fleetRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]*models.FleetStatus, 0, len(fleets))
+	for _, fleet := range fleets {
+		status, err := s.fleetStatus(ctx, fleet)
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// UpdateFleet changes the size, template or autoscale policy of a fleet. A
+// new template only applies to servers created afterwards, and the replicas
+// of an autoscaled fleet are overridden by the autoscaler.
+func (s *Scheduler) UpdateFleet(ctx context.Context, fleetID string, req models.UpdateFleetRequest) (*models.Fleet, error) {
+	fleet, err := s.getFleet(ctx, fleetID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Config != nil {
+		if err := s.validateFleetConfig(fleet.GameType, req.Config); err != nil {
+			return nil, err
+		}
+		fleet.Config = *req.Config
+	}
+	if req.Requirements != nil {
+		fleet.Requirements = *req.Requirements
+	}
+	if req.Replicas != nil {
+		fleet.Replicas = *req.Replicas
+	}
+	if req.AllocationTTLSeconds != nil {
+		fleet.AllocationTTLSeconds = *req.AllocationTTLSeconds
+	}
+	if req.Autoscale != nil {
+		if req.DisableAutoscale {
+			return nil, fmt.Errorf("%w: autoscale and disable_autoscale are mutually exclusive", ErrInvalidAutoscalePolicy)
+		}
+		if err := validateAutoscalePolicy(req.Autoscale); err != nil {
+			return nil, err
+		}
+		fleet.Autoscale = req.Autoscale
+	}
+	if req.DisableAutoscale {
+		fleet.Autoscale = nil
+	}
+
+	if err := s.fleetRepo.Update(ctx, fleet); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Fleet updated",
+		zap.String("fleet_id", fleet.ID),
+		zap.Int("replicas", fleet.Replicas))
+
+	s.reconcileFleet(ctx, fleet)
+
+	return fleet, nil
+}
+
+// DeleteFleet deletes a fleet and all of its servers, including allocated ones
+func (s *Scheduler) DeleteFleet(ctx context.Context, fleetID string) error {
+	fleet, err := s.getFleet(ctx, fleetID)
+	if err != nil {
+		return err
+	}
+
+	// List the servers first, deleting the fleet detaches them
+	servers, err := s.serverRepo.List(ctx, &models.ServerFilters{FleetID: fleet.ID})
+	if err != nil {
+		return fmt.Errorf("failed to list fleet servers: %w", err)
+	}
+
+	// Stop the reconciler from replacing the servers while they are deleted.
+	// A reconcile that is already running finishes first.
+	lock := s.fleetLock(fleet.ID)
+	lock.Lock()
+	err = s.fleetRepo.Delete(ctx, fleet.ID)
+	lock.Unlock()
+	if err != nil {
+		return err
+	}
+
+	s.fleetLocksMu.Lock()
+	delete(s.fleetLocks, fleet.ID)
+	s.fleetLocksMu.Unlock()
+
+	for _, server := range servers {
+		if err := s.DeleteServer(ctx, server.ID, false); err != nil {
+			s.logger.Error("Failed to delete fleet server",
+				zap.Error(err),
+				zap.String("server_id", server.ID))
+		}
+	}
+
+	s.logger.Info("Fleet deleted",
+		zap.String("fleet_id", fleet.ID),
+		zap.Int("servers", len(servers)))
+
+	return nil
+}
+
+// Allocate hands a ready server of a fleet to the caller and marks it as
+// allocated until it is released or its allocation expires
+func (s *Scheduler) Allocate(ctx context.Context, fleetID string) (*models.Allocation, error) {
+	fleet, err := s.getFleet(ctx, fleetID)
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := time.Duration(fleet.AllocationTTLSeconds) * time.Second
+	serverID, err := s.fleetRepo.Allocate(ctx, fleet.ID, ttl)
+	if err != nil {
+		return nil, err
+	}
+	if serverID == "" {
+		return nil, ErrNoReadyServer
+	}
+
+	server, err := s.serverRepo.GetByID(ctx, serverID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get server: %w", err)
+	}
+	if server == nil {
+		return nil, fmt.Errorf("server not found: %s", serverID)
+	}
+
+	allocation := &models.Allocation{
+		ServerID:    server.ID,
+		FleetID:     fleet.ID,
+		NodeID:      server.NodeID,
+		IPAddress:   server.IPAddress,
+		Port:        server.Port,
+		AllocatedAt: server.AllocatedAt.Time,
+	}
+	if server.AllocationExpiresAt.Valid {
+		allocation.ExpiresAt = &server.AllocationExpiresAt.Time
+	}
+
+	s.logger.Info("Server allocated",
+		zap.String("fleet_id", fleet.ID),
+		zap.String("server_id", server.ID))
+
+	// Replace the allocated server in the ready pool right away
+	go s.reconcileFleet(context.Background(), fleet)
+
+	return allocation, nil
+}
+
+// Release returns an allocated server to its fleet's ready pool
+func (s *Scheduler) Release(ctx context.Context, serverID string) error {
+	released, err := s.fleetRepo.Release(ctx, serverID)
+	if err != nil {
+		return err
+	}
+	if !released {
+		return ErrNotAllocated
+	}
+
+	s.logger.Info("Server released", zap.String("server_id", serverID))
+
+	return nil
+}
+
+// RunFleets keeps fleets at their desired size and expires allocations until
+// ctx is cancelled
+func (s *Scheduler) RunFleets(ctx context.Context) {
+	ticker := time.NewTicker(fleetReconcileInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.reconcileFleets(ctx)
+		}
+	}
+}
+
+// reconcileFleets expires allocations, then autoscales and reconciles every
+// fleet
+func (s *Scheduler) reconcileFleets(ctx context.Context) {
+	released, err := s.fleetRepo.ReleaseExpired(ctx)
+	if err != nil {
+		s.logger.Error("Failed to expire allocations", zap.Error(err))
+	}
+	for _, id := range released {
+		s.logger.Info("Allocation expired", zap.String("server_id", id))
+	}
+
+	fleets, err := s.fleetRepo.List(ctx)
+	if err != nil {
+		s.logger.Error("Failed to list fleets", zap.Error(err))
+		return
+	}
+
+	for _, fleet := range fleets {
+		if fleet.Autoscale != nil {
+			s.autoscaleFleet(ctx, fleet)
+		}
+		s.reconcileFleet(ctx, fleet)
+	}
+}
+
+// reconcileFleet replaces errored servers, starts stopped ones and creates or
+// deletes servers until the fleet has the desired number of replicas.
+// Allocated servers and servers with players are never deleted to shrink a
+// fleet. Reconciles of the same fleet run one at a time, each against the
+// fleet as it is stored when it starts.
+func (s *Scheduler) reconcileFleet(ctx context.Context, fleet *models.Fleet) {
+	fleetID := fleet.ID
+	lock := s.fleetLock(fleetID)
+	lock.Lock()
+	defer lock.Unlock()
+
+	fleet, err := s.fleetRepo.GetByID(ctx, fleetID)
+	if err != nil {
+		s.logger.Error("Failed to get fleet",
+			zap.Error(err),
+			zap.String("fleet_id", fleetID))
+		return
+	}
+	if fleet == nil {
+		// Deleted while waiting for another reconcile
+		return
+	}
+
+	servers, err := s.serverRepo.List(ctx, &models.ServerFilters{FleetID: fleet.ID})
+	if err != nil {
+		s.logger.Error("Failed to list fleet servers",
+			zap.Error(err),
+			zap.String("fleet_id", fleet.ID))
+		return
+	}
+
+	healthy := servers[:0]
+	for _, server := range servers {
+		switch {
+		case models.FleetServerStateOf(server) == models.FleetServerStateUnhealthy:
+			s.logger.Warn("Replacing unhealthy fleet server",
+				zap.String("fleet_id", fleet.ID),
+				zap.String("server_id", server.ID))
+			if err := s.DeleteServer(ctx, server.ID, false); err != nil {
+				s.logger.Error("Failed to delete unhealthy fleet server",
+					zap.Error(err),
+					zap.String("server_id", server.ID))
+			}
+			continue
+		case server.Status == models.ServerStatusStopped:
+			if err := s.StartServer(ctx, server.ID); err != nil {
+				s.logger.Error("Failed to start fleet server",
+					zap.Error(err),
+					zap.String("server_id", server.ID))
+			}
+		}
+		healthy = append(healthy, server)
+	}
+
+	// Servers still being created are counted once, whether or not they
+	// are already in the database
+	s.fleetCreatingMu.Lock()
+	creating := len(s.fleetCreating[fleet.ID])
+	for _, server := range healthy {
+		if _, exists := s.fleetCreating[fleet.ID][server.Name]; exists {
+			creating--
+		}
+	}
+	s.fleetCreatingMu.Unlock()
+
+	if missing := fleet.Replicas - len(healthy) - creating; missing > 0 {
+		s.growFleet(ctx, fleet, healthy, missing)
+	}
+
+	if surplus := len(healthy) + creating - fleet.Replicas; surplus > 0 {
+		s.shrinkFleet(ctx, fleet, healthy, surplus)
+	}
+}
+
+// fleetLock returns the mutex that serialises reconciles of a fleet
+func (s *Scheduler) fleetLock(fleetID string) *sync.Mutex {
+	s.fleetLocksMu.Lock()
+	defer s.fleetLocksMu.Unlock()
+
+	lock, exists := s.fleetLocks[fleetID]
+	if !exists {
+		lock = &sync.Mutex{}
+		s.fleetLocks[fleetID] = lock
+	}
+	return lock
+}
+
+// growFleet starts creating count servers for a fleet. Each goes on the
+// eligible node with the fewest servers of the fleet, counting servers still
+// being created, and nodes at their max servers are skipped.
+func (s *Scheduler) growFleet(ctx context.Context, fleet *models.Fleet, servers []*models.Server, count int) {
+	nodes, err := s.nodeMgr.ListNodes()
+	if err != nil {
+		s.logger.Error("Failed to list nodes for fleet servers",
+			zap.Error(err),
+			zap.String("fleet_id", fleet.ID))
+		return
+	}
+
+	fleetServers := make(map[string]int)
+	stored := make(map[string]bool, len(servers))
+	for _, server := range servers {
+		fleetServers[server.NodeID]++
+		stored[server.Name] = true
+	}
+
+	s.fleetCreatingMu.Lock()
+	allCreating := make(map[string]int)
+	for fleetID, names := range s.fleetCreating {
+		for name, nodeID := range names {
+			allCreating[nodeID]++
+			if fleetID == fleet.ID && !stored[name] {
+				fleetServers[nodeID]++
+			}
+		}
+	}
+	s.fleetCreatingMu.Unlock()
+
+	type candidate struct {
+		node  *models.Node
+		total int
+	}
+	var candidates []*candidate
+	for _, n := range nodes {
+		if n.Status != models.NodeStatusOnline || n.GameType != fleet.GameType {
+			continue
+		}
+		total, err := s.serverRepo.CountByNode(ctx, n.ID)
+		if err != nil {
+			s.logger.Error("Failed to count node servers",
+				zap.Error(err),
+				zap.String("node_id", n.ID))
+			continue
+		}
+		candidates = append(candidates, &candidate{node: n, total: total + allCreating[n.ID]})
+	}
+
+	names := make(map[string]string, count)
+	for i := 0; i < count; i++ {
+		var best *candidate
+		for _, c := range candidates {
+			if c.node.MaxServers > 0 && c.total >= c.node.MaxServers {
+				continue
+			}
+			if best == nil ||
+				fleetServers[c.node.ID] < fleetServers[best.node.ID] ||
+				fleetServers[c.node.ID] == fleetServers[best.node.ID] && c.total < best.total {
+				best = c
+			}
+		}
+		if best == nil {
+			s.logger.Warn("No node has room for fleet servers",
+				zap.String("fleet_id", fleet.ID),
+				zap.String("game_type", fleet.GameType),
+				zap.Int("missing", count-i))
+			break
+		}
+
+		fleetServers[best.node.ID]++
+		best.total++
+		names[fmt.Sprintf("%s-%s", fleet.Name, uuid.New().String()[:8])] = best.node.ID
+	}
+
+	s.fleetCreatingMu.Lock()
+	for name, nodeID := range names {
+		if s.fleetCreating[fleet.ID] == nil {
+			s.fleetCreating[fleet.ID] = make(map[string]string)
+		}
+		s.fleetCreating[fleet.ID][name] = nodeID
+	}
+	s.fleetCreatingMu.Unlock()
+
+	for name, nodeID := range names {
+		go s.createFleetServer(fleet, name, nodeID)
+	}
+}
+
+// createFleetServer creates a server named name on a node from a fleet's
+// template
+func (s *Scheduler) createFleetServer(fleet *models.Fleet, name, nodeID string) {
+	defer func() {
+		s.fleetCreatingMu.Lock()
+		delete(s.fleetCreating[fleet.ID], name)
+		if len(s.fleetCreating[fleet.ID]) == 0 {
+			delete(s.fleetCreating, fleet.ID)
+		}
+		s.fleetCreatingMu.Unlock()
+	}()
+
+	config := fleet.Config
+	config.Name = name
+
+	req := &models.CreateServerRequest{
+		NodeID:       nodeID,
+		GameType:     fleet.GameType,
+		Config:       &config,
+		Requirements: &fleet.Requirements,
+		FleetID:      fleet.ID,
+	}
+
+	if _, err := s.CreateServer(context.Background(), req); err != nil {
+		s.logger.Error("Failed to create fleet server",
+			zap.Error(err),
+			zap.String("fleet_id", fleet.ID),
+			zap.String("name", config.Name))
+	}
+}
+
+// shrinkFleet deletes servers that are neither allocated nor have players.
+// Servers that are not ready go first, then the newest ready servers. Each
+// server is claimed in the database first, so one allocated since the
+// servers were listed is skipped.
+func (s *Scheduler) shrinkFleet(ctx context.Context, fleet *models.Fleet, servers []*models.Server, count int) {
+	var candidates []*models.Server
+	for _, server := range servers {
+		if models.FleetServerStateOf(server) != models.FleetServerStateAllocated && server.PlayerCount == 0 {
+			candidates = append(candidates, server)
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		iReady := models.FleetServerStateOf(candidates[i]) == models.FleetServerStateReady
+		jReady := models.FleetServerStateOf(candidates[j]) == models.FleetServerStateReady
+		if iReady != jReady {
+			return !iReady
+		}
+		return candidates[i].CreatedAt.After(candidates[j].CreatedAt)
+	})
+
+	for _, server := range candidates {
+		if count == 0 {
+			break
+		}
+
+		claimed, err := s.fleetRepo.ClaimForRemoval(ctx, server.ID)
+		if err != nil {
+			s.logger.Error("Failed to claim surplus fleet server",
+				zap.Error(err),
+				zap.String("server_id", server.ID))
+			continue
+		}
+		if !claimed {
+			s.logger.Debug("Surplus fleet server was allocated meanwhile",
+				zap.String("fleet_id", fleet.ID),
+				zap.String("server_id", server.ID))
+			continue
+		}
+		count--
+
+		s.logger.Info("Deleting surplus fleet server",
+			zap.String("fleet_id", fleet.ID),
+			zap.String("server_id", server.ID))
+		if err := s.DeleteServer(ctx, server.ID, false); err != nil {
+			s.logger.Error("Failed to delete surplus fleet server",
+				zap.Error(err),
+				zap.String("server_id", server.ID))
+		}
+	}
+}
+
+// fleetStatus counts the servers of a fleet by state
+func (s *Scheduler) fleetStatus(ctx context.Context, fleet *models.Fleet) (*models.FleetStatus, error) {
+	servers, err := s.serverRepo.List(ctx, &models.ServerFilters{FleetID: fleet.ID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list fleet servers: %w", err)
+	}
+
+	status := &models.FleetStatus{Fleet: fleet, Total: len(servers)}
+	for _, server := range servers {
+		switch models.FleetServerStateOf(server) {
+		case models.FleetServerStateReady:
+			status.Ready++
+		case models.FleetServerStateAllocated:
+			status.Allocated++
+		case models.FleetServerStateUnhealthy:
+			status.Unhealthy++
+		default:
+			status.Pending++
+		}
+	}
+
+	return status, nil
+}
+
+// getFleet returns a fleet or ErrFleetNotFound
+func (s *Scheduler) getFleet(ctx context.Context, fleetID string) (*models.Fleet, error) {
+	fleet, err := s.fleetRepo.GetByID(ctx, fleetID)
+	if err != nil {
+		return nil, err
+	}
+	if fleet == nil {
+		return nil, ErrFleetNotFound
+	}
+	return fleet, nil
+}
+
+// validateFleetConfig validates a fleet's server template like CreateServer
+// does, so a bad template fails when the fleet is saved
+func (s *Scheduler) validateFleetConfig(gameType string, config *models.ServerConfig) error {
+	settings, envVars, err := s.gameTypes.Validate(gameType, config.Settings, config.EnvVars)
+	if err != nil {
+		return err
+	}
+	config.Settings = settings
+	config.EnvVars = envVars
+
+	return validateServerConfig(gameType, config)
+}
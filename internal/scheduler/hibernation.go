@@ -0,0 +1,288 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/game-server/controller/internal/core/models"
+	"go.uber.org/zap"
+)
+
+// hibernationCheckInterval is how often idle policies and wake times are checked
+const hibernationCheckInterval = time.Minute
+
+// wakeTimeFormat is the format of IdlePolicy.WakeTimes
+const wakeTimeFormat = "15:04"
+
+// ErrNotHibernating is returned when waking a server that is not hibernating
+var ErrNotHibernating = errors.New("server is not hibernating")
+
+// HibernationReport summarizes the capacity freed by hibernating servers
+type HibernationReport struct {
+	HibernatingServers int                `json:"hibernating_servers"`
+	FreedMemoryMB      int64              `json:"freed_memory_mb"`
+	Nodes              []NodeHibernation  `json:"nodes"`
+	Servers            []HibernatedServer `json:"servers"`
+}
+
+// NodeHibernation is the capacity freed by hibernating servers on one node
+type NodeHibernation struct {
+	NodeID             string `json:"node_id"`
+	HibernatingServers int    `json:"hibernating_servers"`
+	FreedMemoryMB      int64  `json:"freed_memory_mb"`
+}
+
+// HibernatedServer describes a hibernating server
+type HibernatedServer struct {
+	ServerID     string     `json:"server_id"`
+	Name         string     `json:"name"`
+	NodeID       string     `json:"node_id"`
+	HibernatedAt time.Time  `json:"hibernated_at"`
+	MemoryMB     int64      `json:"memory_mb"`
+	NextWake     *time.Time `json:"next_wake,omitempty"`
+}
+
+// RunHibernation hibernates idle servers and wakes scheduled ones until ctx
+// is cancelled
+func (s *Scheduler) RunHibernation(ctx context.Context) {
+	ticker := time.NewTicker(hibernationCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.checkIdleServers(ctx)
+			s.checkWakeTimes(ctx)
+		}
+	}
+}
+
+// checkIdleServers hibernates running servers that have had no players for
+// longer than their idle policy allows
+func (s *Scheduler) checkIdleServers(ctx context.Context) {
+	servers, err := s.serverRepo.List(ctx, &models.ServerFilters{Status: models.ServerStatusRunning})
+	if err != nil {
+		s.logger.Error("Failed to list servers for hibernation", zap.Error(err))
+		return
+	}
+
+	now := time.Now()
+	running := make(map[string]bool, len(servers))
+	for _, server := range servers {
+		running[server.ID] = true
+
+		if server.IdlePolicy == nil || server.IdlePolicy.IdleMinutes <= 0 || server.PlayerCount > 0 {
+			delete(s.idleSince, server.ID)
+			continue
+		}
+
+		since, exists := s.idleSince[server.ID]
+		if !exists {
+			s.idleSince[server.ID] = now
+			continue
+		}
+
+		if now.Sub(since) < time.Duration(server.IdlePolicy.IdleMinutes)*time.Minute {
+			continue
+		}
+
+		delete(s.idleSince, server.ID)
+		go func(serverID string) {
+			if err := s.HibernateServer(ctx, serverID); err != nil {
+				s.logger.Error("Failed to hibernate idle server",
+					zap.Error(err),
+					zap.String("server_id", serverID))
+			}
+		}(server.ID)
+	}
+
+	// Idle time restarts when a server stops running
+	for id := range s.idleSince {
+		if !running[id] {
+			delete(s.idleSince, id)
+		}
+	}
+}
+
+// checkWakeTimes wakes hibernating servers whose wake time has passed since
+// they were hibernated
+func (s *Scheduler) checkWakeTimes(ctx context.Context) {
+	servers, err := s.serverRepo.List(ctx, &models.ServerFilters{Status: models.ServerStatusHibernating})
+	if err != nil {
+		s.logger.Error("Failed to list hibernating servers", zap.Error(err))
+		return
+	}
+
+	now := time.Now()
+	for _, server := range servers {
+		if server.IdlePolicy == nil || !server.HibernatedAt.Valid {
+			continue
+		}
+		// Servers on drained nodes wake once the node is back in service
+		if s.checkNodeAvailable(server.NodeID) != nil {
+			continue
+		}
+
+		wake := lastWakeTime(server.IdlePolicy, now)
+		if wake == nil || !wake.After(server.HibernatedAt.Time) {
+			continue
+		}
+
+		s.logger.Info("Waking server on schedule",
+			zap.String("server_id", server.ID),
+			zap.Time("wake_time", *wake))
+		if err := s.WakeServer(ctx, server.ID); err != nil {
+			s.logger.Error("Failed to wake server",
+				zap.Error(err),
+				zap.String("server_id", server.ID))
+		}
+	}
+}
+
+// HibernateServer gracefully stops a server and marks it as hibernating
+func (s *Scheduler) HibernateServer(ctx context.Context, serverID string) error {
+	server, err := s.serverRepo.GetByID(ctx, serverID)
+	if err != nil {
+		return fmt.Errorf("failed to get server: %w", err)
+	}
+	if server == nil {
+		return fmt.Errorf("server not found: %s", serverID)
+	}
+
+	// Record the memory before stopping, metrics drop to zero afterwards
+	memoryMB := server.MemoryUsage
+
+	if err := s.StopServerGracefully(ctx, serverID, models.StopOptions{Graceful: true}); err != nil {
+		return err
+	}
+
+	if err := s.serverRepo.SetHibernated(ctx, serverID, memoryMB); err != nil {
+		return err
+	}
+
+	s.logger.Info("Server hibernated",
+		zap.String("server_id", serverID),
+		zap.Int64("memory_mb", memoryMB))
+
+	return nil
+}
+
+// WakeServer starts a hibernating server
+func (s *Scheduler) WakeServer(ctx context.Context, serverID string) error {
+	server, err := s.serverRepo.GetByID(ctx, serverID)
+	if err != nil {
+		return fmt.Errorf("failed to get server: %w", err)
+	}
+	if server == nil {
+		return fmt.Errorf("server not found: %s", serverID)
+	}
+	if server.Status != models.ServerStatusHibernating {
+		return ErrNotHibernating
+	}
+
+	if err := s.StartServer(ctx, serverID); err != nil {
+		return err
+	}
+
+	s.logger.Info("Server woken", zap.String("server_id", serverID))
+
+	return nil
+}
+
+// GetHibernationReport returns the capacity freed by hibernating servers
+func (s *Scheduler) GetHibernationReport(ctx context.Context) (*HibernationReport, error) {
+	servers, err := s.serverRepo.List(ctx, &models.ServerFilters{Status: models.ServerStatusHibernating})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list hibernating servers: %w", err)
+	}
+
+	now := time.Now()
+	report := &HibernationReport{
+		Nodes:   []NodeHibernation{},
+		Servers: make([]HibernatedServer, 0, len(servers)),
+	}
+	nodes := make(map[string]*NodeHibernation)
+
+	for _, server := range servers {
+		hibernated := HibernatedServer{
+			ServerID: server.ID,
+			Name:     server.Name,
+			NodeID:   server.NodeID,
+			MemoryMB: server.HibernatedMemoryMB,
+		}
+		if server.HibernatedAt.Valid {
+			hibernated.HibernatedAt = server.HibernatedAt.Time
+		}
+		if server.IdlePolicy != nil {
+			hibernated.NextWake = nextWakeTime(server.IdlePolicy, now)
+		}
+		report.Servers = append(report.Servers, hibernated)
+
+		report.HibernatingServers++
+		report.FreedMemoryMB += server.HibernatedMemoryMB
+
+		n, exists := nodes[server.NodeID]
+		if !exists {
+			n = &NodeHibernation{NodeID: server.NodeID}
+			nodes[server.NodeID] = n
+		}
+		n.HibernatingServers++
+		n.FreedMemoryMB += server.HibernatedMemoryMB
+	}
+
+	for _, n := range nodes {
+		report.Nodes = append(report.Nodes, *n)
+	}
+	sort.Slice(report.Nodes, func(i, j int) bool {
+		return report.Nodes[i].NodeID < report.Nodes[j].NodeID
+	})
+
+	return report, nil
+}
+
+// lastWakeTime returns the most recent wake time at or before now (UTC)
+func lastWakeTime(policy *models.IdlePolicy, now time.Time) *time.Time {
+	now = now.UTC()
+	var last *time.Time
+	for _, wakeTime := range policy.WakeTimes {
+		t, err := time.Parse(wakeTimeFormat, wakeTime)
+		if err != nil {
+			continue
+		}
+
+		candidate := time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, time.UTC)
+		if candidate.After(now) {
+			candidate = candidate.AddDate(0, 0, -1)
+		}
+		if last == nil || candidate.After(*last) {
+			last = &candidate
+		}
+	}
+	return last
+}
+
+// nextWakeTime returns the next wake time after now (UTC)
+func nextWakeTime(policy *models.IdlePolicy, now time.Time) *time.Time {
+	now = now.UTC()
+	var next *time.Time
+	for _, wakeTime := range policy.WakeTimes {
+		t, err := time.Parse(wakeTimeFormat, wakeTime)
+		if err != nil {
+			continue
+		}
+
+		candidate := time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, time.UTC)
+		if !candidate.After(now) {
+			candidate = candidate.AddDate(0, 0, 1)
+		}
+		if next == nil || candidate.Before(*next) {
+			next = &candidate
+		}
+	}
+	return next
+}
@@ -0,0 +1,488 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/game-server/controller/internal/core/models"
+	"github.com/game-server/controller/internal/docker"
+	"go.uber.org/zap"
+)
+
+// hostInfoTimeout bounds asking a host's daemon for its info
+const hostInfoTimeout = 5 * time.Second
+
+var (
+	// ErrHostNotFound is returned when a host does not exist
+	ErrHostNotFound = errors.New("host not found")
+	// ErrInvalidHost is returned for a host request that cannot be applied
+	ErrInvalidHost = errors.New("invalid host")
+	// ErrHostUnreachable is returned when a host's daemon cannot be reached
+	ErrHostUnreachable = errors.New("host is unreachable")
+	// ErrHostInUse is returned when removing a host that still has nodes
+	ErrHostInUse = errors.New("host has nodes")
+	// ErrNoHostCapacity is returned when no host has room for a new node
+	ErrNoHostCapacity = errors.New("no host has capacity for the node")
+)
+
+// LoadHosts connects the registered hosts at startup. A host that cannot be
+// set up is logged and left out; it is listed as not connected.
+func (s *Scheduler) LoadHosts(ctx context.Context) error {
+	hosts, err := s.hostRepo.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, host := range hosts {
+		if err := s.connectHost(host); err != nil {
+			s.logger.Warn("Failed to connect host",
+				zap.Error(err),
+				zap.String("host_id", host.ID),
+				zap.String("name", host.Name))
+		}
+	}
+
+	s.logger.Info("Loaded hosts", zap.Int("hosts", len(hosts)))
+
+	return nil
+}
+
+// RegisterHost registers a Docker endpoint that nodes can be placed on. The
+// daemon must be reachable; capacity left out defaults to the CPUs and memory
+// it reports.
+func (s *Scheduler) RegisterHost(ctx context.Context, req *models.CreateHostRequest) (*models.HostStatus, error) {
+	if req.Name == models.LocalHostID {
+		return nil, fmt.Errorf("%w: the name %q is reserved", ErrInvalidHost, models.LocalHostID)
+	}
+	if (req.TLSCert == "") != (req.TLSKey == "") {
+		return nil, fmt.Errorf("%w: tls_cert and tls_key must be set together", ErrInvalidHost)
+	}
+	existing, err := s.hostRepo.GetByName(ctx, req.Name)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, fmt.Errorf("%w: a host named %s already exists", ErrInvalidHost, req.Name)
+	}
+
+	runtime, err := docker.NewRemoteDockerRuntime(req.Endpoint, tlsCerts(req.TLSCACert, req.TLSCert, req.TLSKey))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidHost, err)
+	}
+
+	infoCtx, cancel := context.WithTimeout(ctx, hostInfoTimeout)
+	info, err := runtime.Info(infoCtx)
+	cancel()
+	if err != nil {
+		runtime.Close()
+		return nil, fmt.Errorf("%w: %v", ErrHostUnreachable, err)
+	}
+
+	host := &models.Host{
+		Name:              req.Name,
+		Endpoint:          req.Endpoint,
+		TLSCACert:         req.TLSCACert,
+		TLSCert:           req.TLSCert,
+		ControllerAddress: req.ControllerAddress,
+		NetworkName:       req.NetworkName,
+		HostCapacity: models.HostCapacity{
+			MaxNodes: req.MaxNodes,
+			CPUCores: req.CPUCores,
+			MemoryMB: req.MemoryMB,
+		},
+	}
+	if host.CPUCores == 0 {
+		host.CPUCores = info.CPUs
+	}
+	if host.MemoryMB == 0 {
+		host.MemoryMB = info.MemoryBytes / (1024 * 1024)
+	}
+	if req.TLSKey != "" {
+		host.TLSKey, err = s.secrets.Encrypt(req.TLSKey)
+		if err != nil {
+			runtime.Close()
+			return nil, fmt.Errorf("failed to encrypt TLS key: %w", err)
+		}
+	}
+
+	if err := s.hostRepo.Create(ctx, host); err != nil {
+		runtime.Close()
+		return nil, err
+	}
+
+	s.nodeMgr.Hosts().Add(&docker.Host{
+		ID:                host.ID,
+		Runtime:           runtime,
+		ControllerAddress: host.ControllerAddress,
+		NetworkName:       host.NetworkName,
+	})
+
+	s.logger.Info("Host registered",
+		zap.String("host_id", host.ID),
+		zap.String("name", host.Name),
+		zap.String("runtime_version", info.Version))
+
+	return &models.HostStatus{
+		Host:      host,
+		Connected: true,
+		Runtime:   info.Name,
+		Version:   info.Version,
+	}, nil
+}
+
+// ListHosts returns the local host, if it is connected, and the registered
+// hosts with their state and the nodes on them
+func (s *Scheduler) ListHosts(ctx context.Context) ([]*models.HostStatus, error) {
+	hosts, err := s.allHosts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := s.nodeMgr.ListNodes()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*models.HostStatus, 0, len(hosts))
+	for _, host := range hosts {
+		result = append(result, s.hostStatus(ctx, host, nodes))
+	}
+	return result, nil
+}
+
+// GetHost returns a host with its state and the nodes on it
+func (s *Scheduler) GetHost(ctx context.Context, hostID string) (*models.HostStatus, error) {
+	host, err := s.getHost(ctx, hostID)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := s.nodeMgr.ListNodes()
+	if err != nil {
+		return nil, err
+	}
+
+	return s.hostStatus(ctx, host, nodes), nil
+}
+
+// UpdateHost changes the controller address or capacity of a registered
+// host. Nodes already on the host keep running; a new controller address
+// applies when their containers are recreated.
+func (s *Scheduler) UpdateHost(ctx context.Context, hostID string, req *models.UpdateHostRequest) (*models.HostStatus, error) {
+	if hostID == models.LocalHostID {
+		return nil, fmt.Errorf("%w: the local host is configured in config.yaml", ErrInvalidHost)
+	}
+	host, err := s.getHost(ctx, hostID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.ControllerAddress != nil {
+		host.ControllerAddress = *req.ControllerAddress
+	}
+	if req.MaxNodes != nil {
+		host.MaxNodes = *req.MaxNodes
+	}
+	if req.CPUCores != nil {
+		host.CPUCores = *req.CPUCores
+	}
+	if req.MemoryMB != nil {
+		host.MemoryMB = *req.MemoryMB
+	}
+
+	if err := s.hostRepo.Update(ctx, host); err != nil {
+		return nil, err
+	}
+
+	// Keep the connection, only the controller address changes
+	if connected, err := s.nodeMgr.Hosts().Get(hostID); err == nil {
+		s.nodeMgr.Hosts().Add(&docker.Host{
+			ID:                host.ID,
+			Runtime:           connected.Runtime,
+			ControllerAddress: host.ControllerAddress,
+			NetworkName:       host.NetworkName,
+		})
+	}
+
+	return s.GetHost(ctx, hostID)
+}
+
+// RemoveHost unregisters a host. Its nodes must be deleted first.
+func (s *Scheduler) RemoveHost(ctx context.Context, hostID string) error {
+	if hostID == models.LocalHostID {
+		return fmt.Errorf("%w: the local host cannot be removed", ErrInvalidHost)
+	}
+	if _, err := s.getHost(ctx, hostID); err != nil {
+		return err
+	}
+
+	nodes, err := s.nodeMgr.ListNodes()
+	if err != nil {
+		return err
+	}
+	count := 0
+	for _, n := range nodes {
+		if n.HostID == hostID {
+			count++
+		}
+	}
+	if count > 0 {
+		return fmt.Errorf("%w: %d nodes are on host %s", ErrHostInUse, count, hostID)
+	}
+
+	if err := s.hostRepo.Delete(ctx, hostID); err != nil {
+		return err
+	}
+	s.nodeMgr.Hosts().Remove(hostID)
+
+	s.hostLocksMu.Lock()
+	delete(s.hostLocks, hostID)
+	s.hostLocksMu.Unlock()
+
+	s.logger.Info("Host removed", zap.String("host_id", hostID))
+
+	return nil
+}
+
+// SelectHost returns the host a new node with the given limits goes on. A
+// requested host must be connected and have room for the node; otherwise the
+// host placement strategy picks among the connected hosts with room.
+//
+// The host stays locked for placement until release is called, which the
+// caller does once the node is stored, so concurrent calls cannot both take
+// the last room on a host.
+func (s *Scheduler) SelectHost(ctx context.Context, hostID string, resources models.NodeResources) (string, func(), error) {
+	strategy, err := models.ParseHostPlacementStrategy(s.cfg.HostPlacementStrategy)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var hosts []*models.Host
+	if hostID != "" {
+		host, err := s.getHost(ctx, hostID)
+		if err != nil {
+			return "", nil, err
+		}
+		hosts = []*models.Host{host}
+	} else {
+		hosts, err = s.allHosts(ctx)
+		if err != nil {
+			return "", nil, err
+		}
+	}
+
+	nodes, err := s.nodeMgr.ListNodes()
+	if err != nil {
+		return "", nil, err
+	}
+
+	var candidates []*models.HostStatus
+	for _, host := range hosts {
+		if hostID == "" && host.ID == models.LocalHostID && !s.cfg.LocalHostPlacement {
+			continue
+		}
+		if _, err := s.nodeMgr.Hosts().Get(host.ID); err != nil {
+			continue
+		}
+		if status := hostUsage(host, nodes); hostFits(status, resources) {
+			candidates = append(candidates, status)
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if strategy == models.HostPlacementPack {
+			return candidates[i].Nodes > candidates[j].Nodes
+		}
+		return candidates[i].Nodes < candidates[j].Nodes
+	})
+
+	// Another placement may have taken the room since the nodes were listed,
+	// so check again while holding the host
+	for _, candidate := range candidates {
+		lock := s.hostLock(candidate.ID)
+		lock.Lock()
+		fits, err := s.hostHasRoom(candidate.Host, "", resources)
+		if err != nil {
+			lock.Unlock()
+			return "", nil, err
+		}
+		if fits {
+			return candidate.ID, lock.Unlock, nil
+		}
+		lock.Unlock()
+	}
+
+	if hostID != "" {
+		if _, err := s.nodeMgr.Hosts().Get(hostID); err != nil {
+			return "", nil, fmt.Errorf("%w: %v", ErrHostUnreachable, err)
+		}
+		return "", nil, fmt.Errorf("%w: host %s is full", ErrNoHostCapacity, hostID)
+	}
+	return "", nil, ErrNoHostCapacity
+}
+
+// hostLock returns the mutex that serialises node placement and resizes on
+// a host
+func (s *Scheduler) hostLock(hostID string) *sync.Mutex {
+	s.hostLocksMu.Lock()
+	defer s.hostLocksMu.Unlock()
+
+	lock, exists := s.hostLocks[hostID]
+	if !exists {
+		lock = &sync.Mutex{}
+		s.hostLocks[hostID] = lock
+	}
+	return lock
+}
+
+// hostHasRoom reports whether a host has room for a node with the given
+// limits, leaving out the node excludeNodeID when it is being resized
+func (s *Scheduler) hostHasRoom(host *models.Host, excludeNodeID string, resources models.NodeResources) (bool, error) {
+	nodes, err := s.nodeMgr.ListNodes()
+	if err != nil {
+		return false, err
+	}
+
+	others := make([]*models.Node, 0, len(nodes))
+	for _, n := range nodes {
+		if n.ID != excludeNodeID {
+			others = append(others, n)
+		}
+	}
+	return hostFits(hostUsage(host, others), resources), nil
+}
+
+// connectHost builds the runtime of a registered host and adds it to the
+// connected hosts
+func (s *Scheduler) connectHost(host *models.Host) error {
+	key := host.TLSKey
+	if key != "" {
+		var err error
+		key, err = s.secrets.Decrypt(key)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt TLS key: %w", err)
+		}
+	}
+
+	runtime, err := docker.NewRemoteDockerRuntime(host.Endpoint, tlsCerts(host.TLSCACert, host.TLSCert, key))
+	if err != nil {
+		return err
+	}
+
+	s.nodeMgr.Hosts().Add(&docker.Host{
+		ID:                host.ID,
+		Runtime:           runtime,
+		ControllerAddress: host.ControllerAddress,
+		NetworkName:       host.NetworkName,
+	})
+
+	return nil
+}
+
+// allHosts returns the local host, if it is connected, followed by the
+// registered hosts
+func (s *Scheduler) allHosts(ctx context.Context) ([]*models.Host, error) {
+	registered, err := s.hostRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	hosts := make([]*models.Host, 0, len(registered)+1)
+	if _, err := s.nodeMgr.Hosts().Get(models.LocalHostID); err == nil {
+		hosts = append(hosts, s.localHost())
+	}
+	return append(hosts, registered...), nil
+}
+
+// getHost returns the local host or a registered host
+func (s *Scheduler) getHost(ctx context.Context, hostID string) (*models.Host, error) {
+	if hostID == models.LocalHostID {
+		return s.localHost(), nil
+	}
+
+	host, err := s.hostRepo.GetByID(ctx, hostID)
+	if err != nil {
+		return nil, err
+	}
+	if host == nil {
+		return nil, fmt.Errorf("%w: %s", ErrHostNotFound, hostID)
+	}
+	return host, nil
+}
+
+// localHost describes the controller's own container runtime, which has no
+// capacity limits
+func (s *Scheduler) localHost() *models.Host {
+	return &models.Host{
+		ID:          models.LocalHostID,
+		Name:        models.LocalHostID,
+		Endpoint:    s.cfg.ContainerRuntime,
+		NetworkName: s.cfg.NodeNetworkName,
+	}
+}
+
+// hostStatus returns a host's usage and asks its daemon whether it is
+// reachable
+func (s *Scheduler) hostStatus(ctx context.Context, host *models.Host, nodes []*models.Node) *models.HostStatus {
+	status := hostUsage(host, nodes)
+	status.Local = host.ID == models.LocalHostID
+
+	connected, err := s.nodeMgr.Hosts().Get(host.ID)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+
+	infoCtx, cancel := context.WithTimeout(ctx, hostInfoTimeout)
+	info, err := connected.Runtime.Info(infoCtx)
+	cancel()
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+
+	status.Connected = true
+	status.Runtime = info.Name
+	status.Version = info.Version
+	return status
+}
+
+// hostUsage returns a host with the number and limits of the nodes on it
+func hostUsage(host *models.Host, nodes []*models.Node) *models.HostStatus {
+	status := &models.HostStatus{Host: host}
+	for _, n := range nodes {
+		if n.HostID != host.ID {
+			continue
+		}
+		status.Nodes++
+		status.AllocatedCPUCores += n.CPUCores
+		status.AllocatedMemoryMB += n.MemoryMB
+	}
+	return status
+}
+
+// hostFits reports whether a host has room for another node with the given
+// limits
+func hostFits(status *models.HostStatus, resources models.NodeResources) bool {
+	if status.MaxNodes > 0 && status.Nodes+1 > status.MaxNodes {
+		return false
+	}
+	if status.CPUCores > 0 && status.AllocatedCPUCores+resources.CPUCores > status.CPUCores {
+		return false
+	}
+	if status.MemoryMB > 0 && status.AllocatedMemoryMB+resources.MemoryMB > status.MemoryMB {
+		return false
+	}
+	return true
+}
+
+// tlsCerts returns the TLS certificates of a host, or nil if it does not use
+// TLS
+func tlsCerts(caCert, cert, key string) *docker.TLSCerts {
+	if caCert == "" && cert == "" && key == "" {
+		return nil
+	}
+	return &docker.TLSCerts{CACert: caCert, Cert: cert, Key: key}
+}
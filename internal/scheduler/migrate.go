@@ -0,0 +1,338 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/game-server/controller/internal/core/models"
+	"github.com/game-server/controller/internal/node"
+	"go.uber.org/zap"
+)
+
+// migrationCopyTimeout bounds copying a server's data between node volumes
+const migrationCopyTimeout = 30 * time.Minute
+
+// registerTimeout is how long to wait for a target node to adopt a migrated server
+const registerTimeout = 60 * time.Second
+
+// MigrationPhase identifies the step of a migration that failed
+type MigrationPhase string
+
+const (
+	MigrationPhasePrepare  MigrationPhase = "prepare"  // checks on the server and target node
+	MigrationPhaseStop     MigrationPhase = "stop"
+	MigrationPhaseCopy     MigrationPhase = "copy"     // copying the data directory between volumes
+	MigrationPhaseRegister MigrationPhase = "register" // target node adopting the server and allocating ports
+	MigrationPhaseUpdate   MigrationPhase = "update"   // moving the database record
+	MigrationPhaseStart    MigrationPhase = "start"
+)
+
+// MigrationError reports which phase of a migration failed. The server is
+// rolled back to its source node.
+type MigrationError struct {
+	ServerID string
+	Phase    MigrationPhase
+	Err      error
+}
+
+// Error implements the error interface
+func (e *MigrationError) Error() string {
+	return fmt.Sprintf("migration of server %s failed during %s: %v", e.ServerID, e.Phase, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *MigrationError) Unwrap() error {
+	return e.Err
+}
+
+var (
+	// ErrSameNode is returned when migrating a server to the node it is on
+	ErrSameNode = errors.New("server is already on the target node")
+	// ErrMigrationInProgress is returned when migrating a server that is
+	// already being migrated
+	ErrMigrationInProgress = errors.New("server is already being migrated")
+)
+
+// MigrationDuration returns an upper bound for how long a migration with the
+// given options can take
+func MigrationDuration(opts models.StopOptions) time.Duration {
+	return GracefulStopDuration(opts) + migrationCopyTimeout + registerTimeout + defaultStartTimeout
+}
+
+// MigrateServer moves a server to another node. A running server is stopped
+// gracefully, its data is copied to the target node's volume, the target
+// node adopts it with new ports, and it is started there. If any step fails
+// the server is returned to its source node, and restarted there if it was
+// running. Only one migration of a server runs at a time. Failures are
+// returned as *MigrationError.
+func (s *Scheduler) MigrateServer(ctx context.Context, serverID, targetNodeID string, opts models.StopOptions) error {
+	server, err := s.serverRepo.GetByID(ctx, serverID)
+	if err != nil {
+		return fmt.Errorf("failed to get server: %w", err)
+	}
+	if server == nil {
+		return fmt.Errorf("server not found: %s", serverID)
+	}
+
+	fail := func(phase MigrationPhase, err error) error {
+		s.logger.Error("Server migration failed",
+			zap.Error(err),
+			zap.String("server_id", serverID),
+			zap.String("phase", string(phase)))
+		return &MigrationError{ServerID: serverID, Phase: phase, Err: err}
+	}
+
+	if !s.claimMigration(serverID) {
+		return fail(MigrationPhasePrepare, ErrMigrationInProgress)
+	}
+	defer s.releaseMigration(serverID)
+
+	if err := s.checkMigrationTarget(server, targetNodeID); err != nil {
+		return fail(MigrationPhasePrepare, err)
+	}
+
+	source := *server
+	wasActive := isActiveServer(server)
+
+	s.logger.Info("Migrating server",
+		zap.String("server_id", serverID),
+		zap.String("from_node", source.NodeID),
+		zap.String("to_node", targetNodeID))
+
+	if wasActive {
+		opts.Graceful = true
+		if err := s.StopServerGracefully(ctx, serverID, opts); err != nil {
+			return fail(MigrationPhaseStop, err)
+		}
+	}
+
+	// From here on the server must not be started on the source node
+	if err := s.serverRepo.UpdateStatus(ctx, serverID, models.ServerStatusMigrating); err != nil {
+		return fail(MigrationPhasePrepare, err)
+	}
+
+	// restore puts the server back on its source node
+	restore := func() {
+		ctx := context.Background()
+		s.serverRepo.UpdateStatus(ctx, serverID, inactiveStatus(&source))
+		if wasActive {
+			if err := s.startAndWait(ctx, &source); err != nil {
+				s.logger.Error("Failed to restart server on source node after failed migration",
+					zap.Error(err),
+					zap.String("server_id", serverID))
+			}
+		}
+	}
+	// removeCopy deletes the server's data from the target node
+	removeCopy := func() {
+		if err := s.nodeMgr.RemoveServerData(context.Background(), targetNodeID, serverID); err != nil {
+			s.logger.Warn("Failed to remove migrated data from target node",
+				zap.Error(err),
+				zap.String("server_id", serverID),
+				zap.String("node_id", targetNodeID))
+		}
+	}
+
+	copyCtx, cancel := context.WithTimeout(ctx, migrationCopyTimeout)
+	err = s.nodeMgr.CopyServerData(copyCtx, serverID, source.NodeID, targetNodeID)
+	cancel()
+	if err != nil {
+		removeCopy()
+		restore()
+		return fail(MigrationPhaseCopy, err)
+	}
+
+	result, err := s.registerMigratedServer(ctx, server, targetNodeID)
+	if err != nil {
+		removeCopy()
+		restore()
+		return fail(MigrationPhaseRegister, err)
+	}
+
+	// unregister removes the server from the target node again
+	unregister := func() {
+		s.sendDeleteServer(targetNodeID, serverID)
+		removeCopy()
+	}
+
+	port, queryPort, rconPort := migratedPorts(result)
+	if err := s.serverRepo.UpdatePlacement(ctx, serverID, targetNodeID, s.serverAddress(ctx, targetNodeID), port, queryPort, rconPort); err != nil {
+		unregister()
+		restore()
+		return fail(MigrationPhaseUpdate, err)
+	}
+
+	target := *server
+	target.NodeID = targetNodeID
+	if wasActive {
+		if err := s.startAndWait(ctx, &target); err != nil {
+			if err := s.serverRepo.UpdatePlacement(context.Background(), serverID, source.NodeID, source.IPAddress, source.Port, source.QueryPort, source.RCONPort); err != nil {
+				s.logger.Error("Failed to restore server placement",
+					zap.Error(err),
+					zap.String("server_id", serverID))
+			}
+			unregister()
+			restore()
+			return fail(MigrationPhaseStart, err)
+		}
+		if err := s.serverRepo.UpdateStatus(ctx, serverID, models.ServerStatusRunning); err != nil {
+			return fmt.Errorf("failed to update status: %w", err)
+		}
+	} else {
+		if err := s.serverRepo.UpdateStatus(ctx, serverID, inactiveStatus(&source)); err != nil {
+			return fmt.Errorf("failed to update status: %w", err)
+		}
+	}
+
+	// The source copy is only removed once the server runs on the target
+	s.sendDeleteServer(source.NodeID, serverID)
+
+	// The target node's server.properties has not been read yet
+	if err := s.serverRepo.ClearPropertiesReport(ctx, serverID); err != nil {
+		s.logger.Warn("Failed to clear properties report",
+			zap.Error(err),
+			zap.String("server_id", serverID))
+	}
+
+	s.logger.Info("Server migrated",
+		zap.String("server_id", serverID),
+		zap.String("from_node", source.NodeID),
+		zap.String("to_node", targetNodeID))
+
+	return nil
+}
+
+// claimMigration marks a server as being migrated, or returns false if it
+// already is
+func (s *Scheduler) claimMigration(serverID string) bool {
+	s.migrationsMu.Lock()
+	defer s.migrationsMu.Unlock()
+
+	if s.migrations[serverID] {
+		return false
+	}
+	s.migrations[serverID] = true
+	return true
+}
+
+// releaseMigration ends a server's claim from claimMigration
+func (s *Scheduler) releaseMigration(serverID string) {
+	s.migrationsMu.Lock()
+	delete(s.migrations, serverID)
+	s.migrationsMu.Unlock()
+}
+
+// checkMigrationTarget checks that a server can move to a node
+func (s *Scheduler) checkMigrationTarget(server *models.Server, targetNodeID string) error {
+	if server.NodeID == targetNodeID {
+		return ErrSameNode
+	}
+	if server.Status == models.ServerStatusMigrating {
+		return ErrMigrationInProgress
+	}
+
+	target, err := s.nodeMgr.GetNode(targetNodeID)
+	if err != nil {
+		return err
+	}
+	if target.Status != models.NodeStatusOnline {
+		return fmt.Errorf("target node %s is %s", targetNodeID, target.Status)
+	}
+	if target.GameType != server.GameType {
+		return fmt.Errorf("target node %s runs %s servers, not %s", targetNodeID, target.GameType, server.GameType)
+	}
+
+	// Server data is copied between volumes on one host
+	source, err := s.nodeMgr.GetNode(server.NodeID)
+	if err != nil {
+		return err
+	}
+	if source.HostID != target.HostID {
+		return fmt.Errorf("%w: target node %s is on host %s, the server is on host %s",
+			node.ErrCrossHost, targetNodeID, target.HostID, source.HostID)
+	}
+
+	return nil
+}
+
+// registerMigratedServer asks a node to adopt a server whose data was copied
+// to its volume, and to allocate ports for it
+func (s *Scheduler) registerMigratedServer(ctx context.Context, server *models.Server, nodeID string) (*node.CommandResult, error) {
+	rconPassword, err := s.ensureRCONPassword(ctx, server.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get RCON password: %w", err)
+	}
+
+	// The source ports may be taken on the target. Leave them out, like for
+	// a new server, so the node writes the ports it allocates.
+	adopted := *server
+	adopted.Port, adopted.QueryPort, adopted.RCONPort = 0, 0, 0
+
+	cmd := &node.Command{
+		ID:   generateCommandID(),
+		Type: node.CommandTypeCreateServer,
+		Payload: map[string]interface{}{
+			"server_id":     server.ID,
+			"game_type":     server.GameType,
+			"config":        serverConfigOf(server),
+			"rcon_password": rconPassword,
+			"config_files":  configFiles(&adopted, rconPassword),
+			"existing_data": true, // adopt the copied data directory instead of installing
+		},
+		Response: make(chan *node.CommandResult, 1),
+	}
+
+	return s.sendCommandAndWait(ctx, nodeID, cmd, registerTimeout)
+}
+
+// sendDeleteServer removes a server from a node, keeping no backup
+func (s *Scheduler) sendDeleteServer(nodeID, serverID string) {
+	cmd := &node.Command{
+		ID:   generateCommandID(),
+		Type: node.CommandTypeDeleteServer,
+		Payload: map[string]interface{}{
+			"server_id":            serverID,
+			"backup_before_delete": false,
+		},
+		Response: make(chan *node.CommandResult, 1),
+	}
+
+	if err := s.nodeMgr.SendCommand(nodeID, cmd); err != nil {
+		s.logger.Warn("Failed to send delete command",
+			zap.Error(err),
+			zap.String("node_id", nodeID),
+			zap.String("server_id", serverID))
+	}
+}
+
+// migratedPorts returns the ports a node allocated for an adopted server.
+// Ports the node did not report are left at 0 until it reports them.
+func migratedPorts(result *node.CommandResult) (int, int, int) {
+	return result.Ports["port"], result.Ports["query_port"], result.Ports["rcon_port"]
+}
+
+// inactiveStatus returns the status a server that is not running returns to:
+// hibernating and errored servers keep their status, others are stopped
+func inactiveStatus(server *models.Server) models.ServerStatus {
+	if server.Status == models.ServerStatusHibernating || server.Status == models.ServerStatusError {
+		return server.Status
+	}
+	return models.ServerStatusStopped
+}
+
+// serverConfigOf returns the configuration a server was created with
+func serverConfigOf(server *models.Server) models.ServerConfig {
+	return models.ServerConfig{
+		Name:       server.Name,
+		Version:    server.Version,
+		Settings:   server.Settings,
+		EnvVars:    server.EnvVars,
+		MaxPlayers: server.MaxPlayers,
+		WorldName:  server.WorldName,
+		OnlineMode: server.OnlineMode,
+		QueryType:  server.QueryType,
+		IdlePolicy: server.IdlePolicy,
+	}
+}
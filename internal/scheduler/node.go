@@ -0,0 +1,195 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/game-server/controller/internal/core/models"
+	"go.uber.org/zap"
+)
+
+const (
+	// nodeRecreateTimeout bounds pulling an agent image and recreating a
+	// node container
+	nodeRecreateTimeout = 5 * time.Minute
+	// nodeRegisterTimeout is how long a recreated node agent has to register
+	nodeRegisterTimeout = 2 * time.Minute
+)
+
+// ErrNodeHasActiveServers is returned when an operation would kill servers
+// that are still active on a node
+var ErrNodeHasActiveServers = errors.New("node has active servers")
+
+// ResizeNode changes the container limits of a node. The container is
+// recreated, so every server on the node must be stopped first, for example
+// by draining it. The new limits must fit on the node's host.
+func (s *Scheduler) ResizeNode(ctx context.Context, nodeID string, resources models.NodeResources) error {
+	if err := s.checkNoActiveServers(ctx, nodeID); err != nil {
+		return err
+	}
+
+	n, err := s.nodeMgr.GetNode(nodeID)
+	if err != nil {
+		return err
+	}
+	host, err := s.getHost(ctx, n.HostID)
+	if err != nil {
+		return err
+	}
+
+	// Hold the host until the new limits are stored, so a node placed
+	// meanwhile sees them
+	lock := s.hostLock(host.ID)
+	lock.Lock()
+	defer lock.Unlock()
+
+	fits, err := s.hostHasRoom(host, nodeID, resources)
+	if err != nil {
+		return err
+	}
+	if !fits {
+		return fmt.Errorf("%w: host %s has no room for the new limits", ErrNoHostCapacity, host.ID)
+	}
+
+	return s.nodeMgr.ResizeNode(ctx, nodeID, resources)
+}
+
+// checkNoActiveServers returns ErrNodeHasActiveServers if a server on the
+// node is active
+func (s *Scheduler) checkNoActiveServers(ctx context.Context, nodeID string) error {
+	servers, err := s.serverRepo.List(ctx, &models.ServerFilters{NodeID: nodeID})
+	if err != nil {
+		return fmt.Errorf("failed to list node servers: %w", err)
+	}
+
+	active := 0
+	for _, server := range servers {
+		if isActiveServer(server) {
+			active++
+		}
+	}
+	if active > 0 {
+		return fmt.Errorf("%w: %d server(s) must be stopped first", ErrNodeHasActiveServers, active)
+	}
+
+	return nil
+}
+
+// StartNode starts a node's stopped container
+func (s *Scheduler) StartNode(ctx context.Context, nodeID string) error {
+	return s.nodeMgr.StartNodeContainer(ctx, nodeID)
+}
+
+// StopNode stops a node's container. Every server on the node must be
+// stopped first.
+func (s *Scheduler) StopNode(ctx context.Context, nodeID string) error {
+	if err := s.checkNoActiveServers(ctx, nodeID); err != nil {
+		return err
+	}
+
+	return s.nodeMgr.StopNodeContainer(ctx, nodeID)
+}
+
+// RestartNode restarts a node's container. Every server on the node must be
+// stopped first.
+func (s *Scheduler) RestartNode(ctx context.Context, nodeID string) error {
+	if err := s.checkNoActiveServers(ctx, nodeID); err != nil {
+		return err
+	}
+
+	return s.nodeMgr.RestartNodeContainer(ctx, nodeID)
+}
+
+// RecreateNode replaces a node's container with a new one from the same
+// image, keeping its volumes. Every server on the node must be stopped first.
+func (s *Scheduler) RecreateNode(ctx context.Context, nodeID string) (string, error) {
+	if err := s.checkNoActiveServers(ctx, nodeID); err != nil {
+		return "", err
+	}
+
+	recreateCtx, cancel := context.WithTimeout(ctx, nodeRecreateTimeout)
+	defer cancel()
+
+	return s.nodeMgr.RecreateNodeContainer(recreateCtx, nodeID, "")
+}
+
+// NodeUpgradeDuration returns an upper bound for how long UpgradeNode can
+// take, including a rollback
+func NodeUpgradeDuration() time.Duration {
+	return 2*nodeRecreateTimeout + nodeRegisterTimeout
+}
+
+// UpgradeNode recreates a node's container from a new agent image (the
+// configured one if empty) and waits for the agent to register again. If it
+// does not register in time, or reports a version other than
+// expectedVersion when one is given, the node is rolled back to the image ID
+// its container ran before, which the tag may no longer point to. Every
+// server on the node must be stopped first.
+func (s *Scheduler) UpgradeNode(ctx context.Context, nodeID, image, expectedVersion string) (*models.NodeUpgrade, error) {
+	if err := s.checkNoActiveServers(ctx, nodeID); err != nil {
+		return nil, err
+	}
+	if image == "" {
+		image = s.nodeMgr.AgentImage()
+	}
+
+	previous := s.nodeMgr.ContainerImage(ctx, nodeID)
+	previousID := s.nodeMgr.ContainerImageID(ctx, nodeID)
+	rollbackImage := previousID
+	if rollbackImage == "" {
+		rollbackImage = previous
+	}
+	since := time.Now()
+
+	s.logger.Info("Upgrading node",
+		zap.String("node_id", nodeID),
+		zap.String("image", image),
+		zap.String("previous_image", previous),
+		zap.String("previous_image_id", previousID))
+
+	recreateCtx, cancel := context.WithTimeout(ctx, nodeRecreateTimeout)
+	_, err := s.nodeMgr.RecreateNodeContainer(recreateCtx, nodeID, image)
+	cancel()
+	if err != nil {
+		return nil, err
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, nodeRegisterTimeout)
+	node, err := s.nodeMgr.WaitForRegistration(waitCtx, nodeID, since)
+	cancel()
+	if err == nil && expectedVersion != "" && node.AgentVersion != expectedVersion {
+		err = fmt.Errorf("node %s registered with agent version %q, expected %q", nodeID, node.AgentVersion, expectedVersion)
+	}
+	if err != nil {
+		s.rollbackUpgrade(nodeID, rollbackImage)
+		return nil, fmt.Errorf("upgrade failed, rolled back to %s (%s): %w", previous, rollbackImage, err)
+	}
+
+	s.logger.Info("Node upgraded",
+		zap.String("node_id", nodeID),
+		zap.String("image", image),
+		zap.String("agent_version", node.AgentVersion))
+
+	return &models.NodeUpgrade{
+		NodeID:          nodeID,
+		Image:           image,
+		PreviousImage:   previous,
+		PreviousImageID: previousID,
+		AgentVersion:    node.AgentVersion,
+	}, nil
+}
+
+// rollbackUpgrade recreates a node's container from its previous image
+func (s *Scheduler) rollbackUpgrade(nodeID, previous string) {
+	ctx, cancel := context.WithTimeout(context.Background(), nodeRecreateTimeout)
+	defer cancel()
+
+	if _, err := s.nodeMgr.RecreateNodeContainer(ctx, nodeID, previous); err != nil {
+		s.logger.Error("Failed to roll back node upgrade",
+			zap.Error(err),
+			zap.String("node_id", nodeID),
+			zap.String("image", previous))
+	}
+}
@@ -0,0 +1,163 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/game-server/controller/internal/core/models"
+	"github.com/game-server/controller/internal/minecraft"
+	"go.uber.org/zap"
+)
+
+// ErrPlayerNotInList is returned when removing a player that is not in a list
+var ErrPlayerNotInList = errors.New("player is not in the list")
+
+// ErrOpLevelRunning is returned when an op level is given for a running
+// server, which can only op players at its op-permission-level
+var ErrOpLevelRunning = errors.New("an op level can only be set while the server is stopped")
+
+// ListPlayers returns the entries of a player list of a Minecraft server
+func (s *Scheduler) ListPlayers(ctx context.Context, serverID string, list minecraft.PlayerList) ([]minecraft.PlayerEntry, error) {
+	server, err := s.getMinecraftServer(ctx, serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := s.readServerFile(ctx, server, list.File())
+	if err != nil {
+		return nil, err
+	}
+
+	return minecraft.ParsePlayerEntries(data)
+}
+
+// AddPlayer adds a player to a list. Running servers are changed live over
+// RCON, stopped servers by editing the list file on the node.
+func (s *Scheduler) AddPlayer(ctx context.Context, serverID string, list minecraft.PlayerList, req models.PlayerListRequest) (*minecraft.PlayerEntry, error) {
+	server, err := s.getMinecraftServer(ctx, serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	id, name, err := minecraft.ResolvePlayer(ctx, req.Name, server.OnlineMode)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := minecraft.NewPlayerEntry(list, id, name, req.Level, sanitizeReason(req.Reason))
+
+	if server.Status == models.ServerStatusRunning {
+		if list == minecraft.PlayerListOps && req.Level != 0 {
+			return nil, ErrOpLevelRunning
+		}
+		if _, err := s.ExecuteRCON(ctx, serverID, list.AddCommand(name, entry.Reason)); err != nil {
+			return nil, err
+		}
+	} else {
+		entries, err := s.ListPlayers(ctx, serverID, list)
+		if err != nil {
+			return nil, err
+		}
+
+		// Replace an existing entry for the same player
+		kept := entries[:0]
+		for _, e := range entries {
+			if !strings.EqualFold(e.Name, name) && e.UUID != id {
+				kept = append(kept, e)
+			}
+		}
+
+		if err := s.writePlayerEntries(ctx, server, list, append(kept, entry)); err != nil {
+			return nil, err
+		}
+	}
+
+	s.logger.Info("Player added to list",
+		zap.String("server_id", serverID),
+		zap.String("list", string(list)),
+		zap.String("player", name))
+
+	return &entry, nil
+}
+
+// RemovePlayer removes a player from a list. Running servers are changed live
+// over RCON, stopped servers by editing the list file on the node.
+func (s *Scheduler) RemovePlayer(ctx context.Context, serverID string, list minecraft.PlayerList, name string) error {
+	server, err := s.getMinecraftServer(ctx, serverID)
+	if err != nil {
+		return err
+	}
+
+	if err := minecraft.ValidatePlayerName(name); err != nil {
+		return err
+	}
+
+	if server.Status == models.ServerStatusRunning {
+		if _, err := s.ExecuteRCON(ctx, serverID, list.RemoveCommand(name)); err != nil {
+			return err
+		}
+	} else {
+		entries, err := s.ListPlayers(ctx, serverID, list)
+		if err != nil {
+			return err
+		}
+
+		kept := entries[:0]
+		for _, e := range entries {
+			if !strings.EqualFold(e.Name, name) {
+				kept = append(kept, e)
+			}
+		}
+		if len(kept) == len(entries) {
+			return ErrPlayerNotInList
+		}
+
+		if err := s.writePlayerEntries(ctx, server, list, kept); err != nil {
+			return err
+		}
+	}
+
+	s.logger.Info("Player removed from list",
+		zap.String("server_id", serverID),
+		zap.String("list", string(list)),
+		zap.String("player", name))
+
+	return nil
+}
+
+// getMinecraftServer returns a server, failing if it is not a Minecraft server
+func (s *Scheduler) getMinecraftServer(ctx context.Context, serverID string) (*models.Server, error) {
+	server, err := s.serverRepo.GetByID(ctx, serverID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get server: %w", err)
+	}
+	if server == nil {
+		return nil, fmt.Errorf("server not found: %s", serverID)
+	}
+	if server.GameType != minecraft.GameType {
+		return nil, fmt.Errorf("player lists are only supported for Minecraft servers")
+	}
+	return server, nil
+}
+
+func (s *Scheduler) writePlayerEntries(ctx context.Context, server *models.Server, list minecraft.PlayerList, entries []minecraft.PlayerEntry) error {
+	data, err := minecraft.FormatPlayerEntries(entries)
+	if err != nil {
+		return err
+	}
+	return s.writeServerFile(ctx, server, list.File(), data)
+}
+
+// sanitizeReason strips control characters so a ban reason stays a single
+// console command
+func sanitizeReason(reason string) string {
+	return strings.TrimSpace(strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, reason))
+}
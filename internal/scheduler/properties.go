@@ -0,0 +1,128 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/game-server/controller/internal/core/models"
+	"github.com/game-server/controller/internal/minecraft"
+	"go.uber.org/zap"
+)
+
+// PropertiesStatus describes the rendered and reported server.properties of a server
+type PropertiesStatus struct {
+	ServerID   string                 `json:"server_id"`
+	Rendered   map[string]string      `json:"rendered"`
+	Reported   map[string]string      `json:"reported,omitempty"`
+	ReportedAt *time.Time             `json:"reported_at,omitempty"`
+	Drift      *minecraft.DriftReport `json:"drift,omitempty"`
+}
+
+// RecordPropertiesReport stores the server.properties reported by a node,
+// with secrets redacted, and logs a warning if it has drifted from the
+// rendered configuration
+func (s *Scheduler) RecordPropertiesReport(ctx context.Context, report *models.ServerPropertiesReport) {
+	if report.ReportedAt.IsZero() {
+		report.ReportedAt = time.Now()
+	}
+
+	server, err := s.serverRepo.GetByID(ctx, report.ServerID)
+	if err != nil || server == nil {
+		return
+	}
+
+	stored := *report
+	stored.Properties = minecraft.RedactProperties(report.Properties)
+	if err := s.serverRepo.SetPropertiesReport(ctx, &stored); err != nil {
+		s.logger.Error("Failed to store properties report",
+			zap.Error(err),
+			zap.String("server_id", server.ID))
+	}
+
+	drift := minecraft.DetectDrift(minecraft.RenderProperties(server), report.Properties)
+	if !drift.InSync {
+		s.logger.Warn("server.properties drift detected",
+			zap.String("server_id", server.ID),
+			zap.Int("changed", len(drift.Changed)),
+			zap.Int("missing", len(drift.Missing)))
+	}
+}
+
+// RefreshPropertiesReport reads server.properties from a Minecraft server's
+// node and records it as the node's report
+func (s *Scheduler) RefreshPropertiesReport(ctx context.Context, server *models.Server) error {
+	data, err := s.readServerFile(ctx, server, minecraft.PropertiesFile)
+	if err != nil {
+		return err
+	}
+
+	props, err := minecraft.ParseProperties(string(data))
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", minecraft.PropertiesFile, err)
+	}
+
+	s.RecordPropertiesReport(ctx, &models.ServerPropertiesReport{
+		ServerID:   server.ID,
+		Properties: props,
+		ReportedAt: time.Now(),
+	})
+	return nil
+}
+
+// GetPropertiesStatus returns the rendered server.properties of a server and,
+// if the node's file has been read, the drift between the two. The file is
+// read again first while the node is connected; otherwise the last stored
+// copy is used.
+func (s *Scheduler) GetPropertiesStatus(ctx context.Context, serverID string) (*PropertiesStatus, error) {
+	server, err := s.serverRepo.GetByID(ctx, serverID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get server: %w", err)
+	}
+	if server == nil {
+		return nil, fmt.Errorf("server not found: %s", serverID)
+	}
+	if server.GameType != minecraft.GameType {
+		return nil, fmt.Errorf("server.properties is only managed for %s servers", minecraft.GameType)
+	}
+
+	if s.nodeMgr.IsConnected(server.NodeID) {
+		if err := s.RefreshPropertiesReport(ctx, server); err != nil {
+			s.logger.Warn("Failed to read server.properties from node",
+				zap.Error(err),
+				zap.String("server_id", serverID))
+		}
+	}
+
+	status := &PropertiesStatus{
+		ServerID: serverID,
+		Rendered: minecraft.RenderProperties(server),
+	}
+
+	report, err := s.serverRepo.GetPropertiesReport(ctx, serverID)
+	if err != nil {
+		return nil, err
+	}
+	if report != nil {
+		status.Reported = report.Properties
+		status.ReportedAt = &report.ReportedAt
+		status.Drift = minecraft.DetectDrift(status.Rendered, report.Properties)
+	}
+
+	return status, nil
+}
+
+// configFiles renders the game config files that are shipped with create and
+// update commands, keyed by file name
+func configFiles(server *models.Server, rconPassword string) map[string]string {
+	if server.GameType != minecraft.GameType {
+		return nil
+	}
+
+	props := minecraft.RenderProperties(server)
+	props[minecraft.RCONPasswordKey] = rconPassword
+
+	return map[string]string{
+		minecraft.PropertiesFile: minecraft.FormatProperties(props),
+	}
+}
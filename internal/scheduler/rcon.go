@@ -0,0 +1,108 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/game-server/controller/internal/rcon"
+	"github.com/game-server/controller/pkg/secrets"
+	"go.uber.org/zap"
+)
+
+// rconTimeout bounds connecting to and waiting for an RCON server
+const rconTimeout = 10 * time.Second
+
+// ExecuteRCON runs a console command on a server over RCON and returns the response
+func (s *Scheduler) ExecuteRCON(ctx context.Context, serverID string, command string) (string, error) {
+	server, err := s.serverRepo.GetByID(ctx, serverID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get server: %w", err)
+	}
+	if server == nil {
+		return "", fmt.Errorf("server not found: %s", serverID)
+	}
+	if server.RCONPort == 0 {
+		return "", fmt.Errorf("server has no RCON port assigned")
+	}
+
+	encrypted, err := s.serverRepo.GetRCONPassword(ctx, serverID)
+	if err != nil {
+		return "", err
+	}
+	if encrypted == "" {
+		return "", fmt.Errorf("server has no RCON password, update the server to generate one")
+	}
+
+	password, err := s.secrets.Decrypt(encrypted)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt RCON password: %w", err)
+	}
+
+	host, err := s.nodeMgr.NodeAddress(ctx, server.NodeID)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve node address: %w", err)
+	}
+
+	address := net.JoinHostPort(host, strconv.Itoa(server.RCONPort))
+	client, err := rcon.Dial(address, password, rconTimeout)
+	if err != nil {
+		return "", err
+	}
+	defer client.Close()
+
+	response, err := client.Execute(command)
+	if err != nil {
+		return "", err
+	}
+
+	s.logger.Info("RCON command executed",
+		zap.String("server_id", serverID),
+		zap.String("command", command))
+
+	return response, nil
+}
+
+// serverAddress returns the address game servers on a node listen on, for
+// clients of the server. It is empty if the node's container cannot be
+// found, for example for an agent the controller did not start.
+func (s *Scheduler) serverAddress(ctx context.Context, nodeID string) string {
+	address, err := s.nodeMgr.NodeAddress(ctx, nodeID)
+	if err != nil {
+		s.logger.Warn("Failed to resolve node address",
+			zap.Error(err),
+			zap.String("node_id", nodeID))
+		return ""
+	}
+	return address
+}
+
+// ensureRCONPassword returns the RCON password of a server, generating and
+// storing a new one if the server does not have one yet
+func (s *Scheduler) ensureRCONPassword(ctx context.Context, serverID string) (string, error) {
+	encrypted, err := s.serverRepo.GetRCONPassword(ctx, serverID)
+	if err != nil {
+		return "", err
+	}
+	if encrypted != "" {
+		return s.secrets.Decrypt(encrypted)
+	}
+
+	password, err := secrets.GeneratePassword()
+	if err != nil {
+		return "", err
+	}
+
+	encrypted, err = s.secrets.Encrypt(password)
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt RCON password: %w", err)
+	}
+
+	if err := s.serverRepo.SetRCONPassword(ctx, serverID, encrypted); err != nil {
+		return "", err
+	}
+
+	return password, nil
+}
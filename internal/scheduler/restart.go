@@ -0,0 +1,127 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/game-server/controller/internal/core/models"
+	"github.com/game-server/controller/internal/node"
+	"go.uber.org/zap"
+)
+
+// defaultStartTimeout is how long to wait for a node to confirm a start
+const defaultStartTimeout = 120 * time.Second
+
+// RestartPhase identifies the step of a restart that failed
+type RestartPhase string
+
+const (
+	RestartPhasePrepare RestartPhase = "prepare" // countdown and world save
+	RestartPhaseStop    RestartPhase = "stop"
+	RestartPhaseStart   RestartPhase = "start"
+	RestartPhaseRestart RestartPhase = "restart" // single restart command handled by the node
+)
+
+// RestartError reports which phase of a restart failed
+type RestartError struct {
+	ServerID string
+	Phase    RestartPhase
+	Err      error
+}
+
+// Error implements the error interface
+func (e *RestartError) Error() string {
+	return fmt.Sprintf("restart of server %s failed during %s: %v", e.ServerID, e.Phase, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *RestartError) Unwrap() error {
+	return e.Err
+}
+
+// RestartDuration returns an upper bound for how long a restart with the
+// given options can take
+func RestartDuration(opts models.StopOptions) time.Duration {
+	return GracefulStopDuration(opts) + defaultStartTimeout
+}
+
+// RestartServer restarts a server and waits for the node to confirm each
+// step. Nodes that support it restart the server with a single command;
+// otherwise the server is stopped, and only started again once the stop is
+// confirmed. Failures are returned as *RestartError.
+func (s *Scheduler) RestartServer(ctx context.Context, serverID string, opts models.StopOptions) error {
+	server, def, err := s.getServerDefinition(ctx, serverID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.serverRepo.UpdateStatus(ctx, serverID, models.ServerStatusStopping); err != nil {
+		return fmt.Errorf("failed to update status: %w", err)
+	}
+
+	if err := s.prepareStop(ctx, server, def, opts); err != nil {
+		s.serverRepo.UpdateStatus(ctx, serverID, server.Status)
+		return &RestartError{ServerID: serverID, Phase: RestartPhasePrepare, Err: err}
+	}
+
+	if s.nodeMgr.NodeSupports(server.NodeID, models.NodeCapabilityRestartServer) {
+		cmd := &node.Command{
+			ID:   generateCommandID(),
+			Type: node.CommandTypeRestartServer,
+			Payload: map[string]interface{}{
+				"server_id":       serverID,
+				"stop_command":    def.StopCommand,
+				"timeout_seconds": int(stopTimeout(opts).Seconds()),
+			},
+			Response: make(chan *node.CommandResult, 1),
+		}
+
+		timeout := stopTimeout(opts) + forceStopTimeout + defaultStartTimeout
+		if err := s.sendAndWait(ctx, server.NodeID, cmd, serverID, models.EventTypeServerStarted, timeout); err != nil {
+			s.serverRepo.UpdateStatus(ctx, serverID, models.ServerStatusError)
+			return &RestartError{ServerID: serverID, Phase: RestartPhaseRestart, Err: err}
+		}
+	} else {
+		if err := s.stopAndWait(ctx, server, def, opts); err != nil {
+			return &RestartError{ServerID: serverID, Phase: RestartPhaseStop, Err: err}
+		}
+
+		if err := s.startAndWait(ctx, server); err != nil {
+			return &RestartError{ServerID: serverID, Phase: RestartPhaseStart, Err: err}
+		}
+	}
+
+	if err := s.serverRepo.UpdateStatus(ctx, serverID, models.ServerStatusRunning); err != nil {
+		return fmt.Errorf("failed to update status: %w", err)
+	}
+
+	s.logger.Info("Server restarted",
+		zap.String("server_id", serverID),
+		zap.Bool("graceful", opts.Graceful))
+
+	return nil
+}
+
+// startAndWait sends a start command and waits for the node to confirm it
+func (s *Scheduler) startAndWait(ctx context.Context, server *models.Server) error {
+	if err := s.serverRepo.UpdateStatus(ctx, server.ID, models.ServerStatusStarting); err != nil {
+		return fmt.Errorf("failed to update status: %w", err)
+	}
+
+	cmd := &node.Command{
+		ID:   generateCommandID(),
+		Type: node.CommandTypeStartServer,
+		Payload: map[string]interface{}{
+			"server_id": server.ID,
+		},
+		Response: make(chan *node.CommandResult, 1),
+	}
+
+	if err := s.sendAndWait(ctx, server.NodeID, cmd, server.ID, models.EventTypeServerStarted, defaultStartTimeout); err != nil {
+		s.serverRepo.UpdateStatus(ctx, server.ID, models.ServerStatusStopped)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,539 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/game-server/controller/internal/core/models"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultRolloutHealthCheck is how long an upgraded node must stay
+	// healthy when the request does not say
+	defaultRolloutHealthCheck = 30 * time.Second
+	// rolloutPollInterval is how often drains and node health are checked
+	// during a rollout
+	rolloutPollInterval = 2 * time.Second
+)
+
+var (
+	// ErrRolloutInProgress is returned when starting a rollout while another
+	// one is running or paused
+	ErrRolloutInProgress = errors.New("a rollout is already in progress")
+	// ErrRolloutNotFound is returned for an unknown rollout ID
+	ErrRolloutNotFound = errors.New("rollout not found")
+	// ErrRolloutState is returned when a rollout cannot be paused, resumed or
+	// cancelled in its current state
+	ErrRolloutState = errors.New("rollout is not in a state that allows this")
+	// ErrInvalidRollout is returned for a rollout request with an unknown
+	// server policy or that selects no node
+	ErrInvalidRollout = errors.New("invalid rollout request")
+)
+
+// nodeRollout is a rolling upgrade in progress or a finished one
+type nodeRollout struct {
+	progress    models.Rollout
+	stop        models.StopOptions
+	healthCheck time.Duration
+	// active is set while a goroutine works through the rollout's batches
+	active bool
+}
+
+// StartRollout upgrades the agents of the selected nodes in batches in the
+// background. Each node gets its servers stopped or drained according to
+// the server policy, is upgraded and must stay healthy for the health check
+// period before the next batch starts. A failed node pauses the rollout.
+func (s *Scheduler) StartRollout(ctx context.Context, req models.RolloutRequest) (*models.Rollout, error) {
+	policy, err := models.ParseRolloutServerPolicy(req.ServerPolicy)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRollout, err)
+	}
+
+	nodes, err := s.rolloutNodes(req)
+	if err != nil {
+		return nil, err
+	}
+
+	image := req.Image
+	if image == "" {
+		image = s.nodeMgr.AgentImage()
+	}
+	batchSize := req.BatchSize
+	if batchSize < 1 {
+		batchSize = 1
+	}
+	healthCheck := time.Duration(req.HealthCheckSeconds) * time.Second
+	if healthCheck == 0 {
+		healthCheck = defaultRolloutHealthCheck
+	}
+
+	rollout := &nodeRollout{
+		progress: models.Rollout{
+			ID:           uuid.New().String(),
+			Image:        image,
+			AgentVersion: req.AgentVersion,
+			BatchSize:    batchSize,
+			ServerPolicy: policy,
+			State:        models.RolloutStateRunning,
+			Total:        len(nodes),
+			Nodes:        make([]models.RolloutNodeProgress, 0, len(nodes)),
+			StartedAt:    time.Now(),
+		},
+		stop:        req.StopOptions,
+		healthCheck: healthCheck,
+		active:      true,
+	}
+	rollout.stop.Graceful = true
+	for _, node := range nodes {
+		rollout.progress.Nodes = append(rollout.progress.Nodes, models.RolloutNodeProgress{
+			NodeID: node.ID,
+			Name:   node.Name,
+			State:  models.RolloutNodePending,
+		})
+	}
+
+	s.rolloutsMu.Lock()
+	for _, other := range s.rollouts {
+		if other.progress.State == models.RolloutStateRunning || other.progress.State == models.RolloutStatePaused {
+			s.rolloutsMu.Unlock()
+			return nil, fmt.Errorf("%w: %s", ErrRolloutInProgress, other.progress.ID)
+		}
+	}
+	s.rollouts[rollout.progress.ID] = rollout
+	progress := copyRollout(&rollout.progress)
+	s.rolloutsMu.Unlock()
+
+	s.logger.Info("Starting rollout",
+		zap.String("rollout_id", rollout.progress.ID),
+		zap.String("image", image),
+		zap.Int("nodes", len(nodes)),
+		zap.Int("batch_size", batchSize),
+		zap.String("server_policy", string(policy)))
+
+	go s.runRollout(rollout)
+
+	return progress, nil
+}
+
+// GetRollout returns the progress of a rollout
+func (s *Scheduler) GetRollout(rolloutID string) (*models.Rollout, error) {
+	s.rolloutsMu.Lock()
+	defer s.rolloutsMu.Unlock()
+
+	rollout, exists := s.rollouts[rolloutID]
+	if !exists {
+		return nil, ErrRolloutNotFound
+	}
+	return copyRollout(&rollout.progress), nil
+}
+
+// ListRollouts returns all rollouts since the controller started, newest first
+func (s *Scheduler) ListRollouts() []*models.Rollout {
+	s.rolloutsMu.Lock()
+	defer s.rolloutsMu.Unlock()
+
+	rollouts := make([]*models.Rollout, 0, len(s.rollouts))
+	for _, rollout := range s.rollouts {
+		rollouts = append(rollouts, copyRollout(&rollout.progress))
+	}
+	sort.Slice(rollouts, func(i, j int) bool {
+		return rollouts[i].StartedAt.After(rollouts[j].StartedAt)
+	})
+	return rollouts
+}
+
+// PauseRollout stops a running rollout from starting new batches. Nodes
+// being upgraded finish first.
+func (s *Scheduler) PauseRollout(rolloutID string) (*models.Rollout, error) {
+	s.rolloutsMu.Lock()
+	defer s.rolloutsMu.Unlock()
+
+	rollout, exists := s.rollouts[rolloutID]
+	if !exists {
+		return nil, ErrRolloutNotFound
+	}
+	if rollout.progress.State != models.RolloutStateRunning {
+		return nil, fmt.Errorf("%w: rollout is %s", ErrRolloutState, rollout.progress.State)
+	}
+
+	rollout.progress.State = models.RolloutStatePaused
+	rollout.progress.PauseReason = "paused by request"
+
+	s.logger.Info("Rollout paused", zap.String("rollout_id", rolloutID))
+
+	return copyRollout(&rollout.progress), nil
+}
+
+// ResumeRollout continues a paused rollout. Failed nodes are retried.
+func (s *Scheduler) ResumeRollout(rolloutID string) (*models.Rollout, error) {
+	s.rolloutsMu.Lock()
+	defer s.rolloutsMu.Unlock()
+
+	rollout, exists := s.rollouts[rolloutID]
+	if !exists {
+		return nil, ErrRolloutNotFound
+	}
+	if rollout.progress.State != models.RolloutStatePaused {
+		return nil, fmt.Errorf("%w: rollout is %s", ErrRolloutState, rollout.progress.State)
+	}
+
+	for i := range rollout.progress.Nodes {
+		if rollout.progress.Nodes[i].State == models.RolloutNodeFailed {
+			rollout.progress.Nodes[i].State = models.RolloutNodePending
+			rollout.progress.Nodes[i].Error = ""
+			rollout.progress.Failed--
+		}
+	}
+	rollout.progress.State = models.RolloutStateRunning
+	rollout.progress.PauseReason = ""
+
+	// The goroutine may still be finishing the batch it was on when paused
+	if !rollout.active {
+		rollout.active = true
+		go s.runRollout(rollout)
+	}
+
+	s.logger.Info("Rollout resumed", zap.String("rollout_id", rolloutID))
+
+	return copyRollout(&rollout.progress), nil
+}
+
+// CancelRollout stops a running or paused rollout for good. Nodes being
+// upgraded finish first, pending nodes are left as they are.
+func (s *Scheduler) CancelRollout(rolloutID string) (*models.Rollout, error) {
+	s.rolloutsMu.Lock()
+	defer s.rolloutsMu.Unlock()
+
+	rollout, exists := s.rollouts[rolloutID]
+	if !exists {
+		return nil, ErrRolloutNotFound
+	}
+	if rollout.progress.State != models.RolloutStateRunning && rollout.progress.State != models.RolloutStatePaused {
+		return nil, fmt.Errorf("%w: rollout is %s", ErrRolloutState, rollout.progress.State)
+	}
+
+	s.finishRollout(rollout, models.RolloutStateCancelled)
+
+	s.logger.Info("Rollout cancelled", zap.String("rollout_id", rolloutID))
+
+	return copyRollout(&rollout.progress), nil
+}
+
+// rolloutNodes returns the nodes a rollout request selects
+func (s *Scheduler) rolloutNodes(req models.RolloutRequest) ([]*models.Node, error) {
+	var nodes []*models.Node
+	if len(req.NodeIDs) > 0 {
+		for _, nodeID := range req.NodeIDs {
+			node, err := s.nodeMgr.GetNode(nodeID)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidRollout, err)
+			}
+			nodes = append(nodes, node)
+		}
+	} else {
+		all, err := s.nodeMgr.ListNodes()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list nodes: %w", err)
+		}
+		nodes = all
+	}
+
+	selected := make([]*models.Node, 0, len(nodes))
+	seen := make(map[string]bool, len(nodes))
+	for _, node := range nodes {
+		if seen[node.ID] || (req.GameType != "" && node.GameType != req.GameType) {
+			continue
+		}
+		seen[node.ID] = true
+		selected = append(selected, node)
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("%w: no nodes to upgrade", ErrInvalidRollout)
+	}
+
+	return selected, nil
+}
+
+// runRollout upgrades the pending nodes of a rollout one batch at a time
+// until it completes, is paused or is cancelled
+func (s *Scheduler) runRollout(rollout *nodeRollout) {
+	for {
+		s.rolloutsMu.Lock()
+		if rollout.progress.State != models.RolloutStateRunning {
+			rollout.active = false
+			s.rolloutsMu.Unlock()
+			return
+		}
+		var batch []int
+		for i := range rollout.progress.Nodes {
+			if len(batch) == rollout.progress.BatchSize {
+				break
+			}
+			if rollout.progress.Nodes[i].State == models.RolloutNodePending {
+				batch = append(batch, i)
+			}
+		}
+		if len(batch) == 0 {
+			s.finishRollout(rollout, models.RolloutStateCompleted)
+			rollout.active = false
+			s.rolloutsMu.Unlock()
+			s.logger.Info("Rollout completed",
+				zap.String("rollout_id", rollout.progress.ID),
+				zap.Int("nodes", rollout.progress.Total))
+			return
+		}
+		s.rolloutsMu.Unlock()
+
+		var wg sync.WaitGroup
+		for _, i := range batch {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				err := s.upgradeRolloutNode(rollout, i)
+				s.finishRolloutNode(rollout, i, err)
+			}(i)
+		}
+		wg.Wait()
+	}
+}
+
+// upgradeRolloutNode takes one node of a rollout through preparing,
+// upgrading, verifying and putting its servers back
+func (s *Scheduler) upgradeRolloutNode(rollout *nodeRollout, i int) error {
+	ctx := context.Background()
+	nodeID := rollout.progress.Nodes[i].NodeID
+
+	s.setRolloutNodeState(rollout, i, models.RolloutNodePreparing)
+	switch rollout.progress.ServerPolicy {
+	case models.RolloutServerPolicyStop:
+		if err := s.stopRolloutServers(ctx, rollout, i); err != nil {
+			return err
+		}
+	case models.RolloutServerPolicyDrain:
+		if err := s.drainAndWait(ctx, nodeID, rollout.stop, s.rolloutTargetsExcluded(rollout)); err != nil {
+			return err
+		}
+		s.rolloutsMu.Lock()
+		rollout.progress.Nodes[i].Drained = true
+		s.rolloutsMu.Unlock()
+	}
+
+	s.setRolloutNodeState(rollout, i, models.RolloutNodeUpgrading)
+	upgrade, err := s.UpgradeNode(ctx, nodeID, rollout.progress.Image, rollout.progress.AgentVersion)
+	if err != nil {
+		return err
+	}
+
+	s.rolloutsMu.Lock()
+	rollout.progress.Nodes[i].PreviousImage = upgrade.PreviousImage
+	rollout.progress.Nodes[i].AgentVersion = upgrade.AgentVersion
+	s.rolloutsMu.Unlock()
+
+	s.setRolloutNodeState(rollout, i, models.RolloutNodeVerifying)
+	if err := s.verifyNodeHealth(ctx, nodeID, rollout.healthCheck); err != nil {
+		return err
+	}
+
+	switch rollout.progress.ServerPolicy {
+	case models.RolloutServerPolicyStop:
+		return s.startRolloutServers(ctx, rollout, i)
+	case models.RolloutServerPolicyDrain:
+		if err := s.UndrainNode(ctx, nodeID); err != nil {
+			return err
+		}
+		s.rolloutsMu.Lock()
+		rollout.progress.Nodes[i].Drained = false
+		s.rolloutsMu.Unlock()
+	}
+	return nil
+}
+
+// rolloutTargetsExcluded returns the nodes of a rollout that are not
+// upgraded yet. Servers drained off a node are not migrated to them, since
+// they would have to move again or land on a node being upgraded.
+func (s *Scheduler) rolloutTargetsExcluded(rollout *nodeRollout) []string {
+	s.rolloutsMu.Lock()
+	defer s.rolloutsMu.Unlock()
+
+	var exclude []string
+	for _, node := range rollout.progress.Nodes {
+		if node.State != models.RolloutNodeUpgraded {
+			exclude = append(exclude, node.NodeID)
+		}
+	}
+	return exclude
+}
+
+// stopRolloutServers gracefully stops the active servers of a rollout node
+// and records them so they are started again after the upgrade
+func (s *Scheduler) stopRolloutServers(ctx context.Context, rollout *nodeRollout, i int) error {
+	nodeID := rollout.progress.Nodes[i].NodeID
+	servers, err := s.serverRepo.List(ctx, &models.ServerFilters{NodeID: nodeID})
+	if err != nil {
+		return fmt.Errorf("failed to list node servers: %w", err)
+	}
+
+	for _, server := range servers {
+		if !isActiveServer(server) {
+			continue
+		}
+		if err := s.StopServerGracefully(ctx, server.ID, rollout.stop); err != nil {
+			return fmt.Errorf("failed to stop server %s: %w", server.ID, err)
+		}
+
+		// A retried node keeps the servers stopped by its earlier attempts
+		s.rolloutsMu.Lock()
+		rollout.progress.Nodes[i].StoppedServers = append(rollout.progress.Nodes[i].StoppedServers, server.ID)
+		s.rolloutsMu.Unlock()
+	}
+
+	return nil
+}
+
+// startRolloutServers starts the servers stopped for a rollout node's upgrade
+func (s *Scheduler) startRolloutServers(ctx context.Context, rollout *nodeRollout, i int) error {
+	s.rolloutsMu.Lock()
+	serverIDs := append([]string(nil), rollout.progress.Nodes[i].StoppedServers...)
+	s.rolloutsMu.Unlock()
+
+	for n, serverID := range serverIDs {
+		if err := s.StartServer(ctx, serverID); err != nil {
+			s.rolloutsMu.Lock()
+			rollout.progress.Nodes[i].StoppedServers = serverIDs[n:]
+			s.rolloutsMu.Unlock()
+			return fmt.Errorf("failed to start server %s: %w", serverID, err)
+		}
+	}
+
+	s.rolloutsMu.Lock()
+	rollout.progress.Nodes[i].StoppedServers = nil
+	s.rolloutsMu.Unlock()
+
+	return nil
+}
+
+// drainAndWait migrates the servers off a node, to nodes not in exclude,
+// and waits for the drain to finish
+func (s *Scheduler) drainAndWait(ctx context.Context, nodeID string, stop models.StopOptions, exclude []string) error {
+	opts := models.DrainOptions{Policy: models.DrainPolicyMigrate, StopOptions: stop, ExcludeTargets: exclude}
+	if _, err := s.DrainNode(ctx, nodeID, opts); err != nil {
+		return fmt.Errorf("failed to drain node: %w", err)
+	}
+
+	ticker := time.NewTicker(rolloutPollInterval)
+	defer ticker.Stop()
+
+	for {
+		progress, err := s.GetDrainProgress(nodeID)
+		if err != nil {
+			return err
+		}
+		switch progress.State {
+		case models.DrainStateCompleted:
+			return nil
+		case models.DrainStateFailed:
+			return fmt.Errorf("drain failed, %d server(s) could not be migrated", progress.Failed)
+		case models.DrainStateCancelled:
+			return errors.New("drain was cancelled")
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// verifyNodeHealth fails if a node disconnects or turns unhealthy within the
+// health check period
+func (s *Scheduler) verifyNodeHealth(ctx context.Context, nodeID string, period time.Duration) error {
+	ticker := time.NewTicker(rolloutPollInterval)
+	defer ticker.Stop()
+	deadline := time.Now().Add(period)
+
+	for {
+		if !s.nodeMgr.IsConnected(nodeID) {
+			return fmt.Errorf("node %s disconnected after the upgrade", nodeID)
+		}
+		node, err := s.nodeMgr.GetNode(nodeID)
+		if err != nil {
+			return err
+		}
+		if node.Status == models.NodeStatusUnhealthy || node.Status == models.NodeStatusOffline {
+			return fmt.Errorf("node %s is %s after the upgrade", nodeID, node.Status)
+		}
+		if !time.Now().Before(deadline) {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// setRolloutNodeState records the state of a node in a rollout
+func (s *Scheduler) setRolloutNodeState(rollout *nodeRollout, i int, state models.RolloutNodeState) {
+	s.rolloutsMu.Lock()
+	defer s.rolloutsMu.Unlock()
+
+	rollout.progress.Nodes[i].State = state
+}
+
+// finishRolloutNode records the outcome of a node's upgrade. A failure
+// pauses a running rollout.
+func (s *Scheduler) finishRolloutNode(rollout *nodeRollout, i int, err error) {
+	s.rolloutsMu.Lock()
+	defer s.rolloutsMu.Unlock()
+
+	progress := &rollout.progress.Nodes[i]
+	if err == nil {
+		progress.State = models.RolloutNodeUpgraded
+		rollout.progress.Upgraded++
+		return
+	}
+
+	progress.State = models.RolloutNodeFailed
+	progress.Error = err.Error()
+	if progress.Drained {
+		progress.Error += " (the node stays drained)"
+	}
+	rollout.progress.Failed++
+
+	s.logger.Error("Rollout node upgrade failed",
+		zap.Error(err),
+		zap.String("rollout_id", rollout.progress.ID),
+		zap.String("node_id", progress.NodeID))
+
+	if rollout.progress.State == models.RolloutStateRunning {
+		rollout.progress.State = models.RolloutStatePaused
+		rollout.progress.PauseReason = fmt.Sprintf("node %s failed: %s", progress.Name, err)
+	}
+}
+
+// finishRollout ends a rollout. rolloutsMu must be held.
+func (s *Scheduler) finishRollout(rollout *nodeRollout, state models.RolloutState) {
+	now := time.Now()
+	rollout.progress.State = state
+	rollout.progress.PauseReason = ""
+	rollout.progress.CompletedAt = &now
+}
+
+func copyRollout(rollout *models.Rollout) *models.Rollout {
+	r := *rollout
+	r.Nodes = make([]models.RolloutNodeProgress, len(rollout.Nodes))
+	for i, node := range rollout.Nodes {
+		node.StoppedServers = append([]string(nil), node.StoppedServers...)
+		r.Nodes[i] = node
+	}
+	return &r
+}
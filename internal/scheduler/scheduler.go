@@ -3,41 +3,155 @@ package scheduler
 import (
 	"context"
 	"fmt"
+	"slices"
+	"sync"
 	"time"
 
 	"github.com/game-server/controller/internal/core/models"
 	"github.com/game-server/controller/internal/core/repository"
+	"github.com/game-server/controller/internal/gametype"
 	"github.com/game-server/controller/internal/node"
+	"github.com/game-server/controller/internal/query"
+	"github.com/game-server/controller/pkg/config"
+	"github.com/game-server/controller/pkg/secrets"
 	"go.uber.org/zap"
 )
 
+// createServerTimeout is how long to wait for a node to create a server
+const createServerTimeout = 60 * time.Second
+
 // Scheduler handles resource allocation and server lifecycle
 type Scheduler struct {
-	nodeRepo    *repository.NodeRepository
-	serverRepo  *repository.ServerRepository
-	nodeMgr     *node.Manager
-	logger      *zap.Logger
+	nodeRepo     *repository.NodeRepository
+	serverRepo   *repository.ServerRepository
+	fleetRepo    *repository.FleetRepository
+	templateRepo *repository.TemplateRepository
+	hostRepo     *repository.HostRepository
+	nodeMgr      *node.Manager
+	gameTypes    *gametype.Registry
+	secrets      *secrets.Box
+	cfg          *config.Config
+	logger       *zap.Logger
+
+	// Time since which running servers with an idle policy have had no
+	// players. Only used by RunHibernation.
+	idleSince map[string]time.Time
+
+	// Callers waiting for server events, keyed by server ID
+	waiters   map[string][]*eventWaiter
+	waitersMu sync.Mutex
+
+	// Serialises node placement and resizes on each host, keyed by host ID
+	hostLocks   map[string]*sync.Mutex
+	hostLocksMu sync.Mutex
+
+	// Servers being migrated, keyed by server ID
+	migrations   map[string]bool
+	migrationsMu sync.Mutex
+
+	// Serialises reconciles of each fleet, keyed by fleet ID
+	fleetLocks   map[string]*sync.Mutex
+	fleetLocksMu sync.Mutex
+
+	// Servers being created by the fleet reconciler, keyed by fleet ID, then
+	// by server name, with the node each goes on
+	fleetCreating   map[string]map[string]string
+	fleetCreatingMu sync.Mutex
+
+	// When fleets were last scaled up and the last recorded dry-run
+	// decisions, keyed by fleet ID. Only used by RunFleets.
+	lastScaleUp map[string]time.Time
+	lastDryRun  map[string]int
+
+	// Current or last drain of each node, keyed by node ID
+	drains   map[string]*nodeDrain
+	drainsMu sync.Mutex
+
+	// Rolling node upgrades, keyed by rollout ID
+	rollouts   map[string]*nodeRollout
+	rolloutsMu sync.Mutex
 }
 
 // NewScheduler creates a new scheduler
 func NewScheduler(
 	nodeRepo *repository.NodeRepository,
 	serverRepo *repository.ServerRepository,
+	fleetRepo *repository.FleetRepository,
+	templateRepo *repository.TemplateRepository,
+	hostRepo *repository.HostRepository,
 	nodeMgr *node.Manager,
+	gameTypes *gametype.Registry,
+	secretsBox *secrets.Box,
+	cfg *config.Config,
 	logger *zap.Logger,
 ) *Scheduler {
 	return &Scheduler{
-		nodeRepo:   nodeRepo,
-		serverRepo: serverRepo,
-		nodeMgr:    nodeMgr,
-		logger:     logger,
+		nodeRepo:     nodeRepo,
+		serverRepo:   serverRepo,
+		fleetRepo:    fleetRepo,
+		templateRepo: templateRepo,
+		hostRepo:     hostRepo,
+		nodeMgr:      nodeMgr,
+		gameTypes:    gameTypes,
+		secrets:      secretsBox,
+		cfg:          cfg,
+		logger:       logger,
+
+		idleSince:     make(map[string]time.Time),
+		waiters:       make(map[string][]*eventWaiter),
+		hostLocks:     make(map[string]*sync.Mutex),
+		migrations:    make(map[string]bool),
+		fleetLocks:    make(map[string]*sync.Mutex),
+		fleetCreating: make(map[string]map[string]string),
+		lastScaleUp:   make(map[string]time.Time),
+		lastDryRun:    make(map[string]int),
+		drains:        make(map[string]*nodeDrain),
+		rollouts:      make(map[string]*nodeRollout),
 	}
 }
 
 // CreateServer creates a new server on the optimal node
 func (s *Scheduler) CreateServer(ctx context.Context, req *models.CreateServerRequest) (*models.CreateServerResponse, error) {
-	// Find optimal node for the server
-	targetNode, err := s.FindOptimalNode(req.GameType, &req.Requirements)
+	if req.TemplateID != "" {
+		if err := s.applyTemplate(ctx, req); err != nil {
+			return nil, err
+		}
+	}
+	if req.GameType == "" || req.Config == nil {
+		return nil, ErrMissingConfig
+	}
+	if req.Name != "" {
+		req.Config.Name = req.Name
+	}
+	if req.Requirements == nil {
+		req.Requirements = &models.ResourceRequirements{}
+	}
+
+	// Validate settings and env vars against the game type schema
+	settings, envVars, err := s.gameTypes.Validate(req.GameType, req.Config.Settings, req.Config.EnvVars)
+	if err != nil {
+		return nil, err
+	}
+	req.Config.Settings = settings
+	req.Config.EnvVars = envVars
+
+	if err := validateServerConfig(req.GameType, req.Config); err != nil {
+		return nil, err
+	}
+
+	// Find optimal node for the server. A world is copied with a helper
+	// container on its host, so the server must go on a node there.
+	// The fleet reconciler picks the node of a fleet server itself, to
+	// spread the fleet's replicas.
+	var targetNode *models.Node
+	switch {
+	case req.World != nil:
+		targetNode, err = s.findNodeOnHost(req.GameType, req.World.HostID, nil)
+	case req.FleetID != "":
+		targetNode, err = s.nodeMgr.GetNode(req.NodeID)
+	default:
+		targetNode, err = s.FindOptimalNode(req.GameType, req.Requirements)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to find optimal node: %w", err)
 	}
@@ -57,7 +171,10 @@ func (s *Scheduler) CreateServer(ctx context.Context, req *models.CreateServerRe
 		Port:          0, // Will be assigned by node
 		QueryPort:     0,
 		RCONPort:      0,
-		IPAddress:     "localhost", // Nodes are on the same machine
+		IPAddress:     s.serverAddress(ctx, targetNode.ID),
+		QueryType:     req.Config.QueryType,
+		IdlePolicy:    req.Config.IdlePolicy,
+		FleetID:       req.FleetID,
 		PlayerCount:   0,
 		CPUUsage:      0,
 		MemoryUsage:   0,
@@ -69,15 +186,33 @@ func (s *Scheduler) CreateServer(ctx context.Context, req *models.CreateServerRe
 		return nil, fmt.Errorf("failed to create server: %w", err)
 	}
 
+	rconPassword, err := s.ensureRCONPassword(ctx, server.ID)
+	if err != nil {
+		s.serverRepo.Delete(ctx, server.ID)
+		return nil, fmt.Errorf("failed to create RCON password: %w", err)
+	}
+
+	// The world is in place before the node installs the server, so the
+	// server starts with it instead of generating a new one
+	if req.World != nil {
+		if err := s.seedWorld(ctx, server, req.World); err != nil {
+			s.serverRepo.Delete(ctx, server.ID)
+			s.nodeMgr.RemoveServerData(context.Background(), server.NodeID, server.ID)
+			return nil, err
+		}
+	}
+
 	// Send create command to node
 	cmd := &node.Command{
 		ID:   generateCommandID(),
 		Type: node.CommandTypeCreateServer,
 		Payload: map[string]interface{}{
 			"server_id":     server.ID,
-			"game_type":    req.GameType,
+			"game_type":     req.GameType,
 			"config":        req.Config,
 			"requirements":  req.Requirements,
+			"rcon_password": rconPassword,
+			"config_files":  configFiles(server, rconPassword),
 		},
 		Response: make(chan *node.CommandResult, 1),
 	}
@@ -94,7 +229,7 @@ func (s *Scheduler) CreateServer(ctx context.Context, req *models.CreateServerRe
 			s.serverRepo.Delete(ctx, server.ID)
 			return nil, fmt.Errorf("failed to create server on node: %s", result.Message)
 		}
-	case <-time.After(60 * time.Second):
+	case <-time.After(createServerTimeout):
 		return nil, fmt.Errorf("timeout waiting for server creation")
 	}
 
@@ -104,9 +239,9 @@ func (s *Scheduler) CreateServer(ctx context.Context, req *models.CreateServerRe
 		zap.String("game_type", req.GameType))
 
 	return &models.CreateServerResponse{
-		Success:   true,
-		ServerID:  server.ID,
-		Message:   "Server created successfully",
+		Success:  true,
+		ServerID: server.ID,
+		Message:  "Server created successfully",
 		ServerInfo: &models.ServerInfo{
 			ServerID:  server.ID,
 			NodeID:    targetNode.ID,
@@ -125,6 +260,17 @@ func (s *Scheduler) UpdateServer(ctx context.Context, serverID string, req model
 
 	// Update server fields
 	if req.Config != nil {
+		settings, envVars, err := s.gameTypes.Validate(server.GameType, req.Config.Settings, req.Config.EnvVars)
+		if err != nil {
+			return err
+		}
+		req.Config.Settings = settings
+		req.Config.EnvVars = envVars
+
+		if err := validateServerConfig(server.GameType, req.Config); err != nil {
+			return err
+		}
+
 		server.Name = req.Config.Name
 		server.Version = req.Config.Version
 		server.Settings = req.Config.Settings
@@ -132,6 +278,8 @@ func (s *Scheduler) UpdateServer(ctx context.Context, serverID string, req model
 		server.MaxPlayers = req.Config.MaxPlayers
 		server.WorldName = req.Config.WorldName
 		server.OnlineMode = req.Config.OnlineMode
+		server.QueryType = req.Config.QueryType
+		server.IdlePolicy = req.Config.IdlePolicy
 	}
 
 	// Save to database
@@ -139,9 +287,39 @@ func (s *Scheduler) UpdateServer(ctx context.Context, serverID string, req model
 		return fmt.Errorf("failed to update server: %w", err)
 	}
 
-	// Send update command to node if server is running
+	// Ship the new configuration to the node
+	if req.Config != nil {
+		rconPassword, err := s.ensureRCONPassword(ctx, serverID)
+		if err != nil {
+			return fmt.Errorf("failed to get RCON password: %w", err)
+		}
+
+		cmd := &node.Command{
+			ID:   generateCommandID(),
+			Type: node.CommandTypeUpdateServer,
+			Payload: map[string]interface{}{
+				"server_id":        serverID,
+				"config":           req.Config,
+				"rcon_password":    rconPassword,
+				"config_files":     configFiles(server, rconPassword),
+				"restart_required": req.Restart,
+			},
+			Response: make(chan *node.CommandResult, 1),
+		}
+
+		if err := s.nodeMgr.SendCommand(server.NodeID, cmd); err != nil {
+			s.logger.Warn("Failed to send update command",
+				zap.Error(err),
+				zap.String("server_id", serverID))
+		}
+	}
+
+	// Restart the server if requested and running, waiting for the node so
+	// that a failed restart is reported to the caller
 	if server.Status == models.ServerStatusRunning && req.Restart {
-		return s.RestartServer(ctx, serverID)
+		if err := s.RestartServer(ctx, serverID, models.StopOptions{}); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -159,7 +337,7 @@ func (s *Scheduler) DeleteServer(ctx context.Context, serverID string, backup bo
 		ID:   generateCommandID(),
 		Type: node.CommandTypeDeleteServer,
 		Payload: map[string]interface{}{
-			"server_id":         serverID,
+			"server_id":            serverID,
 			"backup_before_delete": backup,
 		},
 		Response: make(chan *node.CommandResult, 1),
@@ -186,6 +364,13 @@ func (s *Scheduler) StartServer(ctx context.Context, serverID string) error {
 		return fmt.Errorf("server not found: %w", err)
 	}
 
+	if server.Status == models.ServerStatusMigrating {
+		return fmt.Errorf("server is being migrated: %s", serverID)
+	}
+	if err := s.checkNodeAvailable(server.NodeID); err != nil {
+		return err
+	}
+
 	// Update status
 	if err := s.serverRepo.UpdateStatus(ctx, serverID, models.ServerStatusStarting); err != nil {
 		return fmt.Errorf("failed to update status: %w", err)
@@ -202,10 +387,22 @@ func (s *Scheduler) StartServer(ctx context.Context, serverID string) error {
 	}
 
 	if err := s.nodeMgr.SendCommand(server.NodeID, cmd); err != nil {
-		s.serverRepo.UpdateStatus(ctx, serverID, models.ServerStatusStopped)
+		// A hibernating server keeps its hibernation state until it starts
+		previous := models.ServerStatusStopped
+		if server.Status == models.ServerStatusHibernating {
+			previous = models.ServerStatusHibernating
+		}
+		s.serverRepo.UpdateStatus(ctx, serverID, previous)
 		return fmt.Errorf("failed to send start command: %w", err)
 	}
 
+	// Starting a hibernating server wakes it
+	if server.Status == models.ServerStatusHibernating {
+		if err := s.serverRepo.ClearHibernated(ctx, serverID); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -239,19 +436,6 @@ func (s *Scheduler) StopServer(ctx context.Context, serverID string) error {
 	return nil
 }
 
-// RestartServer restarts a server
-func (s *Scheduler) RestartServer(ctx context.Context, serverID string) error {
-	// Stop then start
-	if err := s.StopServer(ctx, serverID); err != nil {
-		return err
-	}
-
-	// Wait for stop
-	time.Sleep(5 * time.Second)
-
-	return s.StartServer(ctx, serverID)
-}
-
 // ReinstallServer reinstalls a server
 func (s *Scheduler) ReinstallServer(ctx context.Context, serverID string) error {
 	server, err := s.serverRepo.GetByID(ctx, serverID)
@@ -269,8 +453,8 @@ func (s *Scheduler) ReinstallServer(ctx context.Context, serverID string) error
 		ID:   generateCommandID(),
 		Type: node.CommandTypeDeleteServer,
 		Payload: map[string]interface{}{
-			"server_id":     serverID,
-			"reinstall":     true,
+			"server_id":    serverID,
+			"reinstall":    true,
 			"backup_first": true,
 		},
 		Response: make(chan *node.CommandResult, 1),
@@ -327,6 +511,23 @@ func (s *Scheduler) FindOptimalNode(gameType string, requirements *models.Resour
 	return filtered[0], nil
 }
 
+// findNodeOnHost finds an online node for a game type on a container host,
+// skipping the nodes in exclude
+func (s *Scheduler) findNodeOnHost(gameType, hostID string, exclude []string) (*models.Node, error) {
+	nodes, err := s.nodeMgr.ListNodes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	for _, n := range nodes {
+		if n.Status == models.NodeStatusOnline && n.GameType == gameType && n.HostID == hostID && !slices.Contains(exclude, n.ID) {
+			return n, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no suitable node found for game type %s on host %s", gameType, hostID)
+}
+
 // AllocateResources is a no-op since we removed resource tracking
 func (s *Scheduler) AllocateResources(nodeID string, requirements *models.ResourceRequirements) error {
 	// No-op - resource tracking removed
@@ -373,3 +574,38 @@ func (s *Scheduler) GetServerCounts() (map[models.ServerStatus]int, error) {
 func generateCommandID() string {
 	return fmt.Sprintf("cmd-%d", time.Now().UnixNano())
 }
+
+// validateServerConfig checks the config fields that are not covered by the
+// game type schemas
+func validateServerConfig(gameType string, config *models.ServerConfig) error {
+	var errs []gametype.FieldError
+
+	if !query.IsValidQueryType(config.QueryType) {
+		errs = append(errs, gametype.FieldError{
+			Field:   "config.query_type",
+			Message: fmt.Sprintf("must be one of %q, %q or %q", query.ProtocolMinecraft, query.ProtocolGameSpy4, query.ProtocolNone),
+		})
+	}
+
+	if policy := config.IdlePolicy; policy != nil {
+		if policy.IdleMinutes < 0 {
+			errs = append(errs, gametype.FieldError{
+				Field:   "config.idle_policy.idle_minutes",
+				Message: "must be at least 0",
+			})
+		}
+		for i, wakeTime := range policy.WakeTimes {
+			if _, err := time.Parse(wakeTimeFormat, wakeTime); err != nil {
+				errs = append(errs, gametype.FieldError{
+					Field:   fmt.Sprintf("config.idle_policy.wake_times[%d]", i),
+					Message: "must be a time of day in HH:MM format",
+				})
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return &gametype.ValidationError{GameType: gameType, Errors: errs}
+	}
+	return nil
+}
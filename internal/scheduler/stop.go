@@ -0,0 +1,284 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/game-server/controller/internal/core/models"
+	"github.com/game-server/controller/internal/gametype"
+	"github.com/game-server/controller/internal/node"
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultStopTimeout is how long to wait for a node to confirm a stop
+	// before the server is killed
+	defaultStopTimeout = 60 * time.Second
+	// forceStopTimeout is how long to wait for a node to confirm a kill
+	forceStopTimeout = 30 * time.Second
+	// defaultStopMessage is broadcast during a countdown
+	defaultStopMessage = "Server is stopping in {seconds} seconds"
+)
+
+// countdownAnnouncements are the remaining seconds at which a countdown is broadcast
+var countdownAnnouncements = []int{300, 120, 60, 30, 10, 5, 4, 3, 2, 1}
+
+// errWaitTimeout is returned by sendAndWait when the node does not confirm in time
+var errWaitTimeout = errors.New("timed out waiting for node confirmation")
+
+// eventWaiter receives server events of one type for one server
+type eventWaiter struct {
+	eventType models.EventType
+	ch        chan *models.ServerEvent
+}
+
+// GracefulStopDuration returns an upper bound for how long a graceful stop
+// with the given options can take
+func GracefulStopDuration(opts models.StopOptions) time.Duration {
+	return time.Duration(opts.CountdownSeconds)*time.Second + stopTimeout(opts) + forceStopTimeout + 2*rconTimeout
+}
+
+// StopServerGracefully announces the stop to players, saves the world and
+// waits for the node to confirm the stop. The server is killed if it does
+// not stop within the timeout.
+func (s *Scheduler) StopServerGracefully(ctx context.Context, serverID string, opts models.StopOptions) error {
+	server, def, err := s.getServerDefinition(ctx, serverID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.serverRepo.UpdateStatus(ctx, serverID, models.ServerStatusStopping); err != nil {
+		return fmt.Errorf("failed to update status: %w", err)
+	}
+
+	if err := s.prepareStop(ctx, server, def, opts); err != nil {
+		s.serverRepo.UpdateStatus(ctx, serverID, server.Status)
+		return err
+	}
+
+	if err := s.stopAndWait(ctx, server, def, opts); err != nil {
+		return err
+	}
+
+	if err := s.serverRepo.UpdateStatus(ctx, serverID, models.ServerStatusStopped); err != nil {
+		return fmt.Errorf("failed to update status: %w", err)
+	}
+
+	s.logger.Info("Server stopped gracefully", zap.String("server_id", serverID))
+
+	return nil
+}
+
+// getServerDefinition returns a server and its game type definition. Servers
+// of unknown game types get an empty definition.
+func (s *Scheduler) getServerDefinition(ctx context.Context, serverID string) (*models.Server, *gametype.Definition, error) {
+	server, err := s.serverRepo.GetByID(ctx, serverID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get server: %w", err)
+	}
+	if server == nil {
+		return nil, nil, fmt.Errorf("server not found: %s", serverID)
+	}
+
+	def, err := s.gameTypes.Get(server.GameType)
+	if err != nil {
+		def = &gametype.Definition{}
+	}
+
+	return server, def, nil
+}
+
+// prepareStop runs the countdown and saves the world if the options ask for a
+// graceful stop. Players and the world can only be reached while the server
+// is running.
+func (s *Scheduler) prepareStop(ctx context.Context, server *models.Server, def *gametype.Definition, opts models.StopOptions) error {
+	if !opts.Graceful || server.Status != models.ServerStatusRunning {
+		return nil
+	}
+
+	if opts.CountdownSeconds > 0 && def.BroadcastCommand != "" {
+		if err := s.countdown(ctx, server, def, opts); err != nil {
+			return fmt.Errorf("stop countdown interrupted: %w", err)
+		}
+	}
+
+	if !opts.SkipSave && def.SaveCommand != "" {
+		if _, err := s.ExecuteRCON(ctx, server.ID, def.SaveCommand); err != nil {
+			s.logger.Warn("Failed to save world before stop",
+				zap.Error(err),
+				zap.String("server_id", server.ID))
+		}
+	}
+
+	return nil
+}
+
+// stopAndWait sends a stop command and waits for the node to confirm it,
+// killing the server if it does not stop within the timeout. On failure the
+// server status is restored or set to error.
+func (s *Scheduler) stopAndWait(ctx context.Context, server *models.Server, def *gametype.Definition, opts models.StopOptions) error {
+	cmd := &node.Command{
+		ID:   generateCommandID(),
+		Type: node.CommandTypeStopServer,
+		Payload: map[string]interface{}{
+			"server_id":       server.ID,
+			"stop_command":    def.StopCommand,
+			"timeout_seconds": int(stopTimeout(opts).Seconds()),
+		},
+		Response: make(chan *node.CommandResult, 1),
+	}
+
+	err := s.sendAndWait(ctx, server.NodeID, cmd, server.ID, models.EventTypeServerStopped, stopTimeout(opts))
+	if errors.Is(err, errWaitTimeout) {
+		s.logger.Warn("Server did not stop in time, killing it",
+			zap.String("server_id", server.ID),
+			zap.Duration("timeout", stopTimeout(opts)))
+
+		cmd = &node.Command{
+			ID:   generateCommandID(),
+			Type: node.CommandTypeStopServer,
+			Payload: map[string]interface{}{
+				"server_id": server.ID,
+				"force":     true,
+			},
+			Response: make(chan *node.CommandResult, 1),
+		}
+		if err := s.sendAndWait(ctx, server.NodeID, cmd, server.ID, models.EventTypeServerStopped, forceStopTimeout); err != nil {
+			s.serverRepo.UpdateStatus(ctx, server.ID, models.ServerStatusError)
+			return fmt.Errorf("failed to kill server: %w", err)
+		}
+	} else if err != nil {
+		s.serverRepo.UpdateStatus(ctx, server.ID, server.Status)
+		return err
+	}
+
+	return nil
+}
+
+// countdown broadcasts the remaining time until the stop to players
+func (s *Scheduler) countdown(ctx context.Context, server *models.Server, def *gametype.Definition, opts models.StopOptions) error {
+	message := opts.Message
+	if message == "" {
+		message = defaultStopMessage
+	}
+
+	remaining := opts.CountdownSeconds
+	for remaining > 0 {
+		text := strings.ReplaceAll(message, "{seconds}", strconv.Itoa(remaining))
+		command := strings.ReplaceAll(def.BroadcastCommand, "{message}", text)
+		if _, err := s.ExecuteRCON(ctx, server.ID, command); err != nil {
+			// Without RCON nobody can be warned, so skip the rest of the countdown
+			s.logger.Warn("Failed to broadcast stop countdown",
+				zap.Error(err),
+				zap.String("server_id", server.ID))
+			return nil
+		}
+
+		next := 0
+		for _, announcement := range countdownAnnouncements {
+			if announcement < remaining {
+				next = announcement
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Duration(remaining-next) * time.Second):
+		}
+		remaining = next
+	}
+
+	return nil
+}
+
+// sendAndWait sends a command to a node and waits until the node answers the
+// command or emits eventType for the server. A server_error event for the
+// server fails the wait.
+func (s *Scheduler) sendAndWait(ctx context.Context, nodeID string, cmd *node.Command, serverID string, eventType models.EventType, timeout time.Duration) error {
+	events, cancel := s.waitForServerEvent(serverID, eventType)
+	defer cancel()
+	failures, cancelFailures := s.waitForServerEvent(serverID, models.EventTypeServerError)
+	defer cancelFailures()
+
+	if err := s.nodeMgr.SendCommand(nodeID, cmd); err != nil {
+		return fmt.Errorf("failed to send %s command: %w", cmd.Type, err)
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case result := <-cmd.Response:
+		if !result.Success {
+			return fmt.Errorf("node failed to %s: %s", strings.ReplaceAll(string(cmd.Type), "_", " "), result.Message)
+		}
+		return nil
+	case <-events:
+		return nil
+	case event := <-failures:
+		return fmt.Errorf("server reported an error: %s", event.Message)
+	case <-timer.C:
+		return errWaitTimeout
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// waitForServerEvent registers a waiter for the next event of a type for a
+// server. The returned function must be called to unregister it.
+func (s *Scheduler) waitForServerEvent(serverID string, eventType models.EventType) (<-chan *models.ServerEvent, func()) {
+	waiter := &eventWaiter{
+		eventType: eventType,
+		ch:        make(chan *models.ServerEvent, 1),
+	}
+
+	s.waitersMu.Lock()
+	s.waiters[serverID] = append(s.waiters[serverID], waiter)
+	s.waitersMu.Unlock()
+
+	return waiter.ch, func() {
+		s.waitersMu.Lock()
+		defer s.waitersMu.Unlock()
+
+		waiters := s.waiters[serverID]
+		for i, w := range waiters {
+			if w == waiter {
+				s.waiters[serverID] = append(waiters[:i], waiters[i+1:]...)
+				break
+			}
+		}
+		if len(s.waiters[serverID]) == 0 {
+			delete(s.waiters, serverID)
+		}
+	}
+}
+
+// notifyServerEvent wakes up waiters for a server event
+func (s *Scheduler) notifyServerEvent(eventType models.EventType, event *models.ServerEvent) {
+	s.waitersMu.Lock()
+	defer s.waitersMu.Unlock()
+
+	for _, waiter := range s.waiters[event.ServerID] {
+		if waiter.eventType != eventType {
+			continue
+		}
+		select {
+		case waiter.ch <- event:
+		default:
+		}
+	}
+}
+
+// stopTimeout returns the stop timeout from the options or the default
+func stopTimeout(opts models.StopOptions) time.Duration {
+	if opts.TimeoutSeconds > 0 {
+		return time.Duration(opts.TimeoutSeconds) * time.Second
+	}
+	return defaultStopTimeout
+}
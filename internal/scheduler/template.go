@@ -0,0 +1,268 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/game-server/controller/internal/core/models"
+	"github.com/game-server/controller/internal/gametype"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// worldCopyTimeout bounds copying, saving or restoring a world
+const worldCopyTimeout = 30 * time.Minute
+
+var (
+	// ErrTemplateNotFound is returned when a template does not exist
+	ErrTemplateNotFound = errors.New("template not found")
+	// ErrInvalidTemplate is returned for a template request that mixes or
+	// lacks its sources
+	ErrInvalidTemplate = errors.New("invalid template")
+	// ErrMissingConfig is returned when creating a server with neither a
+	// config nor a template
+	ErrMissingConfig = errors.New("game_type and config, or template_id, are required")
+)
+
+// CreateServerDuration returns an upper bound for how long creating a server
+// can take, with or without seeding its world
+func CreateServerDuration(withWorld bool) time.Duration {
+	if withWorld {
+		return worldCopyTimeout + createServerTimeout
+	}
+	return createServerTimeout
+}
+
+// CreateTemplate saves a template from a game type and config, or from the
+// config of an existing server. With IncludeWorld the server's world is
+// archived too; a running server saves its world first.
+func (s *Scheduler) CreateTemplate(ctx context.Context, req *models.CreateTemplateRequest) (*models.ServerTemplate, error) {
+	template := &models.ServerTemplate{Name: req.Name}
+	if req.Requirements != nil {
+		template.Requirements = *req.Requirements
+	}
+
+	if req.ServerID == "" {
+		if req.GameType == "" || req.Config == nil {
+			return nil, fmt.Errorf("%w: game_type and config, or server_id, are required", ErrInvalidTemplate)
+		}
+		if req.IncludeWorld {
+			return nil, fmt.Errorf("%w: include_world requires server_id", ErrInvalidTemplate)
+		}
+		if err := s.validateFleetConfig(req.GameType, req.Config); err != nil {
+			return nil, err
+		}
+		template.GameType = req.GameType
+		template.Config = *req.Config
+
+		if err := s.templateRepo.Create(ctx, template); err != nil {
+			return nil, err
+		}
+		return template, nil
+	}
+
+	if req.GameType != "" || req.Config != nil {
+		return nil, fmt.Errorf("%w: server_id replaces game_type and config", ErrInvalidTemplate)
+	}
+
+	server, def, err := s.getServerDefinition(ctx, req.ServerID)
+	if err != nil {
+		return nil, err
+	}
+	template.GameType = server.GameType
+	template.Config = serverConfigOf(server)
+	template.SourceServerID = server.ID
+
+	if req.IncludeWorld {
+		source, err := s.nodeMgr.GetNode(server.NodeID)
+		if err != nil {
+			return nil, err
+		}
+
+		// The archive is saved under the template ID before the row exists
+		template.ID = uuid.New().String()
+		template.HasWorld = true
+		template.HostID = source.HostID
+
+		s.flushWorld(ctx, server, def)
+
+		copyCtx, cancel := context.WithTimeout(ctx, worldCopyTimeout)
+		err = s.nodeMgr.SaveTemplateWorld(copyCtx, worldNameOf(server), server.NodeID, server.ID, template.ID)
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.templateRepo.Create(ctx, template); err != nil {
+		if template.HasWorld {
+			s.removeTemplateWorld(template.ID)
+		}
+		return nil, err
+	}
+
+	return template, nil
+}
+
+// GetTemplate returns a template
+func (s *Scheduler) GetTemplate(ctx context.Context, templateID string) (*models.ServerTemplate, error) {
+	return s.getTemplate(ctx, templateID)
+}
+
+// ListTemplates returns all templates
+func (s *Scheduler) ListTemplates(ctx context.Context) ([]*models.ServerTemplate, error) {
+	return s.templateRepo.List(ctx)
+}
+
+// DeleteTemplate deletes a template and its world archive. Servers created
+// from it are not affected.
+func (s *Scheduler) DeleteTemplate(ctx context.Context, templateID string) error {
+	template, err := s.getTemplate(ctx, templateID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.templateRepo.Delete(ctx, templateID); err != nil {
+		return err
+	}
+
+	if template.HasWorld {
+		s.removeTemplateWorld(templateID)
+	}
+
+	s.logger.Info("Template deleted",
+		zap.String("template_id", templateID),
+		zap.String("name", template.Name))
+
+	return nil
+}
+
+// CloneServer creates a server with the config of another one, on whichever
+// node fits it best. With IncludeWorld the new server starts with a copy of
+// the source's world, and is placed on a node of the source's host; a
+// running source saves its world first.
+func (s *Scheduler) CloneServer(ctx context.Context, serverID string, req models.CloneServerRequest) (*models.CreateServerResponse, error) {
+	server, _, err := s.getServerDefinition(ctx, serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	config := serverConfigOf(server)
+	config.Name = req.Name
+
+	create := &models.CreateServerRequest{
+		GameType:     server.GameType,
+		Config:       &config,
+		Requirements: req.Requirements,
+	}
+	if req.IncludeWorld {
+		source, err := s.nodeMgr.GetNode(server.NodeID)
+		if err != nil {
+			return nil, err
+		}
+		create.World = &models.WorldSource{
+			HostID:    source.HostID,
+			ServerID:  server.ID,
+			NodeID:    server.NodeID,
+			WorldName: worldNameOf(server),
+		}
+	}
+
+	result, err := s.CreateServer(ctx, create)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Server cloned",
+		zap.String("source_server_id", serverID),
+		zap.String("server_id", result.ServerID),
+		zap.Bool("include_world", req.IncludeWorld))
+
+	return result, nil
+}
+
+// applyTemplate fills a create request from its template
+func (s *Scheduler) applyTemplate(ctx context.Context, req *models.CreateServerRequest) error {
+	if req.GameType != "" || req.Config != nil {
+		return fmt.Errorf("%w: template_id replaces game_type and config", ErrInvalidTemplate)
+	}
+
+	template, err := s.getTemplate(ctx, req.TemplateID)
+	if err != nil {
+		return err
+	}
+
+	config := template.Config
+	req.GameType = template.GameType
+	req.Config = &config
+	if req.Requirements == nil {
+		requirements := template.Requirements
+		req.Requirements = &requirements
+	}
+	if template.HasWorld {
+		req.World = &models.WorldSource{HostID: template.HostID, TemplateID: template.ID}
+	}
+
+	return nil
+}
+
+// seedWorld puts a world into a new server's data directory
+func (s *Scheduler) seedWorld(ctx context.Context, server *models.Server, source *models.WorldSource) error {
+	copyCtx, cancel := context.WithTimeout(ctx, worldCopyTimeout)
+	defer cancel()
+
+	if source.TemplateID != "" {
+		return s.nodeMgr.RestoreTemplateWorld(copyCtx, source.TemplateID, server.NodeID, server.ID)
+	}
+
+	from, def, err := s.getServerDefinition(ctx, source.ServerID)
+	if err != nil {
+		return err
+	}
+	s.flushWorld(ctx, from, def)
+
+	return s.nodeMgr.CopyServerWorld(copyCtx, source.WorldName, source.NodeID, source.ServerID, server.NodeID, server.ID)
+}
+
+// flushWorld saves the world of a running server so a copy of it is current
+func (s *Scheduler) flushWorld(ctx context.Context, server *models.Server, def *gametype.Definition) {
+	if server.Status != models.ServerStatusRunning || def.SaveCommand == "" {
+		return
+	}
+
+	if _, err := s.ExecuteRCON(ctx, server.ID, def.SaveCommand); err != nil {
+		s.logger.Warn("Failed to save world before copying it",
+			zap.Error(err),
+			zap.String("server_id", server.ID))
+	}
+}
+
+// removeTemplateWorld deletes a template's world archive, logging failures
+func (s *Scheduler) removeTemplateWorld(templateID string) {
+	if err := s.nodeMgr.RemoveTemplateWorld(context.Background(), templateID); err != nil {
+		s.logger.Warn("Failed to remove template world",
+			zap.Error(err),
+			zap.String("template_id", templateID))
+	}
+}
+
+func (s *Scheduler) getTemplate(ctx context.Context, templateID string) (*models.ServerTemplate, error) {
+	template, err := s.templateRepo.GetByID(ctx, templateID)
+	if err != nil {
+		return nil, err
+	}
+	if template == nil {
+		return nil, ErrTemplateNotFound
+	}
+	return template, nil
+}
+
+// worldNameOf returns the name of a server's world directory
+func worldNameOf(server *models.Server) string {
+	if server.WorldName == "" {
+		return "world"
+	}
+	return server.WorldName
+}
@@ -0,0 +1,180 @@
+package sessions
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/game-server/controller/internal/core/models"
+)
+
+// dateFormat is the format of days and weeks in analytics results
+const dateFormat = "2006-01-02"
+
+// DailyPeak is the highest number of players online at once during a day (UTC)
+type DailyPeak struct {
+	Date        string `json:"date"`
+	PeakPlayers int    `json:"peak_players"`
+}
+
+// WeeklyUniquePlayers is the number of distinct players during a week
+// starting on Monday (UTC)
+type WeeklyUniquePlayers struct {
+	WeekStart     string `json:"week_start"`
+	UniquePlayers int    `json:"unique_players"`
+}
+
+// DailyPeaks returns the peak concurrent players of a server for each of the
+// last days, oldest first
+func (t *Tracker) DailyPeaks(ctx context.Context, serverID string, days int) ([]DailyPeak, error) {
+	now := time.Now().UTC()
+	from := truncateDay(now).AddDate(0, 0, -(days - 1))
+	to := from.AddDate(0, 0, days)
+
+	sessions, err := t.sessionRepo.ListOverlapping(ctx, serverID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	type change struct {
+		at    time.Time
+		delta int
+	}
+	var changes []change
+	for _, session := range sessions {
+		start, end := clampSession(session, from, now)
+		if !end.After(start) {
+			continue
+		}
+		changes = append(changes, change{at: start, delta: 1})
+		if end.Before(now) {
+			changes = append(changes, change{at: end, delta: -1})
+		}
+	}
+	// Leaves sort before joins at the same instant so a player switching
+	// places with another is not counted twice
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].at.Equal(changes[j].at) {
+			return changes[i].delta < changes[j].delta
+		}
+		return changes[i].at.Before(changes[j].at)
+	})
+
+	peaks := make([]DailyPeak, days)
+	level, next := 0, 0
+	for i := range peaks {
+		day := from.AddDate(0, 0, i)
+		dayEnd := day.AddDate(0, 0, 1)
+
+		peak := level
+		for next < len(changes) && changes[next].at.Before(dayEnd) {
+			level += changes[next].delta
+			if level > peak {
+				peak = level
+			}
+			next++
+		}
+
+		peaks[i] = DailyPeak{Date: day.Format(dateFormat), PeakPlayers: peak}
+	}
+
+	return peaks, nil
+}
+
+// WeeklyUniquePlayers returns the number of distinct players of a server for
+// each of the last weeks, oldest first
+func (t *Tracker) WeeklyUniquePlayers(ctx context.Context, serverID string, weeks int) ([]WeeklyUniquePlayers, error) {
+	now := time.Now().UTC()
+	from := truncateWeek(now).AddDate(0, 0, -7*(weeks-1))
+	to := from.AddDate(0, 0, 7*weeks)
+
+	sessions, err := t.sessionRepo.ListOverlapping(ctx, serverID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	players := make([]map[string]bool, weeks)
+	for i := range players {
+		players[i] = make(map[string]bool)
+	}
+
+	for _, session := range sessions {
+		start, end := clampSession(session, from, now)
+		for i := range players {
+			weekStart := from.AddDate(0, 0, 7*i)
+			weekEnd := weekStart.AddDate(0, 0, 7)
+			if start.Before(weekEnd) && !end.Before(weekStart) {
+				players[i][session.PlayerName] = true
+			}
+		}
+	}
+
+	result := make([]WeeklyUniquePlayers, weeks)
+	for i := range result {
+		result[i] = WeeklyUniquePlayers{
+			WeekStart:     from.AddDate(0, 0, 7*i).Format(dateFormat),
+			UniquePlayers: len(players[i]),
+		}
+	}
+
+	return result, nil
+}
+
+// Usage returns player activity of every server over the last days, most
+// played first. Servers without players are included so unused servers
+// can be found.
+func (t *Tracker) Usage(ctx context.Context, days int) ([]*models.ServerUsage, error) {
+	from := time.Now().AddDate(0, 0, -days)
+
+	usage, err := t.sessionRepo.UsageSince(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+
+	servers, err := t.serverRepo.List(ctx, &models.ServerFilters{})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*models.ServerUsage, 0, len(servers))
+	for _, server := range servers {
+		u, exists := usage[server.ID]
+		if !exists {
+			u = &models.ServerUsage{ServerID: server.ID}
+		}
+		u.Name = server.Name
+		result = append(result, u)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].PlaytimeSeconds > result[j].PlaytimeSeconds
+	})
+
+	return result, nil
+}
+
+// clampSession returns the part of a session between from and now
+func clampSession(session *models.PlayerSession, from, now time.Time) (time.Time, time.Time) {
+	start := session.JoinedAt.UTC()
+	if start.Before(from) {
+		start = from
+	}
+
+	end := now
+	if session.LeftAt != nil && session.LeftAt.Before(now) {
+		end = session.LeftAt.UTC()
+	}
+
+	return start, end
+}
+
+func truncateDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// truncateWeek returns the Monday starting the week of t
+func truncateWeek(t time.Time) time.Time {
+	day := truncateDay(t)
+	offset := (int(day.Weekday()) + 6) % 7
+	return day.AddDate(0, 0, -offset)
+}
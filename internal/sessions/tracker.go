@@ -0,0 +1,171 @@
+package sessions
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/game-server/controller/internal/core/models"
+	"github.com/game-server/controller/internal/core/repository"
+	"github.com/game-server/controller/internal/node"
+	"go.uber.org/zap"
+)
+
+// Tracker turns the online player lists reported by nodes into join/leave
+// sessions
+type Tracker struct {
+	sessionRepo *repository.SessionRepository
+	serverRepo  *repository.ServerRepository
+	nodeMgr     *node.Manager
+	logger      *zap.Logger
+
+	// Players with an open session, keyed by server ID. Loaded from the
+	// database the first time a server reports, so restarts do not reopen
+	// sessions.
+	online map[string]map[string]bool
+	mu     sync.Mutex
+}
+
+// NewTracker creates a new session tracker
+func NewTracker(
+	sessionRepo *repository.SessionRepository,
+	serverRepo *repository.ServerRepository,
+	nodeMgr *node.Manager,
+	logger *zap.Logger,
+) *Tracker {
+	return &Tracker{
+		sessionRepo: sessionRepo,
+		serverRepo:  serverRepo,
+		nodeMgr:     nodeMgr,
+		logger:      logger,
+		online:      make(map[string]map[string]bool),
+	}
+}
+
+// Run consumes node events until ctx is cancelled
+func (t *Tracker) Run(ctx context.Context) {
+	events := t.nodeMgr.SubscribeToEvents("sessions")
+	defer t.nodeMgr.UnsubscribeFromEvents(events)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			t.handleEvent(ctx, event)
+		}
+	}
+}
+
+// handleEvent records player lists from metrics and closes sessions when a
+// server stops
+func (t *Tracker) handleEvent(ctx context.Context, event *node.StreamEvent) {
+	var err error
+	serverID := ""
+
+	switch event.Type {
+	case models.EventTypeMetricsUpdate:
+		metrics, ok := event.Payload.(*models.ServerMetrics)
+		if !ok {
+			return
+		}
+		// Agents that do not report names only send a count
+		if metrics.OnlinePlayers == nil && metrics.PlayerCount > 0 {
+			return
+		}
+		serverID = metrics.ServerID
+		err = t.RecordPlayers(ctx, metrics.ServerID, metrics.OnlinePlayers, metrics.Timestamp)
+
+	case models.EventTypeServerStopped:
+		serverEvent, ok := event.Payload.(*models.ServerEvent)
+		if !ok {
+			return
+		}
+		serverID = serverEvent.ServerID
+		err = t.RecordPlayers(ctx, serverEvent.ServerID, nil, event.Timestamp)
+	}
+
+	if err != nil {
+		t.logger.Error("Failed to record player sessions",
+			zap.Error(err),
+			zap.String("server_id", serverID))
+	}
+}
+
+// RecordPlayers diffs the players online on a server against the open
+// sessions, opening sessions for players who joined and closing those of
+// players who left
+func (t *Tracker) RecordPlayers(ctx context.Context, serverID string, players []string, at time.Time) error {
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	current, exists := t.online[serverID]
+	if !exists {
+		open, err := t.sessionRepo.ListOpen(ctx, serverID)
+		if err != nil {
+			return err
+		}
+		current = make(map[string]bool, len(open))
+		for _, session := range open {
+			current[session.PlayerName] = true
+		}
+	}
+
+	reported := make(map[string]bool, len(players))
+	var joined []string
+	for _, player := range players {
+		if player == "" || reported[player] {
+			continue
+		}
+		reported[player] = true
+		if !current[player] {
+			joined = append(joined, player)
+		}
+	}
+
+	var left []string
+	for player := range current {
+		if !reported[player] {
+			left = append(left, player)
+		}
+	}
+	sort.Strings(joined)
+	sort.Strings(left)
+
+	if err := t.sessionRepo.Close(ctx, serverID, left, at); err != nil {
+		return err
+	}
+	if err := t.sessionRepo.Open(ctx, serverID, joined, at); err != nil {
+		return err
+	}
+
+	t.online[serverID] = reported
+
+	if len(joined) > 0 || len(left) > 0 {
+		t.logger.Debug("Player sessions updated",
+			zap.String("server_id", serverID),
+			zap.Strings("joined", joined),
+			zap.Strings("left", left))
+	}
+
+	return nil
+}
+
+// OnlinePlayers returns the open sessions of a server
+func (t *Tracker) OnlinePlayers(ctx context.Context, serverID string) ([]*models.PlayerSession, error) {
+	return t.sessionRepo.ListOpen(ctx, serverID)
+}
+
+// History returns the most recent sessions of a server, optionally only
+// those of one player
+func (t *Tracker) History(ctx context.Context, serverID, player string, limit int) ([]*models.PlayerSession, error) {
+	return t.sessionRepo.ListByPlayer(ctx, serverID, player, limit)
+}
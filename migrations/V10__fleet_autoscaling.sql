@@ -0,0 +1,18 @@
+-- Flyway Migration: V10__fleet_autoscaling.sql
+-- Autoscaling policies for fleets and a log of autoscaler decisions
+-- A NULL autoscale policy keeps the fleet at its configured replicas
+
+ALTER TABLE fleets ADD COLUMN IF NOT EXISTS autoscale TEXT;
+
+CREATE TABLE IF NOT EXISTS fleet_events (
+    id VARCHAR(36) PRIMARY KEY,
+    fleet_id VARCHAR(36) NOT NULL REFERENCES fleets(id) ON DELETE CASCADE,
+    type VARCHAR(32) NOT NULL,
+    from_replicas INTEGER NOT NULL,
+    to_replicas INTEGER NOT NULL,
+    reason TEXT NOT NULL,
+    dry_run BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_fleet_events_fleet_id ON fleet_events(fleet_id, created_at);
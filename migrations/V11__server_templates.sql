@@ -0,0 +1,15 @@
+-- Flyway Migration: V11__server_templates.sql
+-- Saved server templates for creating servers without a full config
+-- A template with has_world set has a world archive in the templates volume
+
+CREATE TABLE IF NOT EXISTS server_templates (
+    id VARCHAR(36) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL UNIQUE,
+    game_type VARCHAR(100) NOT NULL,
+    config TEXT NOT NULL,
+    requirements TEXT NOT NULL,
+    has_world BOOLEAN NOT NULL DEFAULT FALSE,
+    source_server_id VARCHAR(36),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
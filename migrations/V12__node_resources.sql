@@ -0,0 +1,8 @@
+-- Flyway Migration: V12__node_resources.sql
+-- Resource limits of node agent containers
+-- Nodes created before this migration have no limits (0)
+
+ALTER TABLE nodes ADD COLUMN IF NOT EXISTS max_servers INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE nodes ADD COLUMN IF NOT EXISTS cpu_cores INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE nodes ADD COLUMN IF NOT EXISTS memory_mb BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE nodes ADD COLUMN IF NOT EXISTS storage_mb BIGINT NOT NULL DEFAULT 0;
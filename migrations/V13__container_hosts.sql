@@ -0,0 +1,24 @@
+-- Flyway Migration: V13__container_hosts.sql
+-- Docker hosts that node containers can be placed on besides the controller's own
+-- Nodes without a host_id live on the controller's container runtime
+-- tls_key is encrypted by the controller
+
+CREATE TABLE IF NOT EXISTS hosts (
+    id VARCHAR(36) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL UNIQUE,
+    endpoint VARCHAR(255) NOT NULL,
+    tls_ca_cert TEXT,
+    tls_cert TEXT,
+    tls_key TEXT,
+    controller_address VARCHAR(255),
+    network_name VARCHAR(255),
+    max_nodes INTEGER NOT NULL DEFAULT 0,
+    cpu_cores INTEGER NOT NULL DEFAULT 0,
+    memory_mb BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE nodes ADD COLUMN IF NOT EXISTS host_id VARCHAR(36) REFERENCES hosts(id);
+
+CREATE INDEX IF NOT EXISTS idx_nodes_host_id ON nodes(host_id);
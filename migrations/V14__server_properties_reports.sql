@@ -0,0 +1,6 @@
+-- Flyway Migration: V14__server_properties_reports.sql
+-- Keep the server.properties last read from a server's node, with secrets
+-- redacted, for drift detection
+
+ALTER TABLE servers ADD COLUMN IF NOT EXISTS reported_properties TEXT;
+ALTER TABLE servers ADD COLUMN IF NOT EXISTS properties_reported_at TIMESTAMP;
@@ -0,0 +1,9 @@
+-- Flyway Migration: V15__controller_settings.sql
+-- Controller-wide values kept in the database, such as the check value that
+-- detects a secrets key that cannot decrypt the stored secrets
+
+CREATE TABLE IF NOT EXISTS controller_settings (
+    name VARCHAR(100) PRIMARY KEY,
+    value TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,6 @@
+-- Flyway Migration: V16__template_world_host.sql
+-- The container host a template's world archive is saved on. Servers created
+-- from the template are placed on that host, since the archive is restored
+-- with a helper container there. NULL is the controller's own runtime.
+
+ALTER TABLE server_templates ADD COLUMN IF NOT EXISTS host_id VARCHAR(36);
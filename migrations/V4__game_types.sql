@@ -0,0 +1,12 @@
+-- Flyway Migration: V4__game_types.sql
+-- Store game type definitions imported at runtime (e.g. from Pterodactyl eggs)
+-- Built-in game types are defined in code and are not stored here
+
+CREATE TABLE IF NOT EXISTS game_types (
+    id VARCHAR(100) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    source VARCHAR(50) NOT NULL,
+    definition TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,4 @@
+-- Flyway Migration: V5__rcon_passwords.sql
+-- Store a per-server RCON password, encrypted by the controller
+
+ALTER TABLE servers ADD COLUMN IF NOT EXISTS rcon_password TEXT;
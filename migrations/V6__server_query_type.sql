@@ -0,0 +1,5 @@
+-- Flyway Migration: V6__server_query_type.sql
+-- Query protocol used to probe a server independently of its node agent
+-- ('' = default for the game type, 'minecraft', 'gamespy4' or 'none')
+
+ALTER TABLE servers ADD COLUMN IF NOT EXISTS query_type VARCHAR(32) NOT NULL DEFAULT '';
@@ -0,0 +1,15 @@
+-- Flyway Migration: V7__player_sessions.sql
+-- Player join/leave sessions, derived from the online player lists reported by nodes
+-- A session with no left_at is still open
+
+CREATE TABLE IF NOT EXISTS player_sessions (
+    id VARCHAR(36) PRIMARY KEY,
+    server_id VARCHAR(36) NOT NULL REFERENCES servers(id) ON DELETE CASCADE,
+    player_name VARCHAR(64) NOT NULL,
+    joined_at TIMESTAMP NOT NULL,
+    left_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_player_sessions_server_joined ON player_sessions(server_id, joined_at);
+CREATE INDEX IF NOT EXISTS idx_player_sessions_server_player ON player_sessions(server_id, player_name);
+CREATE INDEX IF NOT EXISTS idx_player_sessions_open ON player_sessions(server_id) WHERE left_at IS NULL;
@@ -0,0 +1,7 @@
+-- Flyway Migration: V8__server_hibernation.sql
+-- Idle policy and hibernation state of servers
+-- idle_policy is a JSON document, NULL when hibernation is disabled
+
+ALTER TABLE servers ADD COLUMN IF NOT EXISTS idle_policy TEXT;
+ALTER TABLE servers ADD COLUMN IF NOT EXISTS hibernated_at TIMESTAMP;
+ALTER TABLE servers ADD COLUMN IF NOT EXISTS hibernated_memory_mb BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,21 @@
+-- Flyway Migration: V9__fleets.sql
+-- Fleets of identical servers and the allocation state of their servers
+-- A fleet server is allocated while allocated_at is set
+
+CREATE TABLE IF NOT EXISTS fleets (
+    id VARCHAR(36) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL UNIQUE,
+    game_type VARCHAR(100) NOT NULL,
+    config TEXT NOT NULL,
+    requirements TEXT NOT NULL,
+    replicas INTEGER NOT NULL DEFAULT 0,
+    allocation_ttl_seconds INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE servers ADD COLUMN IF NOT EXISTS fleet_id VARCHAR(36) REFERENCES fleets(id) ON DELETE SET NULL;
+ALTER TABLE servers ADD COLUMN IF NOT EXISTS allocated_at TIMESTAMP;
+ALTER TABLE servers ADD COLUMN IF NOT EXISTS allocation_expires_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_servers_fleet_id ON servers(fleet_id);
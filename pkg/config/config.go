@@ -24,18 +24,53 @@ type Config struct {
 	DatabasePassword string `mapstructure:"DATABASE_PASSWORD"`
 	DatabaseSSLMode string `mapstructure:"DATABASE_SSL_MODE"`
 
+	// Container Runtime Configuration
+	ContainerRuntime string `mapstructure:"CONTAINER_RUNTIME"` // docker or podman
+	PodmanSocket     string `mapstructure:"PODMAN_SOCKET"`     // defaults to the rootless or system socket
+
+	// Host Placement Configuration
+	HostPlacementStrategy string `mapstructure:"HOST_PLACEMENT_STRATEGY"` // spread or pack
+	LocalHostPlacement    bool   `mapstructure:"LOCAL_HOST_PLACEMENT"`    // place new nodes on the controller's own runtime
+
 	// Node Agent Configuration
 	NodeAgentImage  string `mapstructure:"NODE_AGENT_IMAGE"`
 	NodeNetworkName string `mapstructure:"NODE_NETWORK_NAME"`
+	HelperImage     string `mapstructure:"HELPER_IMAGE"` // runs short jobs on node volumes, e.g. copying server data
+
+	// Secrets Configuration
+	SecretsKey     string `mapstructure:"SECRETS_KEY"`      // base64-encoded 32-byte key
+	SecretsKeyFile string `mapstructure:"SECRETS_KEY_FILE"` // used when SECRETS_KEY is empty
 
 	// Node Configuration
 	DefaultHeartbeatInterval int `mapstructure:"DEFAULT_HEARTBEAT_INTERVAL"`
 	NodeTimeout              int `mapstructure:"NODE_TIMEOUT"`
+	// Seconds nodes recovered at startup have to reconnect before they are
+	// marked offline
+	NodeReconnectGracePeriod int `mapstructure:"NODE_RECONNECT_GRACE_PERIOD"`
+
+	// Default node container limits, used when a create request leaves them out
+	DefaultNodeMaxServers int   `mapstructure:"DEFAULT_NODE_MAX_SERVERS"`
+	DefaultNodeCPUCores   int   `mapstructure:"DEFAULT_NODE_CPU_CORES"`
+	DefaultNodeMemoryMB   int64 `mapstructure:"DEFAULT_NODE_MEMORY_MB"`
+	DefaultNodeStorageMB  int64 `mapstructure:"DEFAULT_NODE_STORAGE_MB"`
 
 	// Metrics Configuration
 	MetricsEnabled       bool   `mapstructure:"METRICS_ENABLED"`
 	MetricsInterval      int    `mapstructure:"METRICS_INTERVAL"`
 	MetricsRetentionDays  int   `mapstructure:"METRICS_RETENTION_DAYS"`
+	// Seconds between samples of node container stats, zero to disable
+	ContainerStatsInterval int `mapstructure:"CONTAINER_STATS_INTERVAL"`
+
+	// Query Probe Configuration
+	QueryProbeInterval    int `mapstructure:"QUERY_PROBE_INTERVAL"`    // seconds
+	QueryProbeTimeout     int `mapstructure:"QUERY_PROBE_TIMEOUT"`     // seconds
+	QueryFailureThreshold int `mapstructure:"QUERY_FAILURE_THRESHOLD"` // failed probes before flagging a server
+
+	// Orphan GC Configuration
+	GCEnabled     bool `mapstructure:"GC_ENABLED"`      // look for orphans periodically
+	GCDryRun      bool `mapstructure:"GC_DRY_RUN"`      // only report orphans, never remove them
+	GCInterval    int  `mapstructure:"GC_INTERVAL"`     // seconds
+	GCGracePeriod int  `mapstructure:"GC_GRACE_PERIOD"` // seconds an orphan must be seen before it is removed
 
 	// Logging Configuration
 	LogLevel    string `mapstructure:"LOG_LEVEL"`
@@ -61,13 +96,31 @@ func Load(configPath string) (*Config, error) {
 	v.SetDefault("DB_URL", "localhost:5432")
 	v.SetDefault("DATABASE_NAME", "game_server")
 	v.SetDefault("DATABASE_SSL_MODE", "disable")
+	v.SetDefault("CONTAINER_RUNTIME", "docker")
+	v.SetDefault("HOST_PLACEMENT_STRATEGY", "spread")
+	v.SetDefault("LOCAL_HOST_PLACEMENT", true)
 	v.SetDefault("NODE_AGENT_IMAGE", "nstut/game-server-node:latest")
 	v.SetDefault("NODE_NETWORK_NAME", "nstut-network")
+	v.SetDefault("HELPER_IMAGE", "alpine:3.19")
+	v.SetDefault("SECRETS_KEY_FILE", "./data/secrets.key")
 	v.SetDefault("DEFAULT_HEARTBEAT_INTERVAL", 30)
 	v.SetDefault("NODE_TIMEOUT", 120)
+	v.SetDefault("NODE_RECONNECT_GRACE_PERIOD", 120)
+	v.SetDefault("DEFAULT_NODE_MAX_SERVERS", 10)
+	v.SetDefault("DEFAULT_NODE_CPU_CORES", 2)
+	v.SetDefault("DEFAULT_NODE_MEMORY_MB", 4096)
+	v.SetDefault("DEFAULT_NODE_STORAGE_MB", 20480)
 	v.SetDefault("METRICS_ENABLED", true)
 	v.SetDefault("METRICS_INTERVAL", 5)
 	v.SetDefault("METRICS_RETENTION_DAYS", 30)
+	v.SetDefault("CONTAINER_STATS_INTERVAL", 30)
+	v.SetDefault("QUERY_PROBE_INTERVAL", 30)
+	v.SetDefault("QUERY_PROBE_TIMEOUT", 5)
+	v.SetDefault("QUERY_FAILURE_THRESHOLD", 3)
+	v.SetDefault("GC_ENABLED", false)
+	v.SetDefault("GC_DRY_RUN", true)
+	v.SetDefault("GC_INTERVAL", 600)
+	v.SetDefault("GC_GRACE_PERIOD", 3600)
 	v.SetDefault("LOG_LEVEL", "info")
 	v.SetDefault("LOG_FORMAT", "json")
 	v.SetDefault("CLUSTER_ENABLED", false)
@@ -137,7 +190,39 @@ func (c *Config) GetNodeTimeout() time.Duration {
 	return time.Duration(c.NodeTimeout) * time.Second
 }
 
+// GetNodeReconnectGracePeriod returns the node reconnect grace period as a
+// duration
+func (c *Config) GetNodeReconnectGracePeriod() time.Duration {
+	return time.Duration(c.NodeReconnectGracePeriod) * time.Second
+}
+
 // GetMetricsInterval returns the metrics interval as a duration
 func (c *Config) GetMetricsInterval() time.Duration {
 	return time.Duration(c.MetricsInterval) * time.Second
 }
+
+// GetContainerStatsInterval returns the container stats interval as a
+// duration
+func (c *Config) GetContainerStatsInterval() time.Duration {
+	return time.Duration(c.ContainerStatsInterval) * time.Second
+}
+
+// GetQueryProbeInterval returns the query probe interval as a duration
+func (c *Config) GetQueryProbeInterval() time.Duration {
+	return time.Duration(c.QueryProbeInterval) * time.Second
+}
+
+// GetQueryProbeTimeout returns the query probe timeout as a duration
+func (c *Config) GetQueryProbeTimeout() time.Duration {
+	return time.Duration(c.QueryProbeTimeout) * time.Second
+}
+
+// GetGCInterval returns the orphan GC interval as a duration
+func (c *Config) GetGCInterval() time.Duration {
+	return time.Duration(c.GCInterval) * time.Second
+}
+
+// GetGCGracePeriod returns the orphan GC grace period as a duration
+func (c *Config) GetGCGracePeriod() time.Duration {
+	return time.Duration(c.GCGracePeriod) * time.Second
+}
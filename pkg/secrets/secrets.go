@@ -0,0 +1,149 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// keySize is the AES-256 key size in bytes
+const keySize = 32
+
+// ciphertextPrefix versions the ciphertext format
+const ciphertextPrefix = "v1:"
+
+// keyCheckPlaintext is the plaintext of a key check
+const keyCheckPlaintext = "game-server-controller secrets key check"
+
+// ErrKeyMismatch is returned when a key cannot decrypt a key check made with
+// the key the stored secrets were encrypted with
+var ErrKeyMismatch = errors.New("secrets key does not match the key stored secrets were encrypted with")
+
+// Box encrypts and decrypts secrets stored in the database
+type Box struct {
+	aead cipher.AEAD
+}
+
+// NewBox creates a box from a 32-byte key
+func NewBox(key []byte) (*Box, error) {
+	if len(key) != keySize {
+		return nil, fmt.Errorf("secrets key must be %d bytes, got %d", keySize, len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM: %w", err)
+	}
+
+	return &Box{aead: aead}, nil
+}
+
+// LoadBox creates a box from a base64 key, or from the key file at path if
+// key is empty. A new key file is generated if it does not exist yet.
+func LoadBox(key string, path string) (*Box, error) {
+	if key != "" {
+		decoded, err := base64.StdEncoding.DecodeString(key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode secrets key: %w", err)
+		}
+		return NewBox(decoded)
+	}
+
+	data, err := os.ReadFile(path)
+	if err == nil {
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode secrets key file: %w", err)
+		}
+		return NewBox(decoded)
+	}
+	if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read secrets key file: %w", err)
+	}
+
+	// Generate a key on first start
+	newKey := make([]byte, keySize)
+	if _, err := rand.Read(newKey); err != nil {
+		return nil, fmt.Errorf("failed to generate secrets key: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create secrets key directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(base64.StdEncoding.EncodeToString(newKey)+"\n"), 0600); err != nil {
+		return nil, fmt.Errorf("failed to write secrets key file: %w", err)
+	}
+
+	return NewBox(newKey)
+}
+
+// Encrypt encrypts plaintext and returns an encoded ciphertext
+func (b *Box) Encrypt(plaintext string) (string, error) {
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	sealed := b.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return ciphertextPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt decrypts a ciphertext produced by Encrypt
+func (b *Box) Decrypt(ciphertext string) (string, error) {
+	if !strings.HasPrefix(ciphertext, ciphertextPrefix) {
+		return "", fmt.Errorf("unsupported ciphertext format")
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(ciphertext, ciphertextPrefix))
+	if err != nil {
+		return "", fmt.Errorf("failed to decode ciphertext: %w", err)
+	}
+
+	nonceSize := b.aead.NonceSize()
+	if len(sealed) < nonceSize {
+		return "", fmt.Errorf("ciphertext too short")
+	}
+
+	plaintext, err := b.aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt: %w", err)
+	}
+
+	return string(plaintext), nil
+}
+
+// KeyCheck returns a value to store next to the encrypted secrets, so that
+// starting with a different key is detected by VerifyKeyCheck
+func (b *Box) KeyCheck() (string, error) {
+	return b.Encrypt(keyCheckPlaintext)
+}
+
+// VerifyKeyCheck returns ErrKeyMismatch if check was not made with the box's
+// key
+func (b *Box) VerifyKeyCheck(check string) error {
+	plaintext, err := b.Decrypt(check)
+	if err != nil || plaintext != keyCheckPlaintext {
+		return ErrKeyMismatch
+	}
+	return nil
+}
+
+// GeneratePassword returns a random URL-safe password
+func GeneratePassword() (string, error) {
+	buf := make([]byte, 18)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate password: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}